@@ -1,45 +1,631 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/certificate"
+	"github.com/Taluu/media-go/pkg/domain/media"
 	"github.com/Taluu/media-go/pkg/domain/media/adapters"
 	"github.com/Taluu/media-go/pkg/domain/media/ports"
 	"github.com/Taluu/media-go/pkg/domain/media/services"
+	"github.com/Taluu/media-go/pkg/logging"
+	"github.com/Taluu/media-go/pkg/metrics"
 	"github.com/Taluu/media-go/pkg/middleware"
+	"github.com/Taluu/media-go/pkg/signature"
+	"github.com/Taluu/media-go/pkg/tracing"
 )
 
 func main() {
+	host := flag.String("host", "localhost", "Set the host")
+	port := flag.Uint("port", 8080, "The port to listen to")
+	stripExif := flag.Bool("strip-exif", false, "Strip GPS and personal EXIF data from uploaded images")
+	keepOriginals := flag.Bool("keep-originals", false, "Keep the original images when stripping EXIF data")
+	md5Checksums := flag.Bool("md5", false, "Also compute the MD5 checksums of the medias, for S3 compatibility")
+	scrubInterval := flag.Duration("scrub-interval", 0, "Interval between two verifications of the stored medias, 0 to disable")
+	batchWorkers := flag.Int("batch-workers", 4, "Number of medias of a batch created concurrently")
+	importTimeout := flag.Duration("import-timeout", 30*time.Second, "Maximum duration of the download of an imported media")
+	importMaxSize := flag.Int64("import-max-size", 32<<20, "Maximum size in bytes of an imported media")
+	importMaxRedirects := flag.Int("import-max-redirects", 5, "Maximum number of redirects followed when importing a media, -1 to disallow them")
+	importAllowPrivate := flag.Bool("import-allow-private", false, "Allow to import medias from private, loopback or link local addresses")
+	signingKeys := flag.String("signing-keys", "", "Comma separated keys signing the presigned urls, the first one being used to sign")
+	presignExpiration := flag.Duration("presign-expiration", 15*time.Minute, "Duration during which the presigned urls are valid")
+	storageDir := flag.String("storage-dir", "", "Directory the content of the medias is stored in, empty to keep it in memory")
+	storageMaxSize := flag.Int64("storage-max-size", 32<<20, "Maximum size in bytes of a media uploaded through a presigned url, 0 for no limit")
+	viewerLinkExpiration := flag.Duration("viewer-link-expiration", 0, "Require signed links to view the medias, valid for this duration, 0 to disable")
+	apiKeysFile := flag.String("api-keys-file", "", "Json file of the api keys and the principals they authenticate")
+	jwtSecret := flag.String("jwt-secret", "", "Secret verifying the HS256 bearer tokens")
+	jwtJWKS := flag.String("jwt-jwks", "", "JSON Web Key Set file verifying the RS256 bearer tokens")
+	jwtIssuer := flag.String("jwt-issuer", "", "Expected issuer of the bearer tokens")
+	jwtAudience := flag.String("jwt-audience", "", "Expected audience of the bearer tokens")
+	authRequired := flag.Bool("auth-required", false, "Reject the anonymous requests")
+	tenantHeader := flag.String("tenant-header", "X-Tenant", "Header choosing the tenant of the requests whose principal has none, empty to disable")
+	rbac := flag.Bool("rbac", false, "Check the permissions of the roles with the default policy")
+	permissionsFile := flag.String("permissions-file", "", "Json file of the roles granted each permission, replacing the default policy")
+	defaultVisibility := flag.String("default-visibility", string(media.VisibilityPublic), "Visibility of the medias created by an authenticated user : private, shared or public")
+	userQuotaBytes := flag.Int64("user-quota-bytes", 0, "Maximum size in bytes of the medias of a user, 0 for no limit")
+	userQuotaMedias := flag.Int64("user-quota-medias", 0, "Maximum number of medias of a user, 0 for no limit")
+	tenantQuotaBytes := flag.Int64("tenant-quota-bytes", 0, "Maximum size in bytes of the medias of a tenant, 0 for no limit")
+	tenantQuotaMedias := flag.Int64("tenant-quota-medias", 0, "Maximum number of medias of a tenant, 0 for no limit")
+	readRateLimit := flag.Int("read-rate-limit", 0, "Maximum number of read requests per minute of a client, 0 for no limit")
+	uploadRateLimit := flag.Int("upload-rate-limit", 0, "Maximum number of upload requests per minute of a client, 0 for no limit")
+	uploadByteRate := flag.Int64("upload-byte-rate", 0, "Maximum number of uploaded bytes per second of a client, 0 for no limit")
+	uploadMaxSize := flag.Int64("upload-max-size", 128<<20, "Maximum size in bytes of a resumable upload, its chunks being held in memory, 0 for no limit")
+	uploadExpiration := flag.Duration("upload-expiration", 24*time.Hour, "Duration after which unfinished resumable uploads are discarded")
+	logLevel := flag.String("log-level", "info", "Minimum level of the logs : debug, info, warn or error")
+	tlsCert := flag.String("tls-cert", "", "PEM certificate file serving the requests over TLS (and HTTP/2), empty to serve them in plain HTTP")
+	tlsKey := flag.String("tls-key", "", "PEM key file of the TLS certificate")
+	tlsClientCA := flag.String("tls-client-ca", "", "PEM file of the authorities verifying the certificates the clients are required to present, empty to not require any")
+	tlsReloadInterval := flag.Duration("tls-reload-interval", 10*time.Second, "Interval between two checks of whether the TLS certificate files changed, to reload them, 0 to never reload them")
+	publicURL := flag.String("public-url", "", "Url the application is publicly reachable at, such as https://medias.example.com, to build the links with, empty to use the ones of the requests")
+	trustedProxies := flag.String("trusted-proxies", "", "Comma separated addresses or CIDR ranges of the proxies whose Forwarded and X-Forwarded-* headers are trusted to build the links and to rate limit their clients")
+	readHeaderTimeout := flag.Duration("read-header-timeout", 10*time.Second, "Maximum duration of the reading of the headers of a request")
+	readTimeout := flag.Duration("read-timeout", 5*time.Minute, "Maximum duration of the reading of a request, body included, 0 for no limit")
+	writeTimeout := flag.Duration("write-timeout", 5*time.Minute, "Maximum duration of the handling of a request until its response is written, 0 for no limit")
+	idleTimeout := flag.Duration("idle-timeout", 2*time.Minute, "Maximum duration a keep-alive connection waits for the next request")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Maximum duration the requests in flight are waited for when stopping")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "Url the spans are exported to with OTLP/HTTP, such as http://localhost:4318/v1/traces, empty to disable")
+	readinessTimeout := flag.Duration("readiness-timeout", 2*time.Second, "Maximum duration of the probes of the components checking the readiness")
+	otlpServiceName := flag.String("otlp-service-name", "media-api", "Name of the service in the exported spans")
+	cacheMaxAge := flag.Duration("cache-max-age", time.Hour, "Duration the content of the medias may be cached, 0 to revalidate it on each request")
+	cacheMaxAgeByType := flag.String("cache-max-age-by-type", "", "Comma separated durations the content of the medias may be cached by family of mimetypes, such as image=24h,video=24h")
+	purgeWebhookURL := flag.String("purge-webhook-url", "", "Url notified of the medias to purge from the caches when they are replaced, deleted or no longer public, empty to disable")
+	purgeWebhookSecret := flag.String("purge-webhook-secret", "", "Secret signing the notifications of the purge webhook")
+
+	flag.Parse()
+
+	// logs
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		log.Fatalf("invalid log level %q", *logLevel)
+	}
+
+	slog.SetDefault(logging.NewLogger(os.Stderr, level))
+
+	// the background loops and the server stop on the first signal
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var loops sync.WaitGroup
+
 	// setup
-	tagsRegistry := adapters.NewFakeTagRegistry()
-	tagsService := services.NewTagService(tagsRegistry)
+	policy, err := policy(*rbac, *permissionsFile)
+	if err != nil {
+		fatal("could not load the policy", "error", err)
+	}
 
-	mediasService := services.NewMediaService(
-		adapters.NewFakeMediaRepository(),
-		tagsRegistry,
-		adapters.NewFakeUploader(),
+	registry := metrics.NewRegistry()
+
+	// the spans are only recorded if they are exported somewhere
+	var exporter tracing.Exporter = tracing.NoopExporter{}
+	if *otlpEndpoint != "" {
+		exporter = tracing.NewOTLPExporter(tracing.OTLPConfig{Endpoint: *otlpEndpoint, ServiceName: *otlpServiceName})
+	}
+
+	tracer := tracing.NewTracer(exporter)
+
+	allTags := adapters.NewFakeTagRegistry()
+	tagsRegistry := adapters.NewTenantTagRegistry(adapters.NewTracedTagRegistry(allTags, tracer))
+	tagsService := services.NewTracedTagService(services.NewTagService(tagsRegistry, services.WithTagPolicy(policy)), tracer)
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	mediaStorage := adapters.NewFakeUploader()
+	if *storageDir != "" {
+		mediaStorage = adapters.NewFileUploader(*storageDir)
+	}
+
+	uploader := adapters.NewInstrumentedUploader(adapters.NewTracedUploader(mediaStorage, tracer), registry)
+
+	// the totals are counted over every tenant
+	registerTotals(registry, mediaRepository, allTags)
+
+	var signer *signature.Signer
+	if *signingKeys != "" {
+		keys := make([][]byte, 0)
+		for _, key := range strings.Split(*signingKeys, ",") {
+			keys = append(keys, []byte(key))
+		}
+
+		signer = signature.NewSigner(keys...)
+		uploader = adapters.NewHmacPresigner(uploader, signer)
+	}
+
+	// the storage endpoints are given the tenant in the keys of the presigned
+	// urls, the requests being otherwise scoped to their tenant
+	storageUploader := uploader
+	uploader = adapters.NewTenantUploader(uploader)
+
+	if *viewerLinkExpiration > 0 && signer == nil {
+		fatal("signing keys are required to sign the viewer links")
+	}
+
+	var viewerSigner *signature.Signer
+	if *viewerLinkExpiration > 0 {
+		viewerSigner = signer
+	}
+
+	linkOptions := make([]ports.LinkOption, 0)
+	if *publicURL != "" {
+		base, err := url.Parse(*publicURL)
+		if err != nil || !base.IsAbs() || base.Host == "" {
+			fatal("invalid public url", "url", *publicURL)
+		}
+
+		linkOptions = append(linkOptions, ports.WithPublicURL(base))
+	}
+
+	var proxies []netip.Prefix
+	if *trustedProxies != "" {
+		var err error
+		if proxies, err = parsePrefixes(*trustedProxies); err != nil {
+			fatal("invalid trusted proxies", "error", err)
+		}
+
+		linkOptions = append(linkOptions, ports.WithTrustedProxies(proxies...))
+	}
+
+	links := ports.NewLinkBuilder(viewerSigner, *viewerLinkExpiration, linkOptions...)
+
+	// the medias are only cached by the clients if they can only be viewed
+	// with credentials, the signed links being enough to view them otherwise
+	cachePolicy := ports.CachePolicy{DefaultMaxAge: *cacheMaxAge, PrivateOnly: *authRequired && viewerSigner == nil}
+	if *tenantHeader != "" {
+		cachePolicy.Vary = []string{*tenantHeader}
+	}
+
+	if *cacheMaxAgeByType != "" {
+		maxAges, err := parseMaxAges(*cacheMaxAgeByType)
+		if err != nil {
+			fatal("invalid cache max ages", "error", err)
+		}
+
+		cachePolicy.MaxAges = maxAges
+	}
+
+	if !media.Visibility(*defaultVisibility).Valid() {
+		fatal("invalid default visibility", "visibility", *defaultVisibility)
+	}
+
+	quotaRepository := adapters.NewFakeQuotaRepository()
+	quotasService := services.NewQuotaService(
+		adapters.NewTracedQuotaRepository(quotaRepository, tracer),
+		media.Usage{Bytes: *userQuotaBytes, Medias: *userQuotaMedias},
+		media.Usage{Bytes: *tenantQuotaBytes, Medias: *tenantQuotaMedias},
 	)
 
+	mediaOptions := []services.MediaOption{
+		services.WithMediaQuotas(quotasService),
+		services.WithPresignExpiration(*presignExpiration),
+		services.WithDefaultVisibility(media.Visibility(*defaultVisibility)),
+		services.WithMediaPolicy(policy),
+		services.WithMediaProber(adapters.NewTracedProber(adapters.NewNativeProber(), tracer)),
+		services.WithMediaPlaceholders(adapters.NewTracedPlaceholderGenerator(adapters.NewBlurhashGenerator(4, 3, 5), tracer)),
+		services.WithPerceptualHashes(
+			adapters.NewTracedHasher(adapters.NewDhashHasher(), tracer),
+			adapters.NewTracedSimilarityIndex(adapters.NewBktreeIndex(), tracer),
+		),
+	}
+
+	if *stripExif {
+		config := adapters.PrivacyConfig{}
+		if *keepOriginals {
+			config.Originals = adapters.NewFakeUploader()
+		}
+
+		mediaOptions = append(mediaOptions, services.WithMediaSanitizer(adapters.NewTracedSanitizer(adapters.NewPrivacySanitizer(config), tracer)))
+	}
+
+	if *md5Checksums {
+		mediaOptions = append(mediaOptions, services.WithMD5Checksums())
+	}
+
+	if *purgeWebhookURL != "" {
+		purger := adapters.NewWebhookPurger(adapters.WebhookPurgerConfig{URL: *purgeWebhookURL, Secret: []byte(*purgeWebhookSecret)})
+		mediaOptions = append(mediaOptions, services.WithCachePurger(adapters.NewTracedCachePurger(purger, tracer)))
+	}
+
+	mediasService := services.NewTracedMediaService(services.NewMediaService(
+		adapters.NewTenantMediaRepository(adapters.NewTracedMediaRepository(mediaRepository, tracer)),
+		tagsRegistry,
+		uploader,
+		mediaOptions...,
+	), tracer)
+
+	if *scrubInterval > 0 {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			scrub(ctx, services.NewScrubber(mediaRepository, uploader), *scrubInterval)
+		}()
+	}
+
+	fetcher := adapters.NewTracedFetcher(adapters.NewHttpFetcher(adapters.HttpFetcherConfig{
+		Timeout:      *importTimeout,
+		MaxSize:      *importMaxSize,
+		MaxRedirects: *importMaxRedirects,
+		AllowPrivate: *importAllowPrivate,
+	}), tracer)
+
+	uploadRepository := adapters.NewFakeUploadRepository()
+	uploadsService := services.NewUploadService(adapters.NewTracedUploadRepository(uploadRepository, tracer), mediasService, *uploadExpiration, services.WithUploadQuotas(quotasService))
+	if *uploadExpiration > 0 {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			// the uploads are checked often enough not to be kept much
+			// longer than their expiration
+			expire(ctx, uploadsService, max(*uploadExpiration/10, time.Second))
+		}()
+	}
+
+	// authentication
+	authenticators, err := authenticators(*apiKeysFile, *jwtSecret, *jwtJWKS, *jwtIssuer, *jwtAudience)
+	if err != nil {
+		fatal("could not load the authenticators", "error", err)
+	}
+
+	// the tenant of the requests is resolved once they are authenticated
+	resolveTenant := middleware.TenantMiddleware(*tenantHeader)
+	requiredAuthentication := middleware.AuthMiddleware(*authRequired, authenticators...)
+	optionalAuthentication := middleware.AuthMiddleware(false, authenticators...)
+
+	authenticate := func(next http.Handler) http.Handler {
+		return requiredAuthentication(resolveTenant(next))
+	}
+
+	authenticateIfAny := func(next http.Handler) http.Handler {
+		return optionalAuthentication(resolveTenant(next))
+	}
+
+	authorize := func(permission auth.Permission) func(http.Handler) http.Handler {
+		return middleware.AuthorizeMiddleware(policy, permission)
+	}
+
+	// the reads and the uploads have their own budgets
+	limitReads := middleware.RateLimitMiddleware(middleware.RateLimit{Requests: *readRateLimit, TrustedProxies: proxies})
+	limitUploads := middleware.RateLimitMiddleware(middleware.RateLimit{Requests: *uploadRateLimit, Bytes: *uploadByteRate, TrustedProxies: proxies})
+
+	// signed links are enough to view a media
+	viewerAuthentication := authenticate
+	if viewerSigner != nil {
+		viewerAuthentication = authenticateIfAny
+	}
+
 	// tags
-	http.Handle("GET /tags", middleware.LogMiddleware(ports.NewHttpTagsList(tagsService)))
-	http.Handle("POST /tags", middleware.LogMiddleware(ports.NewHttpTagCreate(tagsService)))
+	http.Handle("GET /tags", middleware.LogMiddleware(authenticate(limitReads(authorize(auth.PermissionListTags)(ports.NewHttpTagsList(tagsService))))))
+	http.Handle("POST /tags", middleware.LogMiddleware(authenticate(authorize(auth.PermissionCreateTags)(ports.NewHttpTagCreate(tagsService)))))
 
 	// medias routes
-	http.Handle("GET /medias/{tag}", middleware.LogMiddleware(ports.NewHttpMediaSeatch(mediasService)))
-	http.Handle("POST /medias", middleware.LogMiddleware(ports.NewHttpMediaCreate(mediasService)))
-	http.Handle("GET /viewer/{id}", middleware.LogMiddleware(ports.NewHttpMediaViewer(mediasService)))
+	http.Handle("GET /medias/{tag}", middleware.LogMiddleware(authenticate(limitReads(authorize(auth.PermissionViewMedias)(ports.NewHttpMediaSeatch(mediasService, links))))))
+	http.Handle("POST /medias", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpMediaCreate(mediasService, links))))))
+	http.Handle("POST /medias/import", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpMediaImport(mediasService, links, fetcher))))))
+	http.Handle("POST /medias/batch", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpMediaBatch(mediasService, links, *batchWorkers))))))
+	http.Handle("PUT /medias/{id}", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpMediaReplace(mediasService, links))))))
+	http.Handle("DELETE /medias/{id}", middleware.LogMiddleware(authenticate(authorize(auth.PermissionDeleteMedias)(ports.NewHttpMediaDelete(mediasService)))))
+	http.Handle("GET /medias/{id}/metadata", middleware.LogMiddleware(authenticate(limitReads(authorize(auth.PermissionViewMedias)(ports.NewHttpMediaMetadata(mediasService, links))))))
+	http.Handle("PUT /medias/{id}/access", middleware.LogMiddleware(authenticate(authorize(auth.PermissionShareMedias)(ports.NewHttpMediaShare(mediasService)))))
+	http.Handle("GET /medias/{id}/similar", middleware.LogMiddleware(authenticate(limitReads(authorize(auth.PermissionViewMedias)(ports.NewHttpMediaSimilar(mediasService, links))))))
+	http.Handle("POST /medias/presigned", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpMediaPrepare(mediasService, links))))))
+	http.Handle("POST /medias/{id}/complete", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpMediaComplete(mediasService, links))))))
+	http.Handle("GET /medias/{id}/presigned", middleware.LogMiddleware(authenticate(limitReads(authorize(auth.PermissionViewMedias)(ports.NewHttpMediaPresign(mediasService, links))))))
+	http.Handle("GET /viewer/{id}", middleware.LogMiddleware(viewerAuthentication(limitReads(authorize(auth.PermissionViewMedias)(ports.NewHttpMediaViewer(mediasService, viewerSigner, cachePolicy))))))
 
-	// http server
-	host := flag.String("host", "localhost", "Set the host")
-	port := flag.Uint("port", 8080, "The port to listen to")
+	// quotas
+	http.Handle("GET /quota", middleware.LogMiddleware(authenticate(limitReads(ports.NewHttpQuota(quotasService)))))
 
-	flag.Parse()
+	// storage routes, for the presigned urls
+	if signer != nil {
+		storage := ports.NewHttpStorage(mediaRepository, storageUploader, signer, *storageMaxSize)
+		http.Handle("GET /storage/{id...}", middleware.LogMiddleware(limitReads(storage)))
+		http.Handle("PUT /storage/{id...}", middleware.LogMiddleware(limitUploads(storage)))
+	}
 
+	// resumable uploads routes
+	http.Handle("OPTIONS /uploads", middleware.LogMiddleware(authenticateIfAny(ports.NewHttpUploadOptions(*uploadMaxSize))))
+	http.Handle("POST /uploads", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpUploadCreate(uploadsService, links, *uploadMaxSize))))))
+	http.Handle("HEAD /uploads/{id}", middleware.LogMiddleware(authenticate(authorize(auth.PermissionCreateMedias)(ports.NewHttpUploadOffset(uploadsService)))))
+	http.Handle("PATCH /uploads/{id}", middleware.LogMiddleware(authenticate(limitUploads(authorize(auth.PermissionCreateMedias)(ports.NewHttpUploadPatch(uploadsService))))))
+	http.Handle("DELETE /uploads/{id}", middleware.LogMiddleware(authenticate(authorize(auth.PermissionCreateMedias)(ports.NewHttpUploadTerminate(uploadsService)))))
+
+	// metrics and probes, scraped too often to be logged
+	http.Handle("GET /metrics", registry)
+	http.Handle("GET /healthz", ports.NewHttpLiveness())
+
+	// the adapters are probed undecorated, their decorators not telling
+	// whether they can probe their health. Only the file storage does, the in
+	// memory adapters being always available, and reported as unchecked.
+	http.Handle("GET /readyz", ports.NewHttpReadiness(map[string]any{
+		"media_repository": mediaRepository,
+		"tag_registry":     allTags,
+		"media_uploader":   mediaStorage,
+	}, *readinessTimeout))
+
+	// description of the endpoints, for the clients
+	http.Handle("GET /openapi.json", ports.NewHttpOpenAPI())
+
+	// http server
 	addr := fmt.Sprintf("%s:%d", *host, *port)
 
-	fmt.Printf("Starting to listen on %s...", addr)
-	log.Fatal(http.ListenAndServe(addr, nil))
+	// every request is identified, traced and measured, whatever its route
+	handler := middleware.RequestIDMiddleware(middleware.TracingMiddleware(tracer)(middleware.MetricsMiddleware(registry)(http.DefaultServeMux)))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: *readHeaderTimeout,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	// the certificate is reloaded when renewed, and HTTP/2 is negotiated over
+	// TLS
+	var reloader *certificate.Reloader
+	if *tlsCert != "" {
+		reloader, err = certificate.NewReloader(*tlsCert, *tlsKey, *tlsReloadInterval)
+		if err != nil {
+			fatal("could not load the TLS certificate", "error", err)
+		}
+
+		server.TLSConfig = &tls.Config{
+			GetCertificate: reloader.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+
+		if *tlsClientCA != "" {
+			clientCAs, err := certificate.LoadCertPool(*tlsClientCA)
+			if err != nil {
+				fatal("could not load the authorities of the clients", "error", err)
+			}
+
+			server.TLSConfig.ClientCAs = clientCAs
+			server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("starting to listen", "addr", addr, "tls", reloader != nil)
+		if reloader != nil {
+			errs <- server.ListenAndServeTLS("", "")
+			return
+		}
+
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		fatal("the server stopped", "error", err)
+	case <-ctx.Done():
+	}
+
+	// a second signal stops the application without waiting
+	stop()
+
+	slog.Info("shutting down, waiting for the requests in flight", "timeout", shutdownTimeout.String())
+	if err := shutdown(server, *shutdownTimeout); err != nil {
+		slog.Error("could not wait for every request in flight", "error", err)
+	}
+
+	if reloader != nil {
+		reloader.Close()
+	}
+
+	// the components are not closed under the feet of the loops
+	loops.Wait()
+
+	// the spans are exported last, so that none is lost
+	closeAll(mediaRepository, allTags, mediaStorage, quotaRepository, uploadRepository, exporter)
+	slog.Info("stopped")
+}
+
+// shutdown stops the server once the requests in flight are handled, or cuts
+// them after the timeout
+func shutdown(server *http.Server, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return err
+	}
+
+	return nil
+}
+
+// closeAll closes the components holding resources, the io.Closer ones
+func closeAll(components ...any) {
+	for _, component := range components {
+		closer, ok := component.(io.Closer)
+		if !ok {
+			continue
+		}
+
+		if err := closer.Close(); err != nil {
+			slog.Error("could not close a component", "component", fmt.Sprintf("%T", component), "error", err)
+		}
+	}
+}
+
+// fatal logs why the server can't run, and exits
+func fatal(message string, args ...any) {
+	slog.Error(message, args...)
+	os.Exit(1)
+}
+
+// scrub periodically verifies the integrity of the stored medias, until the
+// context is done
+func scrub(ctx context.Context, scrubber media.IntegrityScrubber, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		corrupted, err := scrubber.Scrub(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("could not verify the medias integrity", "error", err)
+		}
+
+		for _, id := range corrupted {
+			slog.Warn("media is corrupted", "media_id", id)
+		}
+	}
+}
+
+// registerTotals exposes the number of medias and tags as gauges, NaN when
+// they can't be counted
+func registerTotals(registry *metrics.Registry, medias media.MediaRepository, tags media.TagRegistry) {
+	registry.NewGaugeFunc("medias", "Number of medias", func() float64 {
+		all, err := medias.GetAll(context.Background())
+		if err != nil {
+			slog.Error("could not count the medias", "error", err)
+			return math.NaN()
+		}
+
+		return float64(len(all))
+	})
+
+	registry.NewGaugeFunc("tags", "Number of tags", func() float64 {
+		all, err := tags.GetAll(context.Background())
+		if err != nil {
+			slog.Error("could not count the tags", "error", err)
+			return math.NaN()
+		}
+
+		return float64(len(all))
+	})
+}
+
+// authenticators returns the configured ways to authenticate the requests
+func authenticators(apiKeysFile string, jwtSecret string, jwtJWKS string, jwtIssuer string, jwtAudience string) ([]middleware.Authenticator, error) {
+	authenticators := make([]middleware.Authenticator, 0)
+
+	if apiKeysFile != "" {
+		keys, err := middleware.LoadAPIKeys(apiKeysFile)
+		if err != nil {
+			return nil, err
+		}
+
+		authenticators = append(authenticators, middleware.NewAPIKeyAuthenticator(keys))
+	}
+
+	if jwtSecret != "" || jwtJWKS != "" {
+		config := middleware.JWTConfig{
+			Secret:   []byte(jwtSecret),
+			Issuer:   jwtIssuer,
+			Audience: jwtAudience,
+			Leeway:   time.Minute,
+		}
+
+		if jwtJWKS != "" {
+			keys, err := middleware.LoadJWKS(jwtJWKS)
+			if err != nil {
+				return nil, err
+			}
+
+			config.Keys = keys
+		}
+
+		authenticators = append(authenticators, middleware.NewJWTAuthenticator(config))
+	}
+
+	return authenticators, nil
+}
+
+// policy returns the permissions of the roles, or nil if they are not checked
+func policy(rbac bool, permissionsFile string) (*auth.Policy, error) {
+	if permissionsFile != "" {
+		return auth.LoadPolicy(permissionsFile)
+	}
+
+	if rbac {
+		return auth.DefaultPolicy(), nil
+	}
+
+	return nil, nil
+}
+
+// parsePrefixes parses comma separated addresses or CIDR ranges, such as
+// 10.0.0.0/8,192.0.2.1
+func parsePrefixes(list string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0)
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+
+		if addr, err := netip.ParseAddr(value); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// parseMaxAges parses comma separated durations by family of mimetypes, such
+// as image=24h,video=24h
+func parseMaxAges(list string) (map[string]time.Duration, error) {
+	maxAges := make(map[string]time.Duration)
+	for _, value := range strings.Split(list, ",") {
+		family, duration, found := strings.Cut(strings.TrimSpace(value), "=")
+		if !found || family == "" {
+			return nil, fmt.Errorf("expected a family and a duration, got %q", value)
+		}
+
+		maxAge, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, err
+		}
+
+		maxAges[strings.ToLower(family)] = maxAge
+	}
+
+	return maxAges, nil
+}
+
+// expire periodically discards the expired uploads, until the context is done
+func expire(ctx context.Context, uploads media.UploadService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := uploads.Expire(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("could not discard the expired uploads", "error", err)
+		}
+	}
 }
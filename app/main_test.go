@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestShutdown(t *testing.T) {
+	serve := func(t *testing.T, handler http.Handler) (*http.Server, string) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("could not listen : %s", err)
+		}
+
+		server := &http.Server{Handler: handler}
+		go server.Serve(listener)
+
+		return server, "http://" + listener.Addr().String()
+	}
+
+	t.Run("requests in flight handled", func(t *testing.T) {
+		started := make(chan struct{})
+		server, url := serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		codes := make(chan int, 1)
+		go func() {
+			resp, err := http.Get(url)
+			if err != nil {
+				codes <- 0
+				return
+			}
+
+			resp.Body.Close()
+			codes <- resp.StatusCode
+		}()
+
+		<-started
+		if err := shutdown(server, 5*time.Second); err != nil {
+			t.Fatalf("unexpected error while shutting down : %s", err)
+		}
+
+		if code := <-codes; code != http.StatusNoContent {
+			t.Errorf("expected the request in flight to be handled, got %d", code)
+		}
+	})
+
+	t.Run("requests in flight cut after the timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		started := make(chan struct{})
+		server, url := serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-ctx.Done()
+		}))
+
+		errs := make(chan error, 1)
+		go func() {
+			resp, err := http.Get(url)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			errs <- err
+		}()
+
+		<-started
+		if err := shutdown(server, 50*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the shutdown to time out, got %v", err)
+		}
+
+		if err := <-errs; err == nil {
+			t.Errorf("expected the request in flight to be cut")
+		}
+	})
+}
+
+// closer counts how many times it is closed
+type closer struct {
+	closed atomic.Int32
+	err    error
+}
+
+func (c *closer) Close() error {
+	c.closed.Add(1)
+	return c.err
+}
+
+func TestCloseAll(t *testing.T) {
+	failing := &closer{err: errors.New("could not flush")}
+	other := &closer{}
+
+	closeAll(failing, "not a closer", other)
+
+	if failing.closed.Load() != 1 || other.closed.Load() != 1 {
+		t.Errorf("expected every closer to be closed once, even after a failure, got %d and %d", failing.closed.Load(), other.closed.Load())
+	}
+}
+
+// scrubber counts how many times it scrubs
+type scrubber struct {
+	scrubs atomic.Int32
+}
+
+func (s *scrubber) Scrub(ctx context.Context) ([]string, error) {
+	s.scrubs.Add(1)
+	return nil, nil
+}
+
+// expirer counts how many times it expires the uploads
+type expirer struct {
+	media.UploadService
+	expires atomic.Int32
+}
+
+func (e *expirer) Expire(ctx context.Context) error {
+	e.expires.Add(1)
+	return nil
+}
+
+func TestLoops(t *testing.T) {
+	scrubber := &scrubber{}
+	expirer := &expirer{}
+
+	testCases := []struct {
+		name  string
+		loop  func(ctx context.Context)
+		count func() int32
+	}{
+		{name: "scrub", loop: func(ctx context.Context) { scrub(ctx, scrubber, 10*time.Millisecond) }, count: scrubber.scrubs.Load},
+		{name: "expire", loop: func(ctx context.Context) { expire(ctx, expirer, 10*time.Millisecond) }, count: expirer.expires.Load},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				tc.loop(ctx)
+			}()
+
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected the loop to stop with its context")
+			}
+
+			if tc.count() == 0 {
+				t.Errorf("expected the loop to run until its context is done")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Role of a principal, granting it the permissions of the policy
+type Role = string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleUploader Role = "uploader"
+	RoleCurator  Role = "curator"
+	// RoleAdmin is granted every permission, whatever the policy
+	RoleAdmin Role = "admin"
+)
+
+// Permission to do an operation of the services
+type Permission string
+
+const (
+	PermissionListTags     Permission = "tags:list"
+	PermissionCreateTags   Permission = "tags:create"
+	PermissionViewMedias   Permission = "medias:view"
+	PermissionCreateMedias Permission = "medias:create"
+	PermissionShareMedias  Permission = "medias:share"
+	PermissionDeleteMedias Permission = "medias:delete"
+)
+
+var ErrForbidden = fmt.Errorf("forbidden")
+
+func Forbidden(permission Permission) error {
+	return fmt.Errorf("%w : missing permission %q", ErrForbidden, permission)
+}
+
+// Policy tells which roles are granted each permission. A nil policy grants
+// every permission to everybody.
+type Policy struct {
+	Permissions map[Permission][]Role `json:"permissions"`
+	// Anonymous are the roles of the requests without a principal
+	Anonymous []Role `json:"anonymous"`
+}
+
+// DefaultPolicy lets the viewers see the medias and the tags, the uploaders
+// also create and share medias, and only the curators create tags.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Permissions: map[Permission][]Role{
+			PermissionListTags:     {RoleViewer, RoleUploader, RoleCurator},
+			PermissionCreateTags:   {RoleCurator},
+			PermissionViewMedias:   {RoleViewer, RoleUploader, RoleCurator},
+			PermissionCreateMedias: {RoleUploader, RoleCurator},
+			PermissionShareMedias:  {RoleUploader, RoleCurator},
+			PermissionDeleteMedias: {RoleUploader, RoleCurator},
+		},
+		Anonymous: []Role{RoleViewer},
+	}
+}
+
+// LoadPolicy reads a json policy, such as :
+//
+//	{"permissions": {"tags:create": ["curator"]}, "anonymous": ["viewer"]}
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the policy : %w", err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return nil, fmt.Errorf("could not decode the policy : %w", err)
+	}
+
+	return &policy, nil
+}
+
+// Allows tells whether the principal, or an anonymous request if it isn't
+// authenticated, is granted the permission.
+func (p *Policy) Allows(principal Principal, authenticated bool, permission Permission) bool {
+	if p == nil {
+		return true
+	}
+
+	roles := p.Anonymous
+	if authenticated {
+		roles = principal.Roles
+	}
+
+	if slices.Contains(roles, RoleAdmin) {
+		return true
+	}
+
+	for _, role := range p.Permissions[permission] {
+		if slices.Contains(roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize returns a ErrForbidden if the principal behind the context is not
+// granted the permission.
+func (p *Policy) Authorize(ctx context.Context, permission Permission) error {
+	principal, authenticated := PrincipalFrom(ctx)
+	if !p.Allows(principal, authenticated, permission) {
+		return Forbidden(permission)
+	}
+
+	return nil
+}
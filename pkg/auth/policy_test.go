@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(path, []byte(`{"permissions": {"tags:create": ["uploader"]}}`), 0644)
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error while loading the policy : %s", err)
+	}
+
+	uploader := WithPrincipal(ctx, Principal{ID: "bob", Roles: []string{RoleUploader}})
+
+	if err := policy.Authorize(uploader, PermissionCreateTags); err != nil {
+		t.Errorf("expected the uploader to create tags, got %s", err)
+	}
+
+	if err := policy.Authorize(uploader, PermissionViewMedias); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected a forbidden error for a permission not in the policy, got %v", err)
+	}
+
+	if err := policy.Authorize(ctx, PermissionCreateTags); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected a forbidden error for an anonymous request, got %v", err)
+	}
+
+	var none *Policy
+	if err := none.Authorize(ctx, PermissionCreateTags); err != nil {
+		t.Errorf("expected a nil policy to grant everything, got %s", err)
+	}
+
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("expected an error when the policy does not exist")
+	}
+}
@@ -0,0 +1,27 @@
+// Package auth holds who is behind a request, so that it can be read by the
+// services once authenticated by the middlewares.
+package auth
+
+import "context"
+
+// Principal is an authenticated user or application
+type Principal struct {
+	ID     string
+	Roles  []string
+	Groups []string
+	// Tenant the principal belongs to, if any
+	Tenant string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of the context holding the given principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal held by the context, if any
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
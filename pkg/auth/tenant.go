@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrInvalidTenant = fmt.Errorf("invalid tenant")
+
+// ValidTenant tells whether a tenant can be used to scope the keys of the
+// adapters : at most 64 letters, digits, dashes or underscores, the default
+// tenant being empty.
+func ValidTenant(tenant string) bool {
+	if len(tenant) > 64 {
+		return false
+	}
+
+	for _, c := range tenant {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of the context scoped to the given tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant the context is scoped to, the default one
+// being empty.
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,160 @@
+// Package certificate serves the TLS certificates of the server, reloaded
+// when their files change, such as when they are renewed.
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNoCertificate is returned when a file of certificates holds none
+var ErrNoCertificate = errors.New("no certificate found")
+
+// Reloader holds the certificate loaded from a certificate and a key files,
+// and checks periodically whether they changed to reload it. It must be
+// closed to stop checking.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	certificate *tls.Certificate
+	loaded      [2]time.Time
+	mtx         sync.RWMutex
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewReloader loads the certificate, and checks the files every interval, a
+// zero (or negative) one meaning they are never checked again
+func NewReloader(certFile string, keyFile string, interval time.Duration) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		close(r.done)
+		return r, nil
+	}
+
+	go r.loop(interval)
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, to be used as the
+// tls.Config GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return r.certificate, nil
+}
+
+// Reload loads the certificate again if one of its files was modified since
+// it was loaded, and tells whether it did. The current certificate is kept if
+// the files can't be loaded, such as while they are being written.
+func (r *Reloader) Reload() (bool, error) {
+	modified, err := r.modified()
+	if err != nil {
+		return false, err
+	}
+
+	r.mtx.RLock()
+	unchanged := r.certificate != nil && modified == r.loaded
+	r.mtx.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("could not load the certificate %q : %w", r.certFile, err)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.certificate = &certificate
+	r.loaded = modified
+
+	return true, nil
+}
+
+// Close stops checking whether the files changed
+func (r *Reloader) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+
+	<-r.done
+	return nil
+}
+
+// modified returns the times the files were last modified
+func (r *Reloader) modified() (modified [2]time.Time, err error) {
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modified, err
+		}
+
+		modified[i] = info.ModTime()
+	}
+
+	return modified, nil
+}
+
+func (r *Reloader) loop(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				slog.Warn("could not reload the certificate, keeping the current one", "error", err)
+			}
+
+			if reloaded {
+				slog.Info("certificate reloaded", "file", r.certFile)
+			}
+		}
+	}
+}
+
+// LoadCertPool loads the PEM encoded certificates of a file, such as the
+// authorities verifying the certificates of the clients
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("could not load the certificates of %q : %w", file, ErrNoCertificate)
+	}
+
+	return pool, nil
+}
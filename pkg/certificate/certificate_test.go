@@ -0,0 +1,248 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generate writes a self signed certificate for the loopback address, and
+// its key, in the directory
+func generate(t *testing.T, dir string, serial int64) (certFile string, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate a key : %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not create a certificate : %s", err)
+	}
+
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	// the files may be rewritten within the resolution of their modification
+	// time
+	later := time.Now().Add(time.Duration(serial) * time.Second)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+
+	return certFile, keyFile
+}
+
+// serve starts a server with the tls configuration, as the application does,
+// and returns its url
+func serve(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen : %s", err)
+	}
+
+	server := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: config,
+		ErrorLog:  log.New(io.Discard, "", 0),
+	}
+
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := generate(t, dir, 1)
+
+	reloader, err := NewReloader(certFile, keyFile, time.Hour)
+	if err != nil {
+		t.Fatalf("expected the certificate to be loaded, got %s", err)
+	}
+
+	defer reloader.Close()
+
+	url := serve(t, &tls.Config{GetCertificate: reloader.GetCertificate})
+
+	// the client trusts whatever the server presents, and tells which
+	// certificate it was
+	var serial int64
+	client := &http.Client{Transport: &http.Transport{
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection: func(state tls.ConnectionState) error {
+				serial = state.PeerCertificates[0].SerialNumber.Int64()
+				return nil
+			},
+		},
+	}}
+
+	get := func() string {
+		t.Helper()
+
+		response, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("expected the request to succeed, got %s", err)
+		}
+
+		defer response.Body.Close()
+		return response.Proto
+	}
+
+	if proto := get(); proto != "HTTP/2.0" || serial != 1 {
+		t.Fatalf("expected the first certificate over HTTP/2.0, got %d over %s", serial, proto)
+	}
+
+	t.Run("unchanged files", func(t *testing.T) {
+		if reloaded, err := reloader.Reload(); reloaded || err != nil {
+			t.Errorf("expected nothing to be reloaded, got %t, %v", reloaded, err)
+		}
+	})
+
+	t.Run("renewed certificate", func(t *testing.T) {
+		generate(t, dir, 2)
+
+		if reloaded, err := reloader.Reload(); !reloaded || err != nil {
+			t.Fatalf("expected the certificate to be reloaded, got %t, %v", reloaded, err)
+		}
+
+		if get(); serial != 2 {
+			t.Errorf("expected the renewed certificate, got %d", serial)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		os.WriteFile(keyFile, []byte("not a key"), 0600)
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(keyFile, later, later)
+
+		if _, err := reloader.Reload(); err == nil {
+			t.Fatalf("expected the invalid key not to be loaded")
+		}
+
+		if get(); serial != 2 {
+			t.Errorf("expected the current certificate to be kept, got %d", serial)
+		}
+	})
+}
+
+func TestReloaderWithoutInterval(t *testing.T) {
+	certFile, keyFile := generate(t, t.TempDir(), 1)
+
+	reloader, err := NewReloader(certFile, keyFile, 0)
+	if err != nil {
+		t.Fatalf("expected the certificate to be loaded, got %s", err)
+	}
+
+	if certificate, _ := reloader.GetCertificate(nil); certificate == nil {
+		t.Errorf("expected the certificate to be served")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		reloader.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the reloader to be closed right away")
+	}
+}
+
+func TestClientVerification(t *testing.T) {
+	serverCert, serverKey := generate(t, t.TempDir(), 1)
+	clientCert, clientKey := generate(t, t.TempDir(), 2)
+
+	reloader, err := NewReloader(serverCert, serverKey, time.Hour)
+	if err != nil {
+		t.Fatalf("expected the certificate to be loaded, got %s", err)
+	}
+
+	defer reloader.Close()
+
+	clientCAs, err := LoadCertPool(clientCert)
+	if err != nil {
+		t.Fatalf("expected the authorities to be loaded, got %s", err)
+	}
+
+	url := serve(t, &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		ClientCAs:      clientCAs,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+	})
+
+	rootCAs, _ := LoadCertPool(serverCert)
+	certificate, _ := tls.LoadX509KeyPair(clientCert, clientKey)
+
+	testCases := []struct {
+		name          string
+		certificates  []tls.Certificate
+		expectedError bool
+	}{
+		{name: "verified client", certificates: []tls.Certificate{certificate}},
+		{name: "anonymous client", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      rootCAs,
+				Certificates: tc.certificates,
+			}}}
+
+			response, err := client.Get(url)
+			if err == nil {
+				response.Body.Close()
+			}
+
+			if tc.expectedError != (err != nil) {
+				t.Errorf("expected an error : %t, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(file, []byte("nothing"), 0600)
+
+	if _, err := LoadCertPool(file); !errors.Is(err, ErrNoCertificate) {
+		t.Errorf("expected an ErrNoCertificate, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package media
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Taluu/media-go/pkg/auth"
+)
+
+// Visibility tells who can see a media, besides its owner
+type Visibility string
+
+const (
+	// VisibilityPrivate medias are only seen by their owner
+	VisibilityPrivate Visibility = "private"
+	// VisibilityShared medias are seen by the users and groups they are shared
+	// with
+	VisibilityShared Visibility = "shared"
+	// VisibilityPublic medias are seen by everyone, anonymous users included.
+	// Medias without any visibility are public, as they were before it was
+	// introduced.
+	VisibilityPublic Visibility = "public"
+)
+
+func (v Visibility) Valid() bool {
+	return v == VisibilityPrivate || v == VisibilityShared || v == VisibilityPublic
+}
+
+// Access tells who owns a media, and who else can see it
+type Access struct {
+	// Owner is the id of the principal who created the media, empty if it was
+	// created anonymously
+	Owner      string
+	Visibility Visibility
+	// Users and Groups are who the media is shared with
+	Users  []string
+	Groups []string
+}
+
+// IsPublic tells whether everyone can see the media
+func (a Access) IsPublic() bool {
+	return a.Visibility == "" || a.Visibility == VisibilityPublic
+}
+
+// CanView tells whether the principal (if authenticated) can see the media
+func (a Access) CanView(principal auth.Principal, authenticated bool) bool {
+	switch {
+	case a.IsPublic():
+		return true
+	case !authenticated:
+		return false
+	case a.Owns(principal, authenticated):
+		return true
+	case a.Visibility != VisibilityShared:
+		return false
+	}
+
+	if slices.Contains(a.Users, principal.ID) {
+		return true
+	}
+
+	return slices.ContainsFunc(principal.Groups, func(group string) bool {
+		return slices.Contains(a.Groups, group)
+	})
+}
+
+// Owns tells whether the principal (if authenticated) owns the media. Medias
+// created anonymously are not owned by anyone.
+func (a Access) Owns(principal auth.Principal, authenticated bool) bool {
+	return authenticated && a.Owner != "" && a.Owner == principal.ID
+}
+
+// CanEdit tells whether the principal (if authenticated) can change the media.
+// Medias created anonymously can be changed by anyone, as they are public.
+func (a Access) CanEdit(principal auth.Principal, authenticated bool) bool {
+	if a.Owner == "" {
+		return a.CanView(principal, authenticated)
+	}
+
+	return a.Owns(principal, authenticated)
+}
+
+type grantKey struct{}
+
+// WithGrant returns a copy of the context granting the view of the given
+// media, whoever the principal and whatever the access of the media, e.g. to
+// whoever holds a signed link to it.
+func WithGrant(ctx context.Context, mediaID string) context.Context {
+	return context.WithValue(ctx, grantKey{}, mediaID)
+}
+
+// GrantFrom returns the id of the media the context grants the view of, if
+// any
+func GrantFrom(ctx context.Context) (string, bool) {
+	mediaID, ok := ctx.Value(grantKey{}).(string)
+	return mediaID, ok
+}
@@ -1,15 +1,76 @@
 package adapters
 
 import (
+	fetcherHttp "github.com/Taluu/media-go/pkg/domain/media/adapters/fetcher/http"
+	fetcherTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/fetcher/traced"
+	hasherDhash "github.com/Taluu/media-go/pkg/domain/media/adapters/hasher/dhash"
+	hasherTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/hasher/traced"
 	mediaFake "github.com/Taluu/media-go/pkg/domain/media/adapters/media/fake"
+	mediaTenant "github.com/Taluu/media-go/pkg/domain/media/adapters/media/tenant"
+	mediaTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/media/traced"
+	placeholderBlurhash "github.com/Taluu/media-go/pkg/domain/media/adapters/placeholder/blurhash"
+	placeholderTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/placeholder/traced"
+	presignerHmac "github.com/Taluu/media-go/pkg/domain/media/adapters/presigner/hmac"
+	proberNative "github.com/Taluu/media-go/pkg/domain/media/adapters/prober/native"
+	proberTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/prober/traced"
+	purgerTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/purger/traced"
+	purgerWebhook "github.com/Taluu/media-go/pkg/domain/media/adapters/purger/webhook"
+	quotaFake "github.com/Taluu/media-go/pkg/domain/media/adapters/quota/fake"
+	quotaTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/quota/traced"
+	sanitizerPrivacy "github.com/Taluu/media-go/pkg/domain/media/adapters/sanitizer/privacy"
+	sanitizerTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/sanitizer/traced"
+	similarityBktree "github.com/Taluu/media-go/pkg/domain/media/adapters/similarity/bktree"
+	similarityTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/similarity/traced"
 	tagFake "github.com/Taluu/media-go/pkg/domain/media/adapters/tag/fake"
+	tagTenant "github.com/Taluu/media-go/pkg/domain/media/adapters/tag/tenant"
+	tagTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/tag/traced"
+	uploadFake "github.com/Taluu/media-go/pkg/domain/media/adapters/upload/fake"
+	uploadTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/upload/traced"
 	uploaderFake "github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
-	uploaderFile "github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
+	uploaderFile "github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/file"
+	uploaderInstrumented "github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/instrumented"
+	uploaderTenant "github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/tenant"
+	uploaderTraced "github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/traced"
 )
 
 var (
-	NewFakeMediaRepository = mediaFake.NewFake
-	NewFakeTagRegistry     = tagFake.NewFake
-	NewFakeUploader        = uploaderFake.NewUploader
-	NewFileUploader        = uploaderFile.NewUploader
+	NewFakeMediaRepository  = mediaFake.NewFake
+	NewFakeTagRegistry      = tagFake.NewFake
+	NewFakeUploader         = uploaderFake.NewUploader
+	NewFakeUploadRepository = uploadFake.NewFake
+	NewFakeQuotaRepository  = quotaFake.NewFake
+	NewFileUploader         = uploaderFile.NewUploader
+	NewPrivacySanitizer     = sanitizerPrivacy.NewSanitizer
+	NewNativeProber         = proberNative.NewProber
+	NewBlurhashGenerator    = placeholderBlurhash.NewGenerator
+	NewDhashHasher          = hasherDhash.NewHasher
+	NewBktreeIndex          = similarityBktree.NewIndex
+	NewHttpFetcher          = fetcherHttp.NewFetcher
+	NewHmacPresigner        = presignerHmac.NewUploader
+	NewWebhookPurger        = purgerWebhook.NewPurger
+
+	NewTenantMediaRepository = mediaTenant.NewRepository
+	NewTenantTagRegistry     = tagTenant.NewRegistry
+	NewTenantUploader        = uploaderTenant.NewUploader
+
+	NewInstrumentedUploader = uploaderInstrumented.NewUploader
+
+	NewTracedMediaRepository      = mediaTraced.NewRepository
+	NewTracedTagRegistry          = tagTraced.NewRegistry
+	NewTracedUploader             = uploaderTraced.NewUploader
+	NewTracedUploadRepository     = uploadTraced.NewRepository
+	NewTracedQuotaRepository      = quotaTraced.NewRepository
+	NewTracedSanitizer            = sanitizerTraced.NewSanitizer
+	NewTracedProber               = proberTraced.NewProber
+	NewTracedPlaceholderGenerator = placeholderTraced.NewGenerator
+	NewTracedHasher               = hasherTraced.NewHasher
+	NewTracedSimilarityIndex      = similarityTraced.NewIndex
+	NewTracedFetcher              = fetcherTraced.NewFetcher
+	NewTracedCachePurger          = purgerTraced.NewPurger
+)
+
+type (
+	PrivacyConfig       = sanitizerPrivacy.Config
+	HttpFetcherConfig   = fetcherHttp.Config
+	WebhookPurgerConfig = purgerWebhook.Config
 )
@@ -0,0 +1,232 @@
+package http
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"syscall"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+type Config struct {
+	// Timeout is the maximum duration of the whole download, redirects
+	// included. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// MaxSize is the maximum size in bytes of a media. Defaults to 32MB.
+	MaxSize int64
+
+	// MaxRedirects is the maximum number of redirects followed. Defaults to 5,
+	// a negative number disallowing them.
+	MaxRedirects int
+
+	// AllowPrivate allows to fetch medias hosted on private, loopback or link
+	// local addresses, which are blocked by default to prevent SSRF.
+	AllowPrivate bool
+}
+
+// NewFetcher returns a fetcher downloading medias over http and https.
+//
+// The addresses are checked once resolved, right before connecting, so that
+// a public hostname resolving to a private address is also blocked.
+func NewFetcher(config Config) MediaFetcher {
+	f := &fetcher{
+		maxSize:      cmp.Or(config.MaxSize, 32<<20),
+		maxRedirects: cmp.Or(config.MaxRedirects, 5),
+		allowPrivate: config.AllowPrivate,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: f.control,
+	}
+
+	f.client = &http.Client{
+		Timeout:       cmp.Or(config.Timeout, 30*time.Second),
+		CheckRedirect: f.checkRedirect,
+		Transport: &http.Transport{
+			// no proxy, as the checked address would then be the proxy's one
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+
+	return f
+}
+
+type fetcher struct {
+	client       *http.Client
+	maxSize      int64
+	maxRedirects int
+	allowPrivate bool
+}
+
+// Fetch implements media.MediaFetcher.
+func (f *fetcher) Fetch(ctx context.Context, rawURL string) (fileContent []byte, filename string, mimetype string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", "", RemoteMediaError(rawURL, err)
+	}
+
+	if err := checkScheme(u); err != nil {
+		return nil, "", "", RemoteMediaError(rawURL, err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", "", RemoteMediaError(rawURL, err)
+	}
+
+	tracing.Inject(ctx, request.Header)
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		if errors.Is(err, ErrRemoteMediaForbidden) {
+			return nil, "", "", err
+		}
+
+		return nil, "", "", RemoteMediaError(rawURL, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, "", "", RemoteMediaError(rawURL, fmt.Errorf("unexpected status %d", response.StatusCode))
+	}
+
+	if response.ContentLength > f.maxSize {
+		return nil, "", "", RemoteMediaTooLarge(rawURL, f.maxSize)
+	}
+
+	// the announced length can't be trusted, reads one more byte to detect
+	// medias too large
+	fileContent, err = io.ReadAll(io.LimitReader(response.Body, f.maxSize+1))
+	if err != nil {
+		return nil, "", "", RemoteMediaError(rawURL, err)
+	}
+
+	if int64(len(fileContent)) > f.maxSize {
+		return nil, "", "", RemoteMediaTooLarge(rawURL, f.maxSize)
+	}
+
+	filename, mimetype = describe(response)
+	logging.LoggerFrom(ctx).Debug("remote media fetched", "url", rawURL, "final_url", response.Request.URL.String(), "size", len(fileContent), "mimetype", mimetype)
+
+	return fileContent, filename, mimetype, nil
+}
+
+// describe guesses the filename and the mimetype of the fetched media, from
+// the headers of the response first, then from the (final) url.
+func describe(response *http.Response) (filename string, mimetype string) {
+	if _, params, err := mime.ParseMediaType(response.Header.Get("Content-Disposition")); err == nil {
+		filename = path.Base(params["filename"])
+	}
+
+	if filename == "" || filename == "." || filename == "/" {
+		filename = path.Base(response.Request.URL.Path)
+	}
+
+	if filename == "." || filename == "/" {
+		filename = response.Request.URL.Hostname()
+	}
+
+	if mediatype, _, err := mime.ParseMediaType(response.Header.Get("Content-Type")); err == nil {
+		mimetype = mediatype
+	}
+
+	if mimetype == "" {
+		mimetype = mime.TypeByExtension(path.Ext(filename))
+	}
+
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+
+	return
+}
+
+func (f *fetcher) checkRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) > f.maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", f.maxRedirects)
+	}
+
+	logging.LoggerFrom(request.Context()).Debug("following a redirect", "url", request.URL.String())
+
+	return checkScheme(request.URL)
+}
+
+// control is called once the address is resolved, before connecting to it
+func (f *fetcher) control(network string, address string, conn syscall.RawConn) error {
+	if f.allowPrivate {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !isPublic(addrPort.Addr()) {
+		return RemoteMediaForbidden(address)
+	}
+
+	return nil
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	return nil
+}
+
+// special purpose ranges not covered by the netip helpers
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, may embed a private IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site local
+	netip.MustParsePrefix("2002::/16"),      // 6to4, may embed a private IPv4
+	netip.MustParsePrefix("2001::/32"),      // teredo
+	netip.MustParsePrefix("100::/64"),       // discard only
+	netip.MustParsePrefix("255.255.255.255/32"),
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() {
+		return false
+	}
+
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
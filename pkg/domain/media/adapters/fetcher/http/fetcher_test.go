@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+func TestFetch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/horse.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png; charset=binary")
+		w.Write([]byte("not really a png"))
+	})
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../song.mp3"`)
+		w.Header()["Content-Type"] = nil // no sniffing
+		w.Write([]byte("not really a mp3"))
+	})
+
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 100)))
+	})
+
+	mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+		for range 10 {
+			w.Write([]byte(strings.Repeat("a", 10)))
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	})
+
+	mux.HandleFunc("/redirect/{count}", func(w http.ResponseWriter, r *http.Request) {
+		var count int
+		fmt.Sscan(r.PathValue("count"), &count)
+
+		if count == 0 {
+			http.Redirect(w, r, "/images/horse.png", http.StatusFound)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", count-1), http.StatusFound)
+	})
+
+	mux.HandleFunc("/missing", http.NotFound)
+
+	traceparents := make(chan string, 1)
+	mux.HandleFunc("/traced", func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get(tracing.TraceparentHeader)
+		w.Write([]byte("traced"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewFetcher(Config{
+		Timeout:      200 * time.Millisecond,
+		MaxSize:      50,
+		MaxRedirects: 2,
+		AllowPrivate: true,
+	})
+
+	testCases := []struct {
+		name     string
+		path     string
+		filename string
+		mimetype string
+		err      error
+	}{
+		{name: "nominal case", path: "/images/horse.png", filename: "horse.png", mimetype: "image/png"},
+		{name: "content disposition", path: "/download", filename: "song.mp3", mimetype: "audio/mpeg"},
+		{name: "redirects", path: "/redirect/1", filename: "horse.png", mimetype: "image/png"},
+		{name: "too many redirects", path: "/redirect/2", err: ErrRemoteMedia},
+		{name: "too large", path: "/large", err: ErrRemoteMediaTooLarge},
+		{name: "too large without a length", path: "/chunked", err: ErrRemoteMediaTooLarge},
+		{name: "timeout", path: "/slow", err: ErrRemoteMedia},
+		{name: "not found", path: "/missing", err: ErrRemoteMedia},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, filename, mimetype, err := fetcher.Fetch(ctx, server.URL+tc.path)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("expected a %q error, got %v", tc.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error while fetching : %s", err)
+			}
+
+			if len(content) == 0 {
+				t.Errorf("expected some content to be fetched")
+			}
+
+			if filename != tc.filename || mimetype != tc.mimetype {
+				t.Errorf("expected a %q file named %q, got a %q file named %q", tc.mimetype, tc.filename, mimetype, filename)
+			}
+		})
+	}
+
+	t.Run("trace propagated", func(t *testing.T) {
+		ctx, span := tracing.NewTracer(tracing.NoopExporter{}).Start(ctx, "fetch")
+
+		if _, _, _, err := fetcher.Fetch(ctx, server.URL+"/traced"); err != nil {
+			t.Fatalf("unexpected error while fetching : %s", err)
+		}
+
+		received := <-traceparents
+		if traceparent, err := tracing.ParseTraceparent(received); err != nil || traceparent.TraceID != span.Context.TraceID {
+			t.Errorf("expected the trace to be propagated, got %q", received)
+		}
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		if _, _, _, err := fetcher.Fetch(ctx, "file:///etc/passwd"); !errors.Is(err, ErrRemoteMedia) {
+			t.Errorf("expected a %q error, got %v", ErrRemoteMedia, err)
+		}
+	})
+
+	t.Run("private addresses blocked by default", func(t *testing.T) {
+		_, _, _, err := NewFetcher(Config{}).Fetch(ctx, server.URL+"/images/horse.png")
+		if !errors.Is(err, ErrRemoteMediaForbidden) {
+			t.Errorf("expected a %q error, got %v", ErrRemoteMediaForbidden, err)
+		}
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	testCases := map[string]bool{
+		"93.184.215.14":        true,
+		"2606:2800:21f:cb07::": true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"::ffff:127.0.0.1":     false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+		"64:ff9b::a00:1":       false,
+	}
+
+	for address, expected := range testCases {
+		if isPublic(netip.MustParseAddr(address)) != expected {
+			t.Errorf("expected %q to be public : %t", address, expected)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewFetcher decorates a fetcher so that each fetch is traced in a span.
+func NewFetcher(fetcher MediaFetcher, tracer *tracing.Tracer) MediaFetcher {
+	return &tracedFetcher{fetcher, tracer}
+}
+
+type tracedFetcher struct {
+	fetcher MediaFetcher
+	tracer  *tracing.Tracer
+}
+
+// Fetch implements media.MediaFetcher.
+func (f *tracedFetcher) Fetch(ctx context.Context, url string) (fileContent []byte, filename string, mimetype string, err error) {
+	ctx, span := f.tracer.StartClient(ctx, "MediaFetcher.Fetch", "url.full", url)
+	defer func() {
+		span.SetAttributes("media.size", len(fileContent), "media.mimetype", mimetype)
+		span.Finish(err)
+	}()
+
+	return f.fetcher.Fetch(ctx, url)
+}
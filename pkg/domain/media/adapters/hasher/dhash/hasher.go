@@ -0,0 +1,95 @@
+package dhash
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"net/http"
+	"strings"
+
+	// registers the decoders supported by image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// images with more pixels than this (about 50 megapixels) are not decoded
+const maxPixels = 50_000_000
+
+// NewHasher returns a hasher computing the difference hash (dHash) of an
+// image : it is shrunk to a 9x8 grayscale image, and each bit tells whether a
+// pixel is brighter than its right neighbour. It is resilient to resizing,
+// re-encoding and small color changes.
+func NewHasher() PerceptualHasher {
+	return &hasher{}
+}
+
+type hasher struct{}
+
+func (h *hasher) Hash(ctx context.Context, mimetype string, fileContent []byte) (PerceptualHash, error) {
+	if mimetype == "" || mimetype == "application/octet-stream" {
+		mimetype = http.DetectContentType(fileContent)
+	}
+
+	if !strings.HasPrefix(mimetype, "image/") {
+		return 0, UnsupportedMedia(mimetype)
+	}
+
+	// the dimensions are checked before decoding, as a few kilobytes can
+	// describe an image whose pixels wouldn't fit in memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(fileContent))
+	if err != nil || int64(config.Width)*int64(config.Height) > maxPixels {
+		return 0, UnsupportedMedia(mimetype)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(fileContent))
+	if err != nil || img.Bounds().Empty() {
+		return 0, UnsupportedMedia(mimetype)
+	}
+
+	gray := shrink(img, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray[y*9+x] > gray[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return PerceptualHash(hash), nil
+}
+
+// shrink returns the luminance of the image resized to the given size, each
+// pixel being the average of the area it covers.
+func shrink(img image.Image, width, height int) []float64 {
+	bounds := img.Bounds()
+	result := make([]float64, width*height)
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var sum float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+
+			result[y*width+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+
+	return result
+}
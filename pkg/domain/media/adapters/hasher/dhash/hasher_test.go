@@ -0,0 +1,106 @@
+package dhash
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// gradient draws a diagonal gradient, in the given direction
+func gradient(width, height int, reversed bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := uint8((x*255/width + y*255/height) / 2)
+			if reversed {
+				value = 255 - value
+			}
+
+			// a bit of texture, so that the hash is not trivial
+			if (x/(width/4)+y/(height/4))%2 == 0 {
+				value /= 2
+			}
+
+			img.Set(x, y, color.RGBA{value, value / 2, 255 - value, 255})
+		}
+	}
+
+	return img
+}
+
+// oversized returns a tiny png whose header declares an image of
+// 100000x100000 pixels, that would need gigabytes once decoded
+func oversized() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+
+	// the IHDR chunk follows the 8 bytes signature : its length, its type,
+	// the width and the height, then its crc after 13 bytes of data
+	content := buf.Bytes()
+	binary.BigEndian.PutUint32(content[16:], 100000)
+	binary.BigEndian.PutUint32(content[20:], 100000)
+	binary.BigEndian.PutUint32(content[29:], crc32.ChecksumIEEE(content[12:29]))
+
+	return content
+}
+
+func TestHash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hasher := NewHasher()
+
+	hash := func(t *testing.T, encode func(*bytes.Buffer) error) PerceptualHash {
+		var buf bytes.Buffer
+		encode(&buf)
+
+		result, err := hasher.Hash(ctx, "image/png", buf.Bytes())
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		return result
+	}
+
+	original := hash(t, func(b *bytes.Buffer) error { return png.Encode(b, gradient(400, 300, false)) })
+	resized := hash(t, func(b *bytes.Buffer) error { return png.Encode(b, gradient(200, 150, false)) })
+	reencoded := hash(t, func(b *bytes.Buffer) error {
+		return jpeg.Encode(b, gradient(400, 300, false), &jpeg.Options{Quality: 30})
+	})
+	different := hash(t, func(b *bytes.Buffer) error { return png.Encode(b, gradient(400, 300, true)) })
+
+	if distance := original.Distance(resized); distance > 4 {
+		t.Errorf("expected a resized copy to be close to the original, got a distance of %d", distance)
+	}
+
+	if distance := original.Distance(reencoded); distance > 4 {
+		t.Errorf("expected a re-encoded copy to be close to the original, got a distance of %d", distance)
+	}
+
+	if distance := original.Distance(different); distance <= 10 {
+		t.Errorf("expected a different image to be far from the original, got a distance of %d", distance)
+	}
+
+	t.Run("unsupported media", func(t *testing.T) {
+		for mimetype, content := range map[string][]byte{
+			"text/plain": []byte("hello"),
+			"image/png":  []byte("garbage"),
+			"image/apng": oversized(),
+		} {
+			_, err := hasher.Hash(ctx, mimetype, content)
+			if !errors.Is(err, ErrUnsupportedMedia) {
+				t.Errorf("expected an unsupported media error for %q, got %s", mimetype, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewHasher decorates a perceptual hasher so that each hash is traced in a
+// span.
+func NewHasher(hasher PerceptualHasher, tracer *tracing.Tracer) PerceptualHasher {
+	return &tracedHasher{hasher, tracer}
+}
+
+type tracedHasher struct {
+	hasher PerceptualHasher
+	tracer *tracing.Tracer
+}
+
+// Hash implements media.PerceptualHasher.
+func (h *tracedHasher) Hash(ctx context.Context, mimetype string, fileContent []byte) (hash PerceptualHash, err error) {
+	ctx, span := h.tracer.Start(ctx, "PerceptualHasher.Hash", "media.mimetype", mimetype, "media.size", len(fileContent))
+	defer func() { span.Finish(err) }()
+
+	return h.hasher.Hash(ctx, mimetype, fileContent)
+}
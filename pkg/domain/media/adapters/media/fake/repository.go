@@ -2,6 +2,7 @@ package fake
 
 import (
 	"context"
+	"maps"
 	"sync"
 
 	//lint:ignore ST1001
@@ -20,22 +21,23 @@ type repository struct {
 	mtx    sync.RWMutex
 }
 
-func (r *repository) Create(ctx context.Context, name string, mimetype string) (Media, error) {
+func (r *repository) Create(ctx context.Context, media Media) (Media, error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	id := uuid.NewString()
-	media := Media{
-		ID:       id,
-		Name:     name,
-		Mimetype: mimetype,
-	}
-
-	r.medias[id] = media
+	media.ID = uuid.NewString()
+	r.medias[media.ID] = media
 
 	return media, nil
 }
 
+func (r *repository) GetAll(ctx context.Context) (map[string]Media, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return maps.Clone(r.medias), nil
+}
+
 func (r *repository) GetByIDs(ctx context.Context, ids ...string) (map[string]Media, error) {
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
@@ -53,3 +55,29 @@ func (r *repository) GetByIDs(ctx context.Context, ids ...string) (map[string]Me
 
 	return result, nil
 }
+
+func (r *repository) Update(ctx context.Context, media Media) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, exists := r.medias[media.ID]; !exists {
+		return MediaNotFound(media.ID)
+	}
+
+	r.medias[media.ID] = media
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, exists := r.medias[id]; !exists {
+		return MediaNotFound(id)
+	}
+
+	delete(r.medias, id)
+
+	return nil
+}
@@ -2,6 +2,7 @@ package fake
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,7 +14,7 @@ func TestCreate(t *testing.T) {
 	defer cancel()
 
 	repository := NewFake()
-	media, err := repository.Create(ctx, "foo", "random/mime")
+	media, err := repository.Create(ctx, Media{Name: "foo", Mimetype: "random/mime"})
 
 	if err != nil {
 		t.Fatalf("error while creating media object : %e", err)
@@ -24,6 +25,24 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestGetAll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+	repository.Create(ctx, Media{Name: "foo", Mimetype: "random/mime"})
+	repository.Create(ctx, Media{Name: "bar", Mimetype: "random/mime"})
+
+	medias, err := repository.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error : %e", err)
+	}
+
+	if len(medias) != 2 {
+		t.Fatalf("expected to get 2 medias, got %d", len(medias))
+	}
+}
+
 func TestGetByIDs(t *testing.T) {
 	type testCase struct {
 		Name   string
@@ -37,11 +56,11 @@ func TestGetByIDs(t *testing.T) {
 	repository := NewFake()
 
 	// create 2 medias with same tag name in common
-	media1, _ := repository.Create(ctx, "foo", "random/mime")
-	media2, _ := repository.Create(ctx, "bar", "random/mime")
+	media1, _ := repository.Create(ctx, Media{Name: "foo", Mimetype: "random/mime"})
+	media2, _ := repository.Create(ctx, Media{Name: "bar", Mimetype: "random/mime"})
 
 	// create a media with no relation to the other 2
-	repository.Create(ctx, "baz", "random/mime")
+	repository.Create(ctx, Media{Name: "baz", Mimetype: "random/mime"})
 
 	cases := []testCase{
 		{
@@ -86,3 +105,26 @@ func TestGetByIDs(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+	media, _ := repository.Create(ctx, Media{Name: "foo", Mimetype: "random/mime"})
+
+	media.Properties.Width = 42
+	if err := repository.Update(ctx, media); err != nil {
+		t.Fatalf("error while updating media object : %e", err)
+	}
+
+	medias, _ := repository.GetByIDs(ctx, media.ID)
+	if medias[media.ID].Properties.Width != 42 {
+		t.Fatalf("media was not updated, expected a width of %d, had %d", 42, medias[media.ID].Properties.Width)
+	}
+
+	err := repository.Update(ctx, Media{ID: "oops"})
+	if !errors.Is(err, ErrMediaNotFound) {
+		t.Fatalf("expected a media not found error, got %s", err)
+	}
+}
@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"context"
+	"maps"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewRepository decorates a repository so that only the medias of the tenant
+// of the context are seen, the medias of the other tenants being not found.
+func NewRepository(repository MediaRepository) MediaRepository {
+	return &tenantRepository{repository}
+}
+
+type tenantRepository struct {
+	repository MediaRepository
+}
+
+// Create implements media.MediaRepository.
+func (r *tenantRepository) Create(ctx context.Context, media Media) (Media, error) {
+	media.Tenant = auth.TenantFrom(ctx)
+	return r.repository.Create(ctx, media)
+}
+
+// GetAll implements media.MediaRepository.
+func (r *tenantRepository) GetAll(ctx context.Context) (map[string]Media, error) {
+	medias, err := r.repository.GetAll(ctx)
+	return r.scope(ctx, medias), err
+}
+
+// GetByIDs implements media.MediaRepository.
+func (r *tenantRepository) GetByIDs(ctx context.Context, mediaIDs ...string) (map[string]Media, error) {
+	medias, err := r.repository.GetByIDs(ctx, mediaIDs...)
+	return r.scope(ctx, medias), err
+}
+
+// Update implements media.MediaRepository.
+func (r *tenantRepository) Update(ctx context.Context, media Media) error {
+	medias, err := r.GetByIDs(ctx, media.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := medias[media.ID]; !exists {
+		return MediaNotFound(media.ID)
+	}
+
+	media.Tenant = auth.TenantFrom(ctx)
+	return r.repository.Update(ctx, media)
+}
+
+// Delete implements media.MediaRepository.
+func (r *tenantRepository) Delete(ctx context.Context, id string) error {
+	medias, err := r.GetByIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := medias[id]; !exists {
+		return MediaNotFound(id)
+	}
+
+	return r.repository.Delete(ctx, id)
+}
+
+// scope removes the medias of the other tenants
+func (r *tenantRepository) scope(ctx context.Context, medias map[string]Media) map[string]Media {
+	tenant := auth.TenantFrom(ctx)
+	maps.DeleteFunc(medias, func(_ string, media Media) bool { return media.Tenant != tenant })
+	return medias
+}
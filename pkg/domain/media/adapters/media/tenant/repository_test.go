@@ -0,0 +1,95 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/media/fake"
+)
+
+func TestRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	acme := auth.WithTenant(ctx, "acme")
+	globex := auth.WithTenant(ctx, "globex")
+
+	repository := NewRepository(fake.NewFake())
+
+	media, err := repository.Create(acme, Media{Name: "foo", Mimetype: "random/mime"})
+	if err != nil {
+		t.Fatalf("unexpected error while creating a media : %s", err)
+	}
+
+	if media.Tenant != "acme" {
+		t.Fatalf("expected the media to belong to %q, got %q", "acme", media.Tenant)
+	}
+
+	repository.Create(globex, Media{Name: "bar", Mimetype: "random/mime"})
+	repository.Create(ctx, Media{Name: "baz", Mimetype: "random/mime"})
+
+	for _, tenant := range []context.Context{acme, globex, ctx} {
+		medias, err := repository.GetAll(tenant)
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if len(medias) != 1 {
+			t.Errorf("expected 1 media for the tenant %q, got %d", auth.TenantFrom(tenant), len(medias))
+		}
+	}
+
+	if medias, _ := repository.GetByIDs(globex, media.ID); len(medias) != 0 {
+		t.Errorf("expected the media of another tenant not to be found, got %+v", medias)
+	}
+
+	if medias, _ := repository.GetByIDs(acme, media.ID); len(medias) != 1 {
+		t.Errorf("expected the media of the tenant to be found, got %+v", medias)
+	}
+
+	media.Name = "stolen"
+	if err := repository.Update(globex, media); !errors.Is(err, ErrMediaNotFound) {
+		t.Errorf("expected the media of another tenant not to be updated, got %v", err)
+	}
+
+	media.Tenant = "globex"
+	media.Name = "renamed"
+	if err := repository.Update(acme, media); err != nil {
+		t.Fatalf("unexpected error while updating a media : %s", err)
+	}
+
+	if medias, _ := repository.GetByIDs(acme, media.ID); medias[media.ID].Name != "renamed" {
+		t.Errorf("expected the media to be updated and stay in its tenant, got %+v", medias)
+	}
+}
+
+// createOnly is a repository whose medias can't be updated
+type createOnly struct {
+	MediaRepository
+}
+
+func (createOnly) Update(ctx context.Context, media Media) error {
+	return errors.New("unexpected update")
+}
+
+func TestCreateAtomically(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	underlying := fake.NewFake()
+	repository := NewRepository(createOnly{underlying})
+
+	// the media is inserted along with its tenant, whatever it is given
+	media, err := repository.Create(auth.WithTenant(ctx, "acme"), Media{Name: "foo", Tenant: "globex"})
+	if err != nil {
+		t.Fatalf("unexpected error while creating a media : %s", err)
+	}
+
+	if medias, _ := underlying.GetByIDs(ctx, media.ID); medias[media.ID].Tenant != "acme" {
+		t.Errorf("expected the media to be inserted in its tenant, got %+v", medias[media.ID])
+	}
+}
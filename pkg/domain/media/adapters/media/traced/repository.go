@@ -0,0 +1,60 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewRepository decorates a media repository so that each of its calls is
+// traced in a span.
+func NewRepository(repository MediaRepository, tracer *tracing.Tracer) MediaRepository {
+	return &tracedRepository{repository, tracer}
+}
+
+type tracedRepository struct {
+	repository MediaRepository
+	tracer     *tracing.Tracer
+}
+
+// GetAll implements media.MediaRepository.
+func (r *tracedRepository) GetAll(ctx context.Context) (medias map[string]Media, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "MediaRepository.GetAll")
+	defer func() { span.Finish(err) }()
+
+	return r.repository.GetAll(ctx)
+}
+
+// GetByIDs implements media.MediaRepository.
+func (r *tracedRepository) GetByIDs(ctx context.Context, mediaIDs ...string) (medias map[string]Media, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "MediaRepository.GetByIDs", "medias.count", len(mediaIDs))
+	defer func() { span.Finish(err) }()
+
+	return r.repository.GetByIDs(ctx, mediaIDs...)
+}
+
+// Create implements media.MediaRepository.
+func (r *tracedRepository) Create(ctx context.Context, media Media) (_ Media, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "MediaRepository.Create", "media.mimetype", media.Mimetype)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Create(ctx, media)
+}
+
+// Update implements media.MediaRepository.
+func (r *tracedRepository) Update(ctx context.Context, media Media) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "MediaRepository.Update", "media.id", media.ID)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Update(ctx, media)
+}
+
+// Delete implements media.MediaRepository.
+func (r *tracedRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "MediaRepository.Delete", "media.id", id)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Delete(ctx, id)
+}
@@ -0,0 +1,107 @@
+package blurhash
+
+import (
+	"math"
+	"strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// encode computes the BlurHash of the given pixels, as described on
+// https://github.com/woltapp/blurhash
+func encode(img pixels, xComponents, yComponents int) string {
+	factors := make([][3]float64, 0, xComponents*yComponents)
+
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			factors = append(factors, basisFactor(img, i, j))
+		}
+	}
+
+	var hash strings.Builder
+	writeBase83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, factor := range ac {
+			actualMaximum = max(actualMaximum, math.Abs(factor[0]), math.Abs(factor[1]), math.Abs(factor[2]))
+		}
+
+		quantisedMaximum := int(max(0, min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		writeBase83(&hash, quantisedMaximum, 1)
+	} else {
+		writeBase83(&hash, 0, 1)
+	}
+
+	writeBase83(&hash, linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4)
+
+	for _, factor := range ac {
+		value := 0
+		for _, channel := range factor {
+			quantised := int(max(0, min(18, math.Floor(signPow(channel/maximumValue, 0.5)*9+9.5))))
+			value = value*19 + quantised
+		}
+
+		writeBase83(&hash, value, 2)
+	}
+
+	return hash.String()
+}
+
+func basisFactor(img pixels, i, j int) [3]float64 {
+	var r, g, b float64
+
+	for y := 0; y < img.height; y++ {
+		for x := 0; x < img.width; x++ {
+			basis := math.Cos(math.Pi*float64(i*x)/float64(img.width)) *
+				math.Cos(math.Pi*float64(j*y)/float64(img.height))
+
+			pixel := img.rgb[y*img.width+x]
+			r += basis * sRGBToLinear(pixel[0])
+			g += basis * sRGBToLinear(pixel[1])
+			b += basis * sRGBToLinear(pixel[2])
+		}
+	}
+
+	normalisation := 2.0
+	if i == 0 && j == 0 {
+		normalisation = 1
+	}
+
+	scale := normalisation / float64(img.width*img.height)
+
+	return [3]float64{r * scale, g * scale, b * scale}
+}
+
+func writeBase83(hash *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		hash.WriteByte(base83Chars[digit])
+	}
+}
+
+func sRGBToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := max(0, min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
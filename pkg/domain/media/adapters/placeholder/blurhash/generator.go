@@ -0,0 +1,109 @@
+package blurhash
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"net/http"
+	"strings"
+
+	// registers the decoders supported by image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// images are downsampled to this size before being processed, as a
+// placeholder doesn't need more than that
+const sampleSize = 64
+
+// images with more pixels than this (about 50 megapixels) are not decoded
+const maxPixels = 50_000_000
+
+// NewGenerator returns a generator computing a BlurHash with the given number
+// of components on each axis (between 1 and 9), and a palette of the given
+// number of dominant colors.
+func NewGenerator(xComponents, yComponents, paletteSize int) PlaceholderGenerator {
+	return &generator{
+		xComponents: min(max(xComponents, 1), 9),
+		yComponents: min(max(yComponents, 1), 9),
+		paletteSize: max(paletteSize, 0),
+	}
+}
+
+type generator struct {
+	xComponents int
+	yComponents int
+	paletteSize int
+}
+
+func (g *generator) Generate(ctx context.Context, mimetype string, fileContent []byte) (Placeholder, error) {
+	if mimetype == "" || mimetype == "application/octet-stream" {
+		mimetype = http.DetectContentType(fileContent)
+	}
+
+	if !strings.HasPrefix(mimetype, "image/") {
+		return Placeholder{}, UnsupportedMedia(mimetype)
+	}
+
+	// the dimensions are checked before decoding, as a few kilobytes can
+	// describe an image whose pixels wouldn't fit in memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(fileContent))
+	if err != nil || int64(config.Width)*int64(config.Height) > maxPixels {
+		return Placeholder{}, UnsupportedMedia(mimetype)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(fileContent))
+	if err != nil || img.Bounds().Empty() {
+		return Placeholder{}, UnsupportedMedia(mimetype)
+	}
+
+	pixels := sample(img, sampleSize)
+
+	return Placeholder{
+		BlurHash: encode(pixels, g.xComponents, g.yComponents),
+		Colors:   palette(pixels, g.paletteSize),
+	}, nil
+}
+
+// pixels is a downsampled image, holding the 8 bits RGB values of each pixel
+type pixels struct {
+	width  int
+	height int
+	rgb    [][3]uint8
+}
+
+// sample downsamples the image so that it fits in a size x size square,
+// averaging the pixels of each area.
+func sample(img image.Image, size int) pixels {
+	bounds := img.Bounds()
+	width := min(bounds.Dx(), size)
+	height := min(bounds.Dy(), size)
+
+	result := pixels{width, height, make([][3]uint8, width*height)}
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+
+			var r, g, b, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := img.At(sx, sy).RGBA()
+					r, g, b = r+uint64(cr>>8), g+uint64(cg>>8), b+uint64(cb>>8)
+					count++
+				}
+			}
+
+			result.rgb[y*width+x] = [3]uint8{uint8(r / count), uint8(g / count), uint8(b / count)}
+		}
+	}
+
+	return result
+}
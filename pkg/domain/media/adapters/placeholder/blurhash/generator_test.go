@@ -0,0 +1,149 @@
+package blurhash
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func decodeBase83(t *testing.T, value string) int {
+	result := 0
+	for _, char := range value {
+		digit := strings.IndexRune(base83Chars, char)
+		if digit < 0 {
+			t.Fatalf("invalid base83 character %q", char)
+		}
+
+		result = result*83 + digit
+	}
+
+	return result
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// fixture returns an image with its left 3/4 red, and the rest blue
+func fixture() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 100; x++ {
+			if x < 75 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+
+	return encodePNG(img)
+}
+
+// oversized returns a tiny png whose header declares an image of
+// 100000x100000 pixels, that would need gigabytes once decoded
+func oversized() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+
+	// the IHDR chunk follows the 8 bytes signature : its length, its type,
+	// the width and the height, then its crc after 13 bytes of data
+	content := buf.Bytes()
+	binary.BigEndian.PutUint32(content[16:], 100000)
+	binary.BigEndian.PutUint32(content[20:], 100000)
+	binary.BigEndian.PutUint32(content[29:], crc32.ChecksumIEEE(content[12:29]))
+
+	return content
+}
+
+func TestGenerate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	generator := NewGenerator(4, 3, 5)
+
+	t.Run("solid color", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		for i := 0; i < len(img.Pix); i += 4 {
+			copy(img.Pix[i:], []byte{0x12, 0x34, 0x56, 0xff})
+		}
+
+		placeholder, err := generator.Generate(ctx, "image/png", encodePNG(img))
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		// size flag, maximum, DC then 11 AC components
+		if len(placeholder.BlurHash) != 1+1+4+2*11 {
+			t.Fatalf("expected a blurhash of %d characters, got %q", 1+1+4+2*11, placeholder.BlurHash)
+		}
+
+		if flag := decodeBase83(t, placeholder.BlurHash[:1]); flag != 3+2*9 {
+			t.Errorf("expected a size flag of %d, got %d", 3+2*9, flag)
+		}
+
+		if dc := decodeBase83(t, placeholder.BlurHash[2:6]); dc != 0x123456 {
+			t.Errorf("expected the average color to be %06x, got %06x", 0x123456, dc)
+		}
+
+		if len(placeholder.Colors) != 1 || placeholder.Colors[0] != "#123456" {
+			t.Errorf("expected a single %q dominant color, got %v", "#123456", placeholder.Colors)
+		}
+	})
+
+	t.Run("two colors", func(t *testing.T) {
+		placeholder, err := generator.Generate(ctx, "application/octet-stream", fixture())
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		expected := []string{"#ff0000", "#0000ff"}
+		if len(placeholder.Colors) != 2 || placeholder.Colors[0] != expected[0] || placeholder.Colors[1] != expected[1] {
+			t.Errorf("expected the dominant colors to be %v, got %v", expected, placeholder.Colors)
+		}
+
+		if maximum := decodeBase83(t, placeholder.BlurHash[1:2]); maximum == 0 {
+			t.Errorf("expected a non zero maximum AC value")
+		}
+	})
+
+	t.Run("components are bounded", func(t *testing.T) {
+		placeholder, err := NewGenerator(0, 12, 0).Generate(ctx, "image/png", fixture())
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if len(placeholder.BlurHash) != 1+1+4+2*8 {
+			t.Errorf("expected a blurhash of %d characters, got %q", 1+1+4+2*8, placeholder.BlurHash)
+		}
+
+		if len(placeholder.Colors) != 0 {
+			t.Errorf("expected no dominant colors, got %v", placeholder.Colors)
+		}
+	})
+
+	t.Run("unsupported media", func(t *testing.T) {
+		for mimetype, content := range map[string][]byte{
+			"text/plain": []byte("hello"),
+			"image/png":  []byte("garbage"),
+			"image/apng": oversized(),
+		} {
+			_, err := generator.Generate(ctx, mimetype, content)
+			if !errors.Is(err, ErrUnsupportedMedia) {
+				t.Errorf("expected an unsupported media error for %q, got %s", mimetype, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package blurhash
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// palette extracts the given number of dominant colors, by grouping the
+// pixels in buckets of similar colors (4 bits per channel), and returning the
+// average color of the most populated buckets.
+func palette(img pixels, size int) []string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+
+	buckets := make(map[int]*bucket)
+	for _, pixel := range img.rgb {
+		key := int(pixel[0]>>4)<<8 | int(pixel[1]>>4)<<4 | int(pixel[2]>>4)
+
+		if _, exists := buckets[key]; !exists {
+			buckets[key] = &bucket{}
+		}
+
+		buckets[key].count++
+		buckets[key].r += int(pixel[0])
+		buckets[key].g += int(pixel[1])
+		buckets[key].b += int(pixel[2])
+	}
+
+	keys := make([]int, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+
+	// most populated first, and the key for a stable order between equals
+	slices.SortFunc(keys, func(a, b int) int {
+		if c := cmp.Compare(buckets[b].count, buckets[a].count); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a, b)
+	})
+
+	colors := make([]string, 0, size)
+	for _, key := range keys[:min(size, len(keys))] {
+		b := buckets[key]
+		colors = append(colors, fmt.Sprintf("#%02x%02x%02x", b.r/b.count, b.g/b.count, b.b/b.count))
+	}
+
+	return colors
+}
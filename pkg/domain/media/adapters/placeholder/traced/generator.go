@@ -0,0 +1,28 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewGenerator decorates a placeholder generator so that each generation is
+// traced in a span.
+func NewGenerator(generator PlaceholderGenerator, tracer *tracing.Tracer) PlaceholderGenerator {
+	return &tracedGenerator{generator, tracer}
+}
+
+type tracedGenerator struct {
+	generator PlaceholderGenerator
+	tracer    *tracing.Tracer
+}
+
+// Generate implements media.PlaceholderGenerator.
+func (g *tracedGenerator) Generate(ctx context.Context, mimetype string, fileContent []byte) (placeholder Placeholder, err error) {
+	ctx, span := g.tracer.Start(ctx, "PlaceholderGenerator.Generate", "media.mimetype", mimetype, "media.size", len(fileContent))
+	defer func() { span.Finish(err) }()
+
+	return g.generator.Generate(ctx, mimetype, fileContent)
+}
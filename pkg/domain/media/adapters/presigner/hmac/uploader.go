@@ -0,0 +1,46 @@
+package hmac
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+// NewUploader decorates an uploader whose content is served by this
+// application, so that it hands out urls to the storage endpoints, signed
+// with the given signer.
+func NewUploader(uploader MediaUploader, signer *signature.Signer) MediaUploader {
+	return &presigningUploader{uploader, signer}
+}
+
+// Path is the path of the storage endpoint serving the content of a media
+func Path(mediaID string) string {
+	return "/storage/" + mediaID
+}
+
+type presigningUploader struct {
+	MediaUploader
+	signer *signature.Signer
+}
+
+// PresignUpload implements media.MediaPresigner.
+func (u *presigningUploader) PresignUpload(ctx context.Context, mediaID string, expiresAt time.Time) (PresignedURL, error) {
+	return u.presign(http.MethodPut, mediaID, expiresAt), nil
+}
+
+// PresignDownload implements media.MediaPresigner.
+func (u *presigningUploader) PresignDownload(ctx context.Context, mediaID string, expiresAt time.Time) (PresignedURL, error) {
+	return u.presign(http.MethodGet, mediaID, expiresAt), nil
+}
+
+func (u *presigningUploader) presign(method string, mediaID string, expiresAt time.Time) PresignedURL {
+	return PresignedURL{
+		URL:       u.signer.SignURL(method, Path(mediaID), expiresAt),
+		Method:    method,
+		ExpiresAt: expiresAt,
+	}
+}
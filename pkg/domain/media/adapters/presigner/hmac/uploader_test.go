@@ -0,0 +1,56 @@
+package hmac
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+func TestPresign(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	signer := signature.NewSigner([]byte("secret"))
+	uploader := NewUploader(fake.NewUploader(), signer)
+
+	presigner, ok := uploader.(MediaPresigner)
+	if !ok {
+		t.Fatalf("expected the uploader to be able to presign urls")
+	}
+
+	expiresAt := time.Now().Add(time.Minute)
+
+	for method, presign := range map[string]func(context.Context, string, time.Time) (PresignedURL, error){
+		"PUT": presigner.PresignUpload,
+		"GET": presigner.PresignDownload,
+	} {
+		presigned, err := presign(ctx, "foo", expiresAt)
+		if err != nil {
+			t.Fatalf("unexpected error while presigning : %s", err)
+		}
+
+		if presigned.Method != method {
+			t.Errorf("expected a %s url, got %s", method, presigned.Method)
+		}
+
+		u, _ := url.Parse(presigned.URL)
+		if u.Path != "/storage/foo" {
+			t.Errorf("expected the url to point to %q, got %q", "/storage/foo", u.Path)
+		}
+
+		if err := signer.Verify(method, u.Path, u.Query(), time.Now()); err != nil {
+			t.Errorf("expected the %s url to be signed, got %s", method, err)
+		}
+	}
+
+	// still an uploader
+	uploader.Upload(ctx, "foo", []byte("content"))
+	if content, _ := uploader.GetContent(ctx, "foo"); string(content) != "content" {
+		t.Errorf("expected the content to be uploaded, got %q", content)
+	}
+}
@@ -0,0 +1,71 @@
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+
+	// registers the decoders supported by image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func probeImage(content []byte) (Properties, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if errors.Is(err, image.ErrFormat) {
+		return Properties{}, UnsupportedMedia(http.DetectContentType(content))
+	}
+
+	if err != nil {
+		return Properties{}, fmt.Errorf("could not decode image : %w", err)
+	}
+
+	return Properties{Width: config.Width, Height: config.Height}, nil
+}
+
+// probeWebP reads the dimensions from the headers of a WebP file, as the
+// standard library does not provide any decoder for this format.
+func probeWebP(content []byte) (Properties, error) {
+	if len(content) < 30 || string(content[:4]) != "RIFF" || string(content[8:12]) != "WEBP" {
+		return Properties{}, fmt.Errorf("invalid webp header")
+	}
+
+	chunk := content[12:]
+	switch string(chunk[:4]) {
+	case "VP8 ":
+		// lossy : 3 bytes of frame tag, 3 bytes of start code, then the 14 bits
+		// dimensions
+		return Properties{
+			Width:  int(binary.LittleEndian.Uint16(chunk[14:]) & 0x3FFF),
+			Height: int(binary.LittleEndian.Uint16(chunk[16:]) & 0x3FFF),
+		}, nil
+
+	case "VP8L":
+		// lossless : a signature byte, then the 14 bits dimensions minus one
+		bits := binary.LittleEndian.Uint32(chunk[9:])
+		return Properties{
+			Width:  int(bits&0x3FFF) + 1,
+			Height: int((bits>>14)&0x3FFF) + 1,
+		}, nil
+
+	case "VP8X":
+		// extended : 4 bytes of flags, then the 24 bits canvas size minus one
+		return Properties{
+			Width:  int(uint24(chunk[12:])) + 1,
+			Height: int(uint24(chunk[15:])) + 1,
+		}, nil
+	}
+
+	return Properties{}, fmt.Errorf("unknown webp chunk %q", chunk[:4])
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
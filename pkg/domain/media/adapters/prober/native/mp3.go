@@ -0,0 +1,133 @@
+package native
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// bitrates in kbps, indexed by [mpeg1][layer][index], layers being I, II, III
+var mp3Bitrates = [2][3][15]int{
+	// MPEG 2 and 2.5
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+	// MPEG 1
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+}
+
+// sample rates, indexed by the version bits of the frame header
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},  // MPEG 2.5
+	{0, 0, 0},             // reserved
+	{22050, 24000, 16000}, // MPEG 2
+	{44100, 48000, 32000}, // MPEG 1
+}
+
+func probeMP3(content []byte) (Properties, error) {
+	pos := 0
+
+	// skip the ID3v2 tag, its size being a syncsafe integer
+	if len(content) >= 10 && string(content[:3]) == "ID3" {
+		pos = 10 + (int(content[6])<<21 | int(content[7])<<14 | int(content[8])<<7 | int(content[9]))
+		if content[5]&0x10 != 0 {
+			pos += 10
+		}
+	}
+
+	end := len(content)
+	if end-128 > pos && string(content[end-128:end-125]) == "TAG" {
+		end -= 128
+	}
+
+	for ; pos+4 <= end; pos++ {
+		if content[pos] != 0xFF || content[pos+1]&0xE0 != 0xE0 {
+			continue
+		}
+
+		header := binary.BigEndian.Uint32(content[pos:])
+		version := (header >> 19) & 3
+		layer := (header >> 17) & 3
+		bitrateIndex := (header >> 12) & 0xF
+		sampleRateIndex := (header >> 10) & 3
+
+		if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 0xF || sampleRateIndex == 3 {
+			continue
+		}
+
+		mpeg1 := 0
+		if version == 3 {
+			mpeg1 = 1
+		}
+
+		// layer bits are reversed : 3 is layer I, 1 is layer III
+		layerIndex := 3 - layer
+		bitrate := mp3Bitrates[mpeg1][layerIndex][bitrateIndex] * 1000
+		sampleRate := mp3SampleRates[version][sampleRateIndex]
+
+		samplesPerFrame := 1152
+		switch {
+		case layerIndex == 0:
+			samplesPerFrame = 384
+		case layerIndex == 2 && mpeg1 == 0:
+			samplesPerFrame = 576
+		}
+
+		audioSize := end - pos
+
+		// a VBR file announces its number of frames in a Xing (or Info) header
+		// held by its first frame
+		if frames := xingFrames(content[pos:end], header, mpeg1 == 1); frames > 0 {
+			duration := time.Duration(float64(frames) * float64(samplesPerFrame) / float64(sampleRate) * float64(time.Second))
+			return Properties{
+				Duration: duration,
+				Bitrate:  int(float64(audioSize) * 8 / duration.Seconds()),
+			}, nil
+		}
+
+		return Properties{
+			Duration: time.Duration(float64(audioSize) * 8 / float64(bitrate) * float64(time.Second)),
+			Bitrate:  bitrate,
+		}, nil
+	}
+
+	return Properties{}, fmt.Errorf("no mp3 frame found")
+}
+
+func xingFrames(frame []byte, header uint32, mpeg1 bool) int {
+	mono := (header>>6)&3 == 3
+
+	// the xing header is right after the side information
+	offset := 4
+	switch {
+	case mpeg1 && !mono:
+		offset += 32
+	case mpeg1, !mono:
+		offset += 17
+	default:
+		offset += 9
+	}
+
+	if len(frame) < offset+12 {
+		return 0
+	}
+
+	if tag := string(frame[offset : offset+4]); tag != "Xing" && tag != "Info" {
+		return 0
+	}
+
+	if flags := binary.BigEndian.Uint32(frame[offset+4:]); flags&1 == 0 {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint32(frame[offset+8:]))
+}
@@ -0,0 +1,150 @@
+package native
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// box is an ISO base media file format box (aka "atom")
+type box struct {
+	kind string
+	data []byte
+}
+
+// readBoxes splits the given data into boxes
+func readBoxes(data []byte) ([]box, error) {
+	boxes := make([]box, 0)
+
+	for pos := 0; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, fmt.Errorf("truncated box header")
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		headerSize := uint64(8)
+
+		switch size {
+		case 0:
+			// extends to the end of the file
+			size = uint64(len(data) - pos)
+		case 1:
+			if pos+16 > len(data) {
+				return nil, fmt.Errorf("truncated box header")
+			}
+
+			size = binary.BigEndian.Uint64(data[pos+8:])
+			headerSize = 16
+		}
+
+		// compared to what is left, as a large size would wrap around once
+		// added to the position
+		if size < headerSize || size > uint64(len(data)-pos) {
+			return nil, fmt.Errorf("box %q out of bounds", kind)
+		}
+
+		boxes = append(boxes, box{kind, data[uint64(pos)+headerSize : uint64(pos)+size]})
+		pos += int(size)
+	}
+
+	return boxes, nil
+}
+
+func findBox(boxes []box, kind string) (box, bool) {
+	for _, b := range boxes {
+		if b.kind == kind {
+			return b, true
+		}
+	}
+
+	return box{}, false
+}
+
+func probeMP4(content []byte) (Properties, error) {
+	boxes, err := readBoxes(content)
+	if err != nil {
+		return Properties{}, err
+	}
+
+	moov, found := findBox(boxes, "moov")
+	if !found {
+		return Properties{}, fmt.Errorf("moov box not found")
+	}
+
+	children, err := readBoxes(moov.data)
+	if err != nil {
+		return Properties{}, err
+	}
+
+	mvhd, found := findBox(children, "mvhd")
+	if !found || len(mvhd.data) < 20 {
+		return Properties{}, fmt.Errorf("mvhd box not found")
+	}
+
+	var timescale, duration uint64
+	if mvhd.data[0] == 1 {
+		if len(mvhd.data) < 32 {
+			return Properties{}, fmt.Errorf("truncated mvhd box")
+		}
+
+		timescale = uint64(binary.BigEndian.Uint32(mvhd.data[20:]))
+		duration = binary.BigEndian.Uint64(mvhd.data[24:])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(mvhd.data[12:]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd.data[16:]))
+	}
+
+	if timescale == 0 {
+		return Properties{}, fmt.Errorf("invalid mvhd timescale")
+	}
+
+	properties := Properties{
+		Duration: time.Duration(float64(duration) / float64(timescale) * float64(time.Second)),
+	}
+
+	if properties.Duration > 0 {
+		properties.Bitrate = int(float64(len(content)) * 8 / properties.Duration.Seconds())
+	}
+
+	// the dimensions are the ones of the first visual track
+	for _, trak := range children {
+		if trak.kind != "trak" {
+			continue
+		}
+
+		trakChildren, err := readBoxes(trak.data)
+		if err != nil {
+			continue
+		}
+
+		tkhd, found := findBox(trakChildren, "tkhd")
+		if !found {
+			continue
+		}
+
+		offset := 76
+		if len(tkhd.data) > 0 && tkhd.data[0] == 1 {
+			offset = 88
+		}
+
+		if len(tkhd.data) < offset+8 {
+			continue
+		}
+
+		// 16.16 fixed point numbers
+		width := int(binary.BigEndian.Uint32(tkhd.data[offset:]) >> 16)
+		height := int(binary.BigEndian.Uint32(tkhd.data[offset+4:]) >> 16)
+
+		if width > 0 && height > 0 {
+			properties.Width = width
+			properties.Height = height
+			break
+		}
+	}
+
+	return properties, nil
+}
@@ -0,0 +1,60 @@
+package native
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+var (
+	pdfObject = regexp.MustCompile(`(?s)\d+\s+\d+\s+obj\b(.*?)\bendobj`)
+	pdfPages  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfPage   = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfCount  = regexp.MustCompile(`/Count\s+(\d+)`)
+)
+
+// probePDF counts the pages of a PDF, relying on the /Count of the root of
+// the pages tree, which is the biggest of them all. Documents with compressed
+// object streams are not supported.
+func probePDF(content []byte) (Properties, error) {
+	if !bytes.HasPrefix(content, []byte("%PDF-")) {
+		return Properties{}, fmt.Errorf("invalid pdf header")
+	}
+
+	pages := 0
+	leaves := 0
+
+	for _, object := range pdfObject.FindAllSubmatch(content, -1) {
+		body := object[1]
+
+		if pdfPage.Match(body) {
+			leaves++
+			continue
+		}
+
+		if !pdfPages.Match(body) {
+			continue
+		}
+
+		if count := pdfCount.FindSubmatch(body); count != nil {
+			if n, err := strconv.Atoi(string(count[1])); err == nil && n > pages {
+				pages = n
+			}
+		}
+	}
+
+	// no pages tree found, let's fall back to the pages we saw
+	if pages == 0 {
+		pages = leaves
+	}
+
+	if pages == 0 {
+		return Properties{}, fmt.Errorf("no pages found")
+	}
+
+	return Properties{Pages: pages}, nil
+}
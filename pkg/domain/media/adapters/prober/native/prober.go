@@ -0,0 +1,58 @@
+package native
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewProber returns a prober relying only on the standard library, and
+// handling the common image formats, WAV, MP3, MP4 and PDF files.
+func NewProber() MediaProber {
+	return &prober{}
+}
+
+type prober struct{}
+
+type probeFunc func(content []byte) (Properties, error)
+
+func (p *prober) Probe(ctx context.Context, mimetype string, fileContent []byte) (Properties, error) {
+	// the mimetype is guessed from the file extension, let's have a look at
+	// the content if it didn't give anything useful
+	if mimetype == "" || mimetype == "application/octet-stream" {
+		mimetype = http.DetectContentType(fileContent)
+	}
+
+	mimetype, _, _ = strings.Cut(mimetype, ";")
+
+	probe := probeFor(strings.TrimSpace(mimetype))
+	if probe == nil {
+		return Properties{}, UnsupportedMedia(mimetype)
+	}
+
+	return probe(fileContent)
+}
+
+func probeFor(mimetype string) probeFunc {
+	switch mimetype {
+	case "image/webp":
+		return probeWebP
+	case "audio/wav", "audio/wave", "audio/x-wav", "audio/vnd.wave":
+		return probeWAV
+	case "audio/mpeg", "audio/mp3":
+		return probeMP3
+	case "video/mp4", "audio/mp4", "video/quicktime", "audio/x-m4a":
+		return probeMP4
+	case "application/pdf":
+		return probePDF
+	}
+
+	if strings.HasPrefix(mimetype, "image/") {
+		return probeImage
+	}
+
+	return nil
+}
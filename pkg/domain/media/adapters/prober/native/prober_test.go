@@ -0,0 +1,225 @@
+package native
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func encodeImage(encode func(*bytes.Buffer, image.Image) error) []byte {
+	var buf bytes.Buffer
+	encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30)))
+	return buf.Bytes()
+}
+
+func fixtureWebP() []byte {
+	// VP8L chunk : signature, then width-1 and height-1 on 14 bits each
+	bits := uint32(40-1) | uint32(30-1)<<14
+	chunk := append([]byte("VP8L\x05\x00\x00\x00\x2f"), binary.LittleEndian.AppendUint32(nil, bits)...)
+	chunk = append(chunk, make([]byte, 16)...)
+
+	content := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(chunk)+4))...)
+	content = append(content, "WEBP"...)
+	return append(content, chunk...)
+}
+
+func fixtureWAV() []byte {
+	// 16 bits stereo at 8000Hz, with 2 seconds of silence
+	le := binary.LittleEndian
+	format := le.AppendUint16(nil, 1)      // PCM
+	format = le.AppendUint16(format, 2)    // channels
+	format = le.AppendUint32(format, 8000) // sample rate
+	format = le.AppendUint32(format, 8000*2*2)
+	format = le.AppendUint16(format, 4)
+	format = le.AppendUint16(format, 16)
+
+	data := make([]byte, 8000*2*2*2)
+
+	content := []byte("RIFF\x00\x00\x00\x00WAVE")
+	content = append(content, "fmt "...)
+	content = le.AppendUint32(content, uint32(len(format)))
+	content = append(content, format...)
+	content = append(content, "data"...)
+	content = le.AppendUint32(content, uint32(len(data)))
+	return append(content, data...)
+}
+
+// MPEG 1 layer III, 128kbps, 44100Hz, stereo
+const mp3Header = 0xFFFB9000
+
+func fixtureMP3CBR() []byte {
+	// 16000 bytes at 128kbps is exactly one second
+	content := []byte("ID3\x04\x00\x00\x00\x00\x00\x0a")
+	content = append(content, make([]byte, 10)...)
+
+	frames := binary.BigEndian.AppendUint32(nil, mp3Header)
+	frames = append(frames, make([]byte, 16000-4)...)
+	return append(content, frames...)
+}
+
+func fixtureMP3VBR() []byte {
+	frame := binary.BigEndian.AppendUint32(nil, mp3Header)
+	frame = append(frame, make([]byte, 32)...)
+	frame = append(frame, "Xing"...)
+	frame = binary.BigEndian.AppendUint32(frame, 1)
+	// 1152 samples per frame, 44100Hz : 3 seconds
+	frame = binary.BigEndian.AppendUint32(frame, 44100*3/1152)
+	return append(frame, make([]byte, 24000-len(frame))...)
+}
+
+func mp4Box(kind string, data ...[]byte) []byte {
+	content := bytes.Join(data, nil)
+	result := binary.BigEndian.AppendUint32(nil, uint32(len(content)+8))
+	result = append(result, kind...)
+	return append(result, content...)
+}
+
+func fixtureMP4() []byte {
+	be := binary.BigEndian
+
+	mvhd := make([]byte, 100)
+	be.PutUint32(mvhd[12:], 1000)  // timescale
+	be.PutUint32(mvhd[16:], 10000) // 10 seconds
+
+	audio := make([]byte, 84)
+	video := make([]byte, 84)
+	be.PutUint32(video[76:], 1920<<16)
+	be.PutUint32(video[80:], 1080<<16)
+
+	return append(
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Box("trak", mp4Box("tkhd", audio)),
+			mp4Box("trak", mp4Box("tkhd", video)),
+		)...,
+	)
+}
+
+const fixturePDF = `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 3 >> endobj
+3 0 obj << /Type /Pages /Parent 2 0 R /Kids [5 0 R 6 0 R] /Count 2 >> endobj
+4 0 obj << /Type /Page /Parent 2 0 R >> endobj
+5 0 obj << /Type /Page /Parent 3 0 R >> endobj
+6 0 obj << /Type /Page /Parent 3 0 R >> endobj
+trailer << /Root 1 0 R >>
+%%EOF`
+
+func TestProbe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prober := NewProber()
+
+	testCases := []struct {
+		name     string
+		mimetype string
+		content  []byte
+		expected Properties
+	}{
+		{
+			name:     "png",
+			mimetype: "image/png",
+			content:  encodeImage(func(b *bytes.Buffer, i image.Image) error { return png.Encode(b, i) }),
+			expected: Properties{Width: 40, Height: 30},
+		},
+		{
+			name:     "jpeg",
+			mimetype: "image/jpeg",
+			content:  encodeImage(func(b *bytes.Buffer, i image.Image) error { return jpeg.Encode(b, i, nil) }),
+			expected: Properties{Width: 40, Height: 30},
+		},
+		{
+			name:     "gif sniffed from an unknown mimetype",
+			mimetype: "application/octet-stream",
+			content:  encodeImage(func(b *bytes.Buffer, i image.Image) error { return gif.Encode(b, i, nil) }),
+			expected: Properties{Width: 40, Height: 30},
+		},
+		{
+			name:     "webp",
+			mimetype: "image/webp",
+			content:  fixtureWebP(),
+			expected: Properties{Width: 40, Height: 30},
+		},
+		{
+			name:     "wav",
+			mimetype: "audio/wav",
+			content:  fixtureWAV(),
+			expected: Properties{Duration: 2 * time.Second, Bitrate: 256000},
+		},
+		{
+			name:     "constant bitrate mp3",
+			mimetype: "audio/mpeg",
+			content:  fixtureMP3CBR(),
+			expected: Properties{Duration: time.Second, Bitrate: 128000},
+		},
+		{
+			name:     "variable bitrate mp3",
+			mimetype: "audio/mpeg",
+			content:  fixtureMP3VBR(),
+			expected: Properties{Duration: 114 * 1152 * time.Second / 44100, Bitrate: 64473},
+		},
+		{
+			name:     "mp4",
+			mimetype: "video/mp4",
+			content:  fixtureMP4(),
+			expected: Properties{Width: 1920, Height: 1080, Duration: 10 * time.Second, Bitrate: len(fixtureMP4()) * 8 / 10},
+		},
+		{
+			name:     "pdf",
+			mimetype: "application/pdf",
+			content:  []byte(fixturePDF),
+			expected: Properties{Pages: 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			properties, err := prober.Probe(ctx, tc.mimetype, tc.content)
+			if err != nil {
+				t.Fatalf("unexpected error while probing : %s", err)
+			}
+
+			if properties != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, properties)
+			}
+		})
+	}
+
+	t.Run("unsupported media", func(t *testing.T) {
+		_, err := prober.Probe(ctx, "text/plain", []byte("hello"))
+		if !errors.Is(err, ErrUnsupportedMedia) {
+			t.Errorf("expected an unsupported media error, got %s", err)
+		}
+	})
+
+	t.Run("box size wrapping around", func(t *testing.T) {
+		// a 64 bits size which would wrap around once added to the position
+		header := binary.BigEndian.AppendUint32(nil, 1)
+		header = append(header, "moov"...)
+		header = binary.BigEndian.AppendUint64(header, 1<<64-4)
+
+		content := append(mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")), header...)
+		if _, err := prober.Probe(ctx, "video/mp4", content); err == nil {
+			t.Errorf("expected an error when probing a box out of bounds")
+		}
+	})
+
+	t.Run("corrupted media", func(t *testing.T) {
+		for _, mimetype := range []string{"image/png", "image/webp", "audio/wav", "audio/mpeg", "video/mp4", "application/pdf"} {
+			if _, err := prober.Probe(ctx, mimetype, []byte("garbage")); err == nil {
+				t.Errorf("expected an error when probing a corrupted %q file", mimetype)
+			}
+		}
+	})
+}
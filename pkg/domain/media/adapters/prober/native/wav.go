@@ -0,0 +1,53 @@
+package native
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func probeWAV(content []byte) (Properties, error) {
+	if len(content) < 12 || string(content[:4]) != "RIFF" || string(content[8:12]) != "WAVE" {
+		return Properties{}, fmt.Errorf("invalid wav header")
+	}
+
+	var byteRate, dataSize uint32
+
+	pos := 12
+	for pos+8 <= len(content) {
+		id := string(content[pos : pos+4])
+		size := binary.LittleEndian.Uint32(content[pos+4:])
+		data := content[pos+8:]
+
+		switch id {
+		case "fmt ":
+			if len(data) < 16 {
+				return Properties{}, fmt.Errorf("truncated wav format chunk")
+			}
+
+			byteRate = binary.LittleEndian.Uint32(data[8:])
+
+		case "data":
+			dataSize = size
+		}
+
+		if dataSize > 0 && byteRate > 0 {
+			break
+		}
+
+		// chunks are word aligned
+		pos += 8 + int(size) + int(size&1)
+	}
+
+	if byteRate == 0 {
+		return Properties{}, fmt.Errorf("wav format chunk not found")
+	}
+
+	return Properties{
+		Duration: time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second)),
+		Bitrate:  int(byteRate) * 8,
+	}, nil
+}
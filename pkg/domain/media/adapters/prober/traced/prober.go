@@ -0,0 +1,27 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewProber decorates a prober so that each probe is traced in a span.
+func NewProber(prober MediaProber, tracer *tracing.Tracer) MediaProber {
+	return &tracedProber{prober, tracer}
+}
+
+type tracedProber struct {
+	prober MediaProber
+	tracer *tracing.Tracer
+}
+
+// Probe implements media.MediaProber.
+func (p *tracedProber) Probe(ctx context.Context, mimetype string, fileContent []byte) (properties Properties, err error) {
+	ctx, span := p.tracer.Start(ctx, "MediaProber.Probe", "media.mimetype", mimetype, "media.size", len(fileContent))
+	defer func() { span.Finish(err) }()
+
+	return p.prober.Probe(ctx, mimetype, fileContent)
+}
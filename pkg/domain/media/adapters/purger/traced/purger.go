@@ -0,0 +1,27 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewPurger decorates a cache purger so that each purge is traced in a span.
+func NewPurger(purger CachePurger, tracer *tracing.Tracer) CachePurger {
+	return &tracedPurger{purger, tracer}
+}
+
+type tracedPurger struct {
+	purger CachePurger
+	tracer *tracing.Tracer
+}
+
+// Purge implements media.CachePurger.
+func (p *tracedPurger) Purge(ctx context.Context, media Media, event PurgeEvent) (err error) {
+	ctx, span := p.tracer.StartClient(ctx, "CachePurger.Purge", "media.id", media.ID, "purge.event", string(event))
+	defer func() { span.Finish(err) }()
+
+	return p.purger.Purge(ctx, media, event)
+}
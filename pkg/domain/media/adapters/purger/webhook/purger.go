@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// SignatureHeader holds the HMAC-SHA256 of the body of the notifications,
+// as sha256=<hex>, if they are signed
+const SignatureHeader = "X-Webhook-Signature"
+
+type Config struct {
+	// URL the notifications are posted to
+	URL string
+
+	// Secret signs the notifications, so that the receiver can check they
+	// were sent by this application. They are not signed if it is empty.
+	Secret []byte
+
+	// Timeout is the maximum duration of a notification. Defaults to 10
+	// seconds.
+	Timeout time.Duration
+
+	// Client posts the notifications, a client with the timeout by default
+	Client *http.Client
+}
+
+// NewPurger returns a purger posting a json notification to a webhook, such
+// as one purging the medias from a CDN, for each media to purge :
+//
+//	{"event":"replaced","media_id":"...","tenant":"...","occurred_at":"..."}
+//
+// Any status other than a 2xx is an error.
+func NewPurger(config Config) CachePurger {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: cmp.Or(config.Timeout, 10*time.Second)}
+	}
+
+	return &purger{url: config.URL, secret: config.Secret, client: client}
+}
+
+type purger struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+type notification struct {
+	Event      PurgeEvent `json:"event"`
+	MediaID    string     `json:"media_id"`
+	Tenant     string     `json:"tenant,omitempty"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// Purge implements media.CachePurger.
+func (p *purger) Purge(ctx context.Context, media Media, event PurgeEvent) error {
+	body, err := json.Marshal(notification{
+		Event:      event,
+		MediaID:    media.ID,
+		Tenant:     media.Tenant,
+		OccurredAt: time.Now().UTC(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, request.Header)
+
+	if len(p.secret) > 0 {
+		mac := hmac.New(sha256.New, p.secret)
+		mac.Write(body)
+		request.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("could not notify the purge of %q : %w", media.ID, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("could not notify the purge of %q : unexpected status %d", media.ID, response.StatusCode)
+	}
+
+	logging.LoggerFrom(ctx).Debug("media purge notified", "media_id", media.ID, "event", event)
+	return nil
+}
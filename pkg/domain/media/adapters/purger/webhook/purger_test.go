@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+func TestPurger(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var received *http.Request
+	var body []byte
+	status := http.StatusNoContent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	secret := []byte("secret")
+	purger := NewPurger(Config{URL: server.URL, Secret: secret})
+	media := Media{ID: "media-1", Tenant: "acme"}
+
+	t.Run("notified", func(t *testing.T) {
+		ctx, span := tracing.NewTracer(tracing.NoopExporter{}).Start(ctx, "purge")
+
+		if err := purger.Purge(ctx, media, PurgeReplaced); err != nil {
+			t.Fatalf("expected the purge to be notified, got %s", err)
+		}
+
+		if received.Method != http.MethodPost || received.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a json POST, got a %s of %q", received.Method, received.Header.Get("Content-Type"))
+		}
+
+		var got notification
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("expected a json notification, got %s", err)
+		}
+
+		if got.Event != PurgeReplaced || got.MediaID != "media-1" || got.Tenant != "acme" || got.OccurredAt.IsZero() {
+			t.Errorf("unexpected notification %+v", got)
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if expected := "sha256=" + hex.EncodeToString(mac.Sum(nil)); received.Header.Get(SignatureHeader) != expected {
+			t.Errorf("expected the signature %q, got %q", expected, received.Header.Get(SignatureHeader))
+		}
+
+		traceparent, err := tracing.ParseTraceparent(received.Header.Get(tracing.TraceparentHeader))
+		if err != nil || traceparent.TraceID != span.Context.TraceID {
+			t.Errorf("expected the trace to be propagated, got %q", received.Header.Get(tracing.TraceparentHeader))
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		if err := NewPurger(Config{URL: server.URL}).Purge(ctx, media, PurgeDeleted); err != nil {
+			t.Fatalf("expected the purge to be notified, got %s", err)
+		}
+
+		if signature := received.Header.Get(SignatureHeader); signature != "" {
+			t.Errorf("expected the notification not to be signed, got %q", signature)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		status = http.StatusBadGateway
+
+		if err := purger.Purge(ctx, media, PurgeDeleted); err == nil {
+			t.Errorf("expected an error when the webhook fails")
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		unreachable := NewPurger(Config{URL: "http://127.0.0.1:1", Timeout: time.Second})
+
+		if err := unreachable.Purge(ctx, media, PurgeDeleted); err == nil {
+			t.Errorf("expected an error when the webhook is unreachable")
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewFake() QuotaRepository {
+	return &repository{
+		usages: make(map[string]Usage),
+	}
+}
+
+type repository struct {
+	usages map[string]Usage
+	mtx    sync.RWMutex
+}
+
+func (r *repository) Get(ctx context.Context, accounts ...string) (map[string]Usage, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	result := make(map[string]Usage, len(accounts))
+	for _, account := range accounts {
+		result[account] = r.usages[account]
+	}
+
+	return result, nil
+}
+
+func (r *repository) Add(ctx context.Context, delta Usage, limits map[string]Usage) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// freeing some space is always allowed, even if the limits were lowered
+	// in the meantime
+	if delta.Bytes > 0 || delta.Medias > 0 {
+		for account, limit := range limits {
+			usage := r.usages[account]
+			usage.Bytes += delta.Bytes
+			usage.Medias += delta.Medias
+
+			if usage.Exceeds(limit) {
+				return QuotaExceeded(account)
+			}
+		}
+	}
+
+	for account := range limits {
+		usage := r.usages[account]
+		usage.Bytes = max(usage.Bytes+delta.Bytes, 0)
+		usage.Medias = max(usage.Medias+delta.Medias, 0)
+		r.usages[account] = usage
+	}
+
+	return nil
+}
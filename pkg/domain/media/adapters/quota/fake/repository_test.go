@@ -0,0 +1,48 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestAdd(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+	limits := map[string]Usage{
+		"user":   {Bytes: 10},
+		"tenant": {Medias: 2},
+	}
+
+	if err := repository.Add(ctx, Usage{Bytes: 6, Medias: 1}, limits); err != nil {
+		t.Fatalf("unexpected error while adding some usage : %s", err)
+	}
+
+	if err := repository.Add(ctx, Usage{Bytes: 6, Medias: 1}, limits); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected a quota exceeded error, got %v", err)
+	}
+
+	usages, _ := repository.Get(ctx, "user", "tenant", "unknown")
+	if usages["user"] != (Usage{Bytes: 6, Medias: 1}) || usages["tenant"] != (Usage{Bytes: 6, Medias: 1}) {
+		t.Errorf("expected nothing to be added when a quota is exceeded, got %+v", usages)
+	}
+
+	if usages["unknown"] != (Usage{}) {
+		t.Errorf("expected an unknown account to have used nothing, got %+v", usages["unknown"])
+	}
+
+	// lowered limits don't prevent from freeing space
+	if err := repository.Add(ctx, Usage{Bytes: -6, Medias: -1}, map[string]Usage{"user": {Bytes: 1}}); err != nil {
+		t.Fatalf("unexpected error while freeing some space : %s", err)
+	}
+
+	usages, _ = repository.Get(ctx, "user")
+	if usages["user"] != (Usage{}) {
+		t.Errorf("expected the space to be freed, got %+v", usages["user"])
+	}
+}
@@ -0,0 +1,36 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewRepository decorates a quota repository so that each of its calls is
+// traced in a span.
+func NewRepository(repository QuotaRepository, tracer *tracing.Tracer) QuotaRepository {
+	return &tracedRepository{repository, tracer}
+}
+
+type tracedRepository struct {
+	repository QuotaRepository
+	tracer     *tracing.Tracer
+}
+
+// Get implements media.QuotaRepository.
+func (r *tracedRepository) Get(ctx context.Context, accounts ...string) (usages map[string]Usage, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "QuotaRepository.Get", "accounts.count", len(accounts))
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Get(ctx, accounts...)
+}
+
+// Add implements media.QuotaRepository.
+func (r *tracedRepository) Add(ctx context.Context, delta Usage, limits map[string]Usage) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "QuotaRepository.Add", "delta.bytes", delta.Bytes, "delta.medias", delta.Medias)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Add(ctx, delta, limits)
+}
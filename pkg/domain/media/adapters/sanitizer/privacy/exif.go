@@ -0,0 +1,200 @@
+package privacy
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Well known EXIF tags
+const (
+	TagOrientation      uint16 = 0x0112
+	TagArtist           uint16 = 0x013B
+	TagHostComputer     uint16 = 0x013C
+	TagExifIFD          uint16 = 0x8769
+	TagGPSIFD           uint16 = 0x8825
+	TagMakerNote        uint16 = 0x927C
+	TagUserComment      uint16 = 0x9286
+	TagInteropIFD       uint16 = 0xA005
+	TagImageUniqueID    uint16 = 0xA420
+	TagCameraOwnerName  uint16 = 0xA430
+	TagBodySerialNumber uint16 = 0xA431
+	TagLensSerialNumber uint16 = 0xA435
+)
+
+// DefaultTags are the tags stripped when none are configured : the whole GPS
+// block, serial numbers and anything that could identify the owner.
+var DefaultTags = []uint16{
+	TagGPSIFD,
+	TagArtist,
+	TagHostComputer,
+	TagMakerNote,
+	TagUserComment,
+	TagImageUniqueID,
+	TagCameraOwnerName,
+	TagBodySerialNumber,
+	TagLensSerialNumber,
+}
+
+var errInvalidExif = fmt.Errorf("invalid exif data")
+
+// size in bytes of one component for each of the TIFF field types
+var typeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// scrubber removes tags from a TIFF structure (the payload of an EXIF block),
+// in place : nothing is moved around so every offset stays valid, removed
+// entries are taken out of their IFD and the values they pointed to are
+// zeroed out.
+type scrubber struct {
+	data    []byte
+	order   binary.ByteOrder
+	strip   map[uint16]bool
+	visited map[uint32]bool
+}
+
+func scrubTIFF(data []byte, strip map[uint16]bool) error {
+	if len(data) < 8 {
+		return errInvalidExif
+	}
+
+	s := &scrubber{data: data, strip: strip, visited: make(map[uint32]bool)}
+
+	switch string(data[:4]) {
+	case "II*\x00":
+		s.order = binary.LittleEndian
+	case "MM\x00*":
+		s.order = binary.BigEndian
+	default:
+		return errInvalidExif
+	}
+
+	// IFD0 and its followers (IFD1 being the thumbnail)
+	offset := s.order.Uint32(data[4:])
+	for offset != 0 {
+		next, err := s.scrubIFD(offset)
+		if err != nil {
+			return err
+		}
+
+		offset = next
+	}
+
+	return nil
+}
+
+// scrubIFD scrubs the IFD at the given offset, and returns the offset of the
+// next one.
+func (s *scrubber) scrubIFD(offset uint32) (uint32, error) {
+	count, err := s.entriesCount(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	s.visited[offset] = true
+
+	start := offset + 2
+	end := start + 12*count
+	next := s.order.Uint32(s.data[end:])
+
+	kept := make([][]byte, 0, count)
+	for i := uint32(0); i < count; i++ {
+		entry := s.data[start+12*i : start+12*(i+1)]
+		tag := s.order.Uint16(entry)
+
+		if s.strip[tag] {
+			if isSubIFD(tag) {
+				s.wipeIFD(s.order.Uint32(entry[8:]))
+			}
+
+			s.wipeValue(entry)
+			continue
+		}
+
+		if isSubIFD(tag) {
+			if _, err := s.scrubIFD(s.order.Uint32(entry[8:])); err != nil {
+				return 0, err
+			}
+		}
+
+		kept = append(kept, append([]byte(nil), entry...))
+	}
+
+	if len(kept) == int(count) {
+		return next, nil
+	}
+
+	// rewrite the IFD with only the kept entries, and blank what's left
+	s.order.PutUint16(s.data[offset:], uint16(len(kept)))
+	for i, entry := range kept {
+		copy(s.data[start+12*uint32(i):], entry)
+	}
+
+	s.order.PutUint32(s.data[start+12*uint32(len(kept)):], next)
+	clear(s.data[start+12*uint32(len(kept))+4 : end+4])
+
+	return next, nil
+}
+
+// wipeIFD zeroes out an IFD and every value it references. Nested IFDs are
+// not followed, as only the GPS one should ever be wiped.
+func (s *scrubber) wipeIFD(offset uint32) {
+	count, err := s.entriesCount(offset)
+	if err != nil {
+		return
+	}
+
+	s.visited[offset] = true
+
+	start := offset + 2
+	for i := uint32(0); i < count; i++ {
+		s.wipeValue(s.data[start+12*i : start+12*(i+1)])
+	}
+
+	clear(s.data[offset : start+12*count+4])
+}
+
+// wipeValue zeroes out the value referenced by an entry if it is not stored
+// inline.
+func (s *scrubber) wipeValue(entry []byte) {
+	size, known := typeSizes[s.order.Uint16(entry[2:])]
+	if !known {
+		return
+	}
+
+	length := uint64(size) * uint64(s.order.Uint32(entry[4:]))
+	if length <= 4 {
+		clear(entry[8:12])
+		return
+	}
+
+	valueOffset := uint64(s.order.Uint32(entry[8:]))
+	if valueOffset+length > uint64(len(s.data)) {
+		return
+	}
+
+	clear(s.data[valueOffset : valueOffset+length])
+}
+
+// entriesCount validates the IFD at the given offset and returns its number
+// of entries.
+func (s *scrubber) entriesCount(offset uint32) (uint32, error) {
+	if s.visited[offset] {
+		return 0, fmt.Errorf("%w : loop detected at offset %d", errInvalidExif, offset)
+	}
+
+	if uint64(offset)+2 > uint64(len(s.data)) {
+		return 0, fmt.Errorf("%w : ifd offset %d out of bounds", errInvalidExif, offset)
+	}
+
+	count := uint32(s.order.Uint16(s.data[offset:]))
+	if uint64(offset)+2+12*uint64(count)+4 > uint64(len(s.data)) {
+		return 0, fmt.Errorf("%w : ifd at offset %d is truncated", errInvalidExif, offset)
+	}
+
+	return count, nil
+}
+
+func isSubIFD(tag uint16) bool {
+	return tag == TagExifIFD || tag == TagGPSIFD || tag == TagInteropIFD
+}
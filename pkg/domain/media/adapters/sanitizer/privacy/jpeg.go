@@ -0,0 +1,97 @@
+package privacy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8}
+	exifHeader    = []byte("Exif\x00\x00")
+	xmpHeader     = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+var errInvalidJPEG = fmt.Errorf("invalid jpeg")
+
+const (
+	markerAPP1 = 0xE1
+	markerSOS  = 0xDA
+	markerEOI  = 0xD9
+)
+
+// scrubJPEG strips the given tags from the EXIF segments of a JPEG, and drops
+// the XMP segments altogether, as they may hold the same data.
+func scrubJPEG(content []byte, strip map[uint16]bool) ([]byte, error) {
+	result := make([]byte, 0, len(content))
+	result = append(result, jpegSignature...)
+
+	pos := len(jpegSignature)
+	for pos < len(content) {
+		if content[pos] != 0xFF {
+			return nil, fmt.Errorf("%w : expected a marker at offset %d", errInvalidJPEG, pos)
+		}
+
+		// skip fill bytes
+		if pos+1 < len(content) && content[pos+1] == 0xFF {
+			pos++
+			continue
+		}
+
+		if pos+1 >= len(content) {
+			return nil, fmt.Errorf("%w : truncated marker", errInvalidJPEG)
+		}
+
+		marker := content[pos+1]
+
+		// markers without any payload
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			result = append(result, content[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		if marker == markerEOI {
+			result = append(result, content[pos:]...)
+			break
+		}
+
+		if pos+4 > len(content) {
+			return nil, fmt.Errorf("%w : truncated segment", errInvalidJPEG)
+		}
+
+		length := int(binary.BigEndian.Uint16(content[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(content) {
+			return nil, fmt.Errorf("%w : segment at offset %d out of bounds", errInvalidJPEG, pos)
+		}
+
+		// from there on, this is the compressed image data
+		if marker == markerSOS {
+			result = append(result, content[pos:]...)
+			break
+		}
+
+		segment := content[pos:end]
+		payload := segment[4:]
+
+		if marker == markerAPP1 {
+			switch {
+			case bytes.HasPrefix(payload, xmpHeader):
+				pos = end
+				continue
+
+			case bytes.HasPrefix(payload, exifHeader):
+				segment = bytes.Clone(segment)
+				if err := scrubTIFF(segment[4+len(exifHeader):], strip); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		result = append(result, segment...)
+		pos = end
+	}
+
+	return result, nil
+}
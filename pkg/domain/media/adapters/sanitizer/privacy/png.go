@@ -0,0 +1,62 @@
+package privacy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var errInvalidPNG = fmt.Errorf("invalid png")
+
+const xmpKeyword = "XML:com.adobe.xmp\x00"
+
+// scrubPNG strips the given tags from the eXIf chunk of a PNG, and drops the
+// XMP text chunks altogether, as they may hold the same data.
+func scrubPNG(content []byte, strip map[uint16]bool) ([]byte, error) {
+	result := make([]byte, 0, len(content))
+	result = append(result, pngSignature...)
+
+	pos := len(pngSignature)
+	for pos < len(content) {
+		if pos+8 > len(content) {
+			return nil, fmt.Errorf("%w : truncated chunk", errInvalidPNG)
+		}
+
+		length := uint64(binary.BigEndian.Uint32(content[pos:]))
+		end := uint64(pos) + 12 + length
+		if end > uint64(len(content)) {
+			return nil, fmt.Errorf("%w : chunk at offset %d out of bounds", errInvalidPNG, pos)
+		}
+
+		chunk := content[pos:end]
+		kind := string(chunk[4:8])
+		data := chunk[8 : 8+length]
+		pos = int(end)
+
+		switch kind {
+		case "iTXt":
+			if bytes.HasPrefix(data, []byte(xmpKeyword)) {
+				continue
+			}
+
+		case "eXIf":
+			chunk = bytes.Clone(chunk)
+			if err := scrubTIFF(chunk[8:8+length], strip); err != nil {
+				return nil, err
+			}
+
+			binary.BigEndian.PutUint32(chunk[8+length:], crc32.ChecksumIEEE(chunk[4:8+length]))
+		}
+
+		result = append(result, chunk...)
+
+		if kind == "IEND" {
+			break
+		}
+	}
+
+	return result, nil
+}
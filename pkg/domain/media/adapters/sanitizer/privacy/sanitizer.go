@@ -0,0 +1,74 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+type Config struct {
+	// Tags are the EXIF tags to strip from the uploaded images. Defaults to
+	// DefaultTags if empty. The orientation is always kept.
+	Tags []uint16
+
+	// Originals, if set, is where the untouched uploads are kept. They are
+	// discarded otherwise.
+	Originals media.MediaUploader
+}
+
+// NewSanitizer returns a sanitizer stripping personal metadata (GPS location,
+// serial numbers, ...) from JPEG and PNG files before they are uploaded.
+// Other files are left as is.
+func NewSanitizer(config Config) media.MediaSanitizer {
+	tags := config.Tags
+	if len(tags) == 0 {
+		tags = DefaultTags
+	}
+
+	strip := make(map[uint16]bool, len(tags))
+	for _, tag := range tags {
+		strip[tag] = true
+	}
+
+	delete(strip, TagOrientation)
+
+	return &sanitizer{
+		originals: config.Originals,
+		strip:     strip,
+	}
+}
+
+type sanitizer struct {
+	originals media.MediaUploader
+	strip     map[uint16]bool
+}
+
+func (s *sanitizer) Sanitize(ctx context.Context, id string, fileContent []byte) ([]byte, error) {
+	scrubbed, err := Scrub(fileContent, s.strip)
+	if err != nil {
+		return nil, media.FileError(id, err)
+	}
+
+	if s.originals != nil {
+		if err := s.originals.Upload(ctx, id, fileContent); err != nil {
+			return nil, err
+		}
+	}
+
+	return scrubbed, nil
+}
+
+// Scrub strips the given EXIF tags from a JPEG or a PNG file, which is
+// detected by its signature. Any other content is returned as is. The given
+// content is never modified.
+func Scrub(content []byte, strip map[uint16]bool) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(content, jpegSignature):
+		return scrubJPEG(content, strip)
+	case bytes.HasPrefix(content, pngSignature):
+		return scrubPNG(content, strip)
+	default:
+		return content, nil
+	}
+}
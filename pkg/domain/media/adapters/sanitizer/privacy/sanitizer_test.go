@@ -0,0 +1,295 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
+)
+
+type testEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+	sub   []testEntry
+}
+
+// buildTIFF builds a little endian TIFF structure with the given IFD0
+func buildTIFF(ifd []testEntry) []byte {
+	buf := []byte("II*\x00\x08\x00\x00\x00")
+	_, buf = writeIFD(buf, ifd)
+	return buf
+}
+
+func writeIFD(buf []byte, ifd []testEntry) (uint32, []byte) {
+	le := binary.LittleEndian
+	offset := len(buf)
+	buf = append(buf, make([]byte, 2+12*len(ifd)+4)...)
+	le.PutUint16(buf[offset:], uint16(len(ifd)))
+
+	for i, entry := range ifd {
+		pos := offset + 2 + 12*i
+		le.PutUint16(buf[pos:], entry.tag)
+		le.PutUint16(buf[pos+2:], entry.kind)
+		le.PutUint32(buf[pos+4:], entry.count)
+
+		switch {
+		case entry.sub != nil:
+			var subOffset uint32
+			subOffset, buf = writeIFD(buf, entry.sub)
+			le.PutUint32(buf[pos+8:], subOffset)
+		case len(entry.value) <= 4:
+			copy(buf[pos+8:], entry.value)
+		default:
+			le.PutUint32(buf[pos+8:], uint32(len(buf)))
+			buf = append(buf, entry.value...)
+		}
+	}
+
+	return uint32(offset), buf
+}
+
+// readTags lists the tags found in the IFD0 and its sub IFDs
+func readTags(t *testing.T, data []byte) map[uint16]uint32 {
+	le := binary.LittleEndian
+	tags := make(map[uint16]uint32)
+
+	var read func(offset uint32)
+	read = func(offset uint32) {
+		count := uint32(le.Uint16(data[offset:]))
+		for i := uint32(0); i < count; i++ {
+			entry := data[offset+2+12*i:]
+			tag := le.Uint16(entry)
+			tags[tag] = le.Uint32(entry[8:])
+
+			if isSubIFD(tag) {
+				read(le.Uint32(entry[8:]))
+			}
+		}
+	}
+
+	read(le.Uint32(data[4:]))
+	return tags
+}
+
+func fixtureTIFF() []byte {
+	return buildTIFF([]testEntry{
+		{tag: TagOrientation, kind: 3, count: 1, value: []byte{6, 0}},
+		{tag: TagArtist, kind: 2, count: 9, value: []byte("John Doe\x00")},
+		{tag: TagExifIFD, kind: 4, count: 1, sub: []testEntry{
+			{tag: 0x9000, kind: 7, count: 4, value: []byte("0232")},
+			{tag: TagBodySerialNumber, kind: 2, count: 9, value: []byte("SN123456\x00")},
+		}},
+		{tag: TagGPSIFD, kind: 4, count: 1, sub: []testEntry{
+			{tag: 0x0001, kind: 2, count: 2, value: []byte("N\x00")},
+			{tag: 0x0002, kind: 5, count: 3, value: []byte("LATITUDE-LATITUDE-LATITU")},
+		}},
+	})
+}
+
+func assertScrubbed(t *testing.T, content []byte) {
+	for _, leak := range []string{"John Doe", "SN123456", "LATITUDE", "secret-place"} {
+		if bytes.Contains(content, []byte(leak)) {
+			t.Errorf("expected %q to be stripped from the content", leak)
+		}
+	}
+}
+
+func assertTags(t *testing.T, tags map[uint16]uint32) {
+	if tags[TagOrientation] != 6 {
+		t.Errorf("expected the orientation to be kept as %d, got %d", 6, tags[TagOrientation])
+	}
+
+	if _, exists := tags[0x9000]; !exists {
+		t.Errorf("expected the exif version to be kept")
+	}
+
+	for _, tag := range []uint16{TagArtist, TagGPSIFD, TagBodySerialNumber} {
+		if _, exists := tags[tag]; exists {
+			t.Errorf("expected the tag 0x%04X to be stripped", tag)
+		}
+	}
+}
+
+func fixtureImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+
+	return img
+}
+
+func TestScrubTIFF(t *testing.T) {
+	data := fixtureTIFF()
+	strip := map[uint16]bool{TagArtist: true, TagGPSIFD: true, TagBodySerialNumber: true}
+
+	if err := scrubTIFF(data, strip); err != nil {
+		t.Fatalf("unexpected error while scrubbing : %s", err)
+	}
+
+	assertScrubbed(t, data)
+	assertTags(t, readTags(t, data))
+
+	t.Run("invalid header", func(t *testing.T) {
+		if err := scrubTIFF([]byte("not a tiff"), strip); err == nil {
+			t.Errorf("expected an error on an invalid tiff")
+		}
+	})
+
+	t.Run("truncated ifd", func(t *testing.T) {
+		data := fixtureTIFF()
+		if err := scrubTIFF(data[:20], strip); err == nil {
+			t.Errorf("expected an error on a truncated tiff")
+		}
+	})
+
+	t.Run("ifd loop", func(t *testing.T) {
+		data := buildTIFF([]testEntry{{tag: TagOrientation, kind: 3, count: 1, value: []byte{1, 0}}})
+		// make the next ifd point back to IFD0
+		binary.LittleEndian.PutUint32(data[8+2+12:], 8)
+
+		if err := scrubTIFF(data, strip); err == nil {
+			t.Errorf("expected an error on a looping tiff")
+		}
+	})
+}
+
+func TestScrubJPEG(t *testing.T) {
+	var encoded bytes.Buffer
+	jpeg.Encode(&encoded, fixtureImage(), nil)
+
+	exif := append([]byte("Exif\x00\x00"), fixtureTIFF()...)
+	xmp := append([]byte("http://ns.adobe.com/xap/1.0/\x00"), []byte("<x:xmpmeta>secret-place</x:xmpmeta>")...)
+
+	content := append([]byte(nil), encoded.Bytes()[:2]...)
+	for _, payload := range [][]byte{exif, xmp} {
+		content = append(content, 0xFF, markerAPP1)
+		content = binary.BigEndian.AppendUint16(content, uint16(len(payload)+2))
+		content = append(content, payload...)
+	}
+	content = append(content, encoded.Bytes()[2:]...)
+	original := bytes.Clone(content)
+
+	scrubbed, err := Scrub(content, map[uint16]bool{TagArtist: true, TagGPSIFD: true, TagBodySerialNumber: true})
+	if err != nil {
+		t.Fatalf("unexpected error while scrubbing : %s", err)
+	}
+
+	if !bytes.Equal(content, original) {
+		t.Errorf("expected the original content to be left untouched")
+	}
+
+	assertScrubbed(t, scrubbed)
+	assertTags(t, readTags(t, scrubbed[2+4+len(exifHeader):]))
+
+	if _, err := jpeg.Decode(bytes.NewReader(scrubbed)); err != nil {
+		t.Errorf("expected the scrubbed jpeg to still be valid, got %s", err)
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := Scrub(content[:10], nil); err == nil {
+			t.Errorf("expected an error on a truncated jpeg")
+		}
+	})
+}
+
+func TestScrubPNG(t *testing.T) {
+	var encoded bytes.Buffer
+	png.Encode(&encoded, fixtureImage())
+
+	chunk := func(kind string, data []byte) []byte {
+		result := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+		result = append(result, kind...)
+		result = append(result, data...)
+		return binary.BigEndian.AppendUint32(result, crc32.ChecksumIEEE(result[4:]))
+	}
+
+	// insert the metadata right after the IHDR chunk
+	ihdrEnd := len(pngSignature) + 12 + 13
+	content := append([]byte(nil), encoded.Bytes()[:ihdrEnd]...)
+	content = append(content, chunk("eXIf", fixtureTIFF())...)
+	content = append(content, chunk("iTXt", []byte(xmpKeyword+"\x00\x00\x00\x00secret-place"))...)
+	content = append(content, encoded.Bytes()[ihdrEnd:]...)
+
+	scrubbed, err := Scrub(content, map[uint16]bool{TagArtist: true, TagGPSIFD: true, TagBodySerialNumber: true})
+	if err != nil {
+		t.Fatalf("unexpected error while scrubbing : %s", err)
+	}
+
+	assertScrubbed(t, scrubbed)
+	assertTags(t, readTags(t, scrubbed[ihdrEnd+8:]))
+
+	if _, err := png.Decode(bytes.NewReader(scrubbed)); err != nil {
+		t.Errorf("expected the scrubbed png to still be valid, got %s", err)
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		if _, err := Scrub(content[:ihdrEnd+20], nil); err == nil {
+			t.Errorf("expected an error on a truncated png")
+		}
+	})
+}
+
+func TestSanitizer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	content := append([]byte("\x89PNG\r\n\x1a\n"), []byte("\x00\x00\x00\x04eXIf")...)
+	content = append(content, fixtureTIFF()[:4]...)
+
+	t.Run("invalid image", func(t *testing.T) {
+		sanitizer := NewSanitizer(Config{})
+		if _, err := sanitizer.Sanitize(ctx, "media", content); !errors.Is(err, media.ErrFile) {
+			t.Errorf("expected a file error when sanitizing an invalid png, got %s", err)
+		}
+	})
+
+	t.Run("other files", func(t *testing.T) {
+		sanitized, err := NewSanitizer(Config{}).Sanitize(ctx, "media", []byte("John Doe"))
+		if err != nil {
+			t.Fatalf("unexpected error while sanitizing : %s", err)
+		}
+
+		if string(sanitized) != "John Doe" {
+			t.Errorf("expected non image files to be left as is, got %q", sanitized)
+		}
+	})
+
+	t.Run("originals", func(t *testing.T) {
+		var encoded bytes.Buffer
+		jpeg.Encode(&encoded, fixtureImage(), nil)
+
+		exif := append([]byte("Exif\x00\x00"), fixtureTIFF()...)
+		content := append([]byte(nil), encoded.Bytes()[:2]...)
+		content = append(content, 0xFF, markerAPP1)
+		content = binary.BigEndian.AppendUint16(content, uint16(len(exif)+2))
+		content = append(content, exif...)
+		content = append(content, encoded.Bytes()[2:]...)
+
+		originals := fake.NewUploader()
+		sanitizer := NewSanitizer(Config{Originals: originals})
+
+		sanitized, err := sanitizer.Sanitize(ctx, "media", content)
+		if err != nil {
+			t.Fatalf("unexpected error while sanitizing : %s", err)
+		}
+
+		assertScrubbed(t, sanitized)
+
+		original, _ := originals.GetContent(ctx, "media")
+		if !bytes.Equal(original, content) {
+			t.Errorf("expected the original to be kept untouched")
+		}
+	})
+}
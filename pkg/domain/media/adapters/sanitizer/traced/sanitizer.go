@@ -0,0 +1,28 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewSanitizer decorates a sanitizer so that each sanitization is traced in a
+// span.
+func NewSanitizer(sanitizer MediaSanitizer, tracer *tracing.Tracer) MediaSanitizer {
+	return &tracedSanitizer{sanitizer, tracer}
+}
+
+type tracedSanitizer struct {
+	sanitizer MediaSanitizer
+	tracer    *tracing.Tracer
+}
+
+// Sanitize implements media.MediaSanitizer.
+func (s *tracedSanitizer) Sanitize(ctx context.Context, mediaID string, fileContent []byte) (sanitized []byte, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaSanitizer.Sanitize", "media.id", mediaID, "media.size", len(fileContent))
+	defer func() { span.Finish(err) }()
+
+	return s.sanitizer.Sanitize(ctx, mediaID, fileContent)
+}
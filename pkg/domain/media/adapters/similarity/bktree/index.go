@@ -0,0 +1,135 @@
+package bktree
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewIndex returns an in memory similarity index, backed by a BK-tree : each
+// node only needs to explore the children whose distance is within range, as
+// the Hamming distance satisfies the triangle inequality.
+func NewIndex() SimilarityIndex {
+	return &index{}
+}
+
+type node struct {
+	hash     PerceptualHash
+	mediaIDs []string
+	children map[int]*node
+}
+
+type index struct {
+	root *node
+	// hash each media is indexed with, so that it is only indexed once
+	hashes map[string]PerceptualHash
+	mtx    sync.RWMutex
+}
+
+// Add implements media.SimilarityIndex. A media indexed again is only kept
+// with its last hash.
+func (i *index) Add(ctx context.Context, mediaID string, hash PerceptualHash) error {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	if previous, indexed := i.hashes[mediaID]; indexed {
+		if previous == hash {
+			return nil
+		}
+
+		i.remove(mediaID, previous)
+	}
+
+	if i.hashes == nil {
+		i.hashes = make(map[string]PerceptualHash)
+	}
+
+	i.hashes[mediaID] = hash
+
+	if i.root == nil {
+		i.root = &node{hash: hash, mediaIDs: []string{mediaID}}
+		return nil
+	}
+
+	current := i.root
+	for {
+		distance := current.hash.Distance(hash)
+		if distance == 0 {
+			current.mediaIDs = append(current.mediaIDs, mediaID)
+			return nil
+		}
+
+		child, exists := current.children[distance]
+		if !exists {
+			if current.children == nil {
+				current.children = make(map[int]*node)
+			}
+
+			current.children[distance] = &node{hash: hash, mediaIDs: []string{mediaID}}
+			return nil
+		}
+
+		current = child
+	}
+}
+
+func (i *index) Search(ctx context.Context, hash PerceptualHash, maxDistance int) (map[string]int, error) {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+
+	result := make(map[string]int)
+	if i.root == nil {
+		return result, nil
+	}
+
+	candidates := []*node{i.root}
+	for len(candidates) > 0 {
+		current := candidates[len(candidates)-1]
+		candidates = candidates[:len(candidates)-1]
+
+		distance := current.hash.Distance(hash)
+		if distance <= maxDistance {
+			for _, mediaID := range current.mediaIDs {
+				result[mediaID] = distance
+			}
+		}
+
+		for childDistance, child := range current.children {
+			if childDistance >= distance-maxDistance && childDistance <= distance+maxDistance {
+				candidates = append(candidates, child)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Remove implements media.SimilarityIndex. The node of the hash is kept, as
+// its children are placed according to it, but no longer holds the media.
+func (i *index) Remove(ctx context.Context, mediaID string, hash PerceptualHash) error {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	if i.hashes[mediaID] == hash {
+		delete(i.hashes, mediaID)
+	}
+
+	i.remove(mediaID, hash)
+	return nil
+}
+
+func (i *index) remove(mediaID string, hash PerceptualHash) {
+	current := i.root
+	for current != nil {
+		distance := current.hash.Distance(hash)
+		if distance == 0 {
+			current.mediaIDs = slices.DeleteFunc(current.mediaIDs, func(id string) bool { return id == mediaID })
+			return
+		}
+
+		current = current.children[distance]
+	}
+}
@@ -0,0 +1,115 @@
+package bktree
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestSearch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	index := NewIndex()
+
+	t.Run("empty index", func(t *testing.T) {
+		found, err := index.Search(ctx, 0, 64)
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if len(found) != 0 {
+			t.Fatalf("expected no media to be found, got %d", len(found))
+		}
+	})
+
+	index.Add(ctx, "media-1", 0b0000)
+	index.Add(ctx, "media-2", 0b0001)
+	index.Add(ctx, "media-3", 0b0011)
+	index.Add(ctx, "media-4", 0b0011) // same hash as media-3
+	index.Add(ctx, "media-5", 0b1111)
+	index.Add(ctx, "media-6", PerceptualHash(^uint64(0)))
+
+	testCases := []struct {
+		name        string
+		hash        PerceptualHash
+		maxDistance int
+		expected    map[string]int
+	}{
+		{
+			name:        "exact match",
+			hash:        0b0011,
+			maxDistance: 0,
+			expected:    map[string]int{"media-3": 0, "media-4": 0},
+		},
+		{
+			name:        "close matches",
+			hash:        0b0001,
+			maxDistance: 1,
+			expected:    map[string]int{"media-1": 1, "media-2": 0, "media-3": 1, "media-4": 1},
+		},
+		{
+			name:        "far away",
+			hash:        PerceptualHash(^uint64(0) - 1),
+			maxDistance: 2,
+			expected:    map[string]int{"media-6": 1},
+		},
+		{
+			name:        "everything",
+			hash:        0,
+			maxDistance: 64,
+			expected:    map[string]int{"media-1": 0, "media-2": 1, "media-3": 2, "media-4": 2, "media-5": 4, "media-6": 64},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := index.Search(ctx, tc.hash, tc.maxDistance)
+			if err != nil {
+				t.Fatalf("unexpected error : %s", err)
+			}
+
+			if len(found) != len(tc.expected) {
+				t.Fatalf("expected %d medias to be found, got %d (%v)", len(tc.expected), len(found), found)
+			}
+
+			for id, distance := range tc.expected {
+				if got, exists := found[id]; !exists || got != distance {
+					t.Errorf("expected %q to be found at a distance of %d, got %v", id, distance, found)
+				}
+			}
+		})
+	}
+}
+
+func TestAddAgain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	index := NewIndex()
+
+	t.Run("same hash", func(t *testing.T) {
+		index.Add(ctx, "media-1", 0b0011)
+		index.Add(ctx, "media-1", 0b0011)
+		index.Remove(ctx, "media-1", 0b0011)
+
+		if found, _ := index.Search(ctx, 0b0011, 0); len(found) != 0 {
+			t.Errorf("expected the media to be indexed once, got %v", found)
+		}
+	})
+
+	t.Run("other hash", func(t *testing.T) {
+		index.Add(ctx, "media-2", 0b0000)
+		index.Add(ctx, "media-2", 0b1111)
+
+		if found, _ := index.Search(ctx, 0b0000, 0); len(found) != 0 {
+			t.Errorf("expected the previous hash not to be indexed anymore, got %v", found)
+		}
+
+		if found, _ := index.Search(ctx, 0b1111, 0); found["media-2"] != 0 || len(found) != 1 {
+			t.Errorf("expected the media to be indexed with its last hash, got %v", found)
+		}
+	})
+}
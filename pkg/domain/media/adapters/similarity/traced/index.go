@@ -0,0 +1,47 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewIndex decorates a similarity index so that each of its calls is traced
+// in a span.
+func NewIndex(index SimilarityIndex, tracer *tracing.Tracer) SimilarityIndex {
+	return &tracedIndex{index, tracer}
+}
+
+type tracedIndex struct {
+	index  SimilarityIndex
+	tracer *tracing.Tracer
+}
+
+// Add implements media.SimilarityIndex.
+func (i *tracedIndex) Add(ctx context.Context, mediaID string, hash PerceptualHash) (err error) {
+	ctx, span := i.tracer.StartClient(ctx, "SimilarityIndex.Add", "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return i.index.Add(ctx, mediaID, hash)
+}
+
+// Search implements media.SimilarityIndex.
+func (i *tracedIndex) Search(ctx context.Context, hash PerceptualHash, maxDistance int) (distances map[string]int, err error) {
+	ctx, span := i.tracer.StartClient(ctx, "SimilarityIndex.Search", "max_distance", maxDistance)
+	defer func() {
+		span.SetAttributes("medias.count", len(distances))
+		span.Finish(err)
+	}()
+
+	return i.index.Search(ctx, hash, maxDistance)
+}
+
+// Remove implements media.SimilarityIndex.
+func (i *tracedIndex) Remove(ctx context.Context, mediaID string, hash PerceptualHash) (err error) {
+	ctx, span := i.tracer.StartClient(ctx, "SimilarityIndex.Remove", "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return i.index.Remove(ctx, mediaID, hash)
+}
@@ -2,6 +2,7 @@ package fake
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	//lint:ignore ST1001
@@ -92,3 +93,19 @@ func (r *repository) Link(ctx context.Context, tagID, mediaID string) error {
 	r.tags[tagID] = append(r.tags[tagID], mediaID)
 	return nil
 }
+
+func (r *repository) Unlink(ctx context.Context, tagID, mediaID string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.medias[mediaID] = slices.DeleteFunc(r.medias[mediaID], func(tag string) bool { return tag == tagID })
+	if len(r.medias[mediaID]) == 0 {
+		delete(r.medias, mediaID)
+	}
+
+	if mediaIDs, exists := r.tags[tagID]; exists {
+		r.tags[tagID] = slices.DeleteFunc(mediaIDs, func(id string) bool { return id == mediaID })
+	}
+
+	return nil
+}
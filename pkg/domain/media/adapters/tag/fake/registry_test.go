@@ -90,3 +90,35 @@ func TestGetTagsForMedias(t *testing.T) {
 		t.Fatalf("expected 2 tags for the media-1, got %d", len(tags["media-1"]))
 	}
 }
+
+func TestUnlink(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+
+	repository.Link(ctx, "foo", "media-1")
+	repository.Link(ctx, "bar", "media-1")
+	repository.Link(ctx, "foo", "media-2")
+
+	if err := repository.Unlink(ctx, "foo", "media-1"); err != nil {
+		t.Fatalf("unexpected error while unlinking a tag : %s", err)
+	}
+
+	// unlinking twice is not an error
+	if err := repository.Unlink(ctx, "foo", "media-1"); err != nil {
+		t.Fatalf("unexpected error while unlinking a tag again : %s", err)
+	}
+
+	if medias, _ := repository.GetMediaIDsForTag(ctx, "foo"); len(medias) != 1 || medias[0] != "media-2" {
+		t.Errorf("expected only the media-2 to be left with the tag, got %v", medias)
+	}
+
+	if tags, _ := repository.GetTagsForMedias(ctx, "media-1"); len(tags["media-1"]) != 1 || tags["media-1"][0].Name != "bar" {
+		t.Errorf("expected only the bar tag to be left on the media-1, got %v", tags["media-1"])
+	}
+
+	if tags, _ := repository.GetAll(ctx); len(tags) != 2 {
+		t.Errorf("expected the tags to be kept, got %v", tags)
+	}
+}
@@ -0,0 +1,96 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewRegistry decorates a registry so that each tenant has its own tags, the
+// tags of the context's tenant being the only ones seen.
+//
+// The tags are stored prefixed by their tenant, as "tenant/name" ; a tenant
+// can't contain any slash, so that the prefix is never ambiguous.
+func NewRegistry(registry TagRegistry) TagRegistry {
+	return &tenantRegistry{registry}
+}
+
+type tenantRegistry struct {
+	registry TagRegistry
+}
+
+// GetAll implements media.TagRegistry.
+func (r *tenantRegistry) GetAll(ctx context.Context) (map[string]Tag, error) {
+	all, err := r.registry.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]Tag)
+	for _, tag := range all {
+		if name, ok := unscope(ctx, tag.Name); ok {
+			tags[name] = Tag{Name: name}
+		}
+	}
+
+	return tags, nil
+}
+
+// GetMediaIDsForTag implements media.TagRegistry.
+func (r *tenantRegistry) GetMediaIDsForTag(ctx context.Context, name string) ([]string, error) {
+	return r.registry.GetMediaIDsForTag(ctx, scope(ctx, name))
+}
+
+// GetTagsForMedias implements media.TagRegistry.
+func (r *tenantRegistry) GetTagsForMedias(ctx context.Context, mediasID ...string) (map[string][]Tag, error) {
+	all, err := r.registry.GetTagsForMedias(ctx, mediasID...)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]Tag, len(all))
+	for mediaID, mediaTags := range all {
+		tags[mediaID] = make([]Tag, 0, len(mediaTags))
+
+		for _, tag := range mediaTags {
+			if name, ok := unscope(ctx, tag.Name); ok {
+				tags[mediaID] = append(tags[mediaID], Tag{Name: name})
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// Create implements media.TagRegistry.
+func (r *tenantRegistry) Create(ctx context.Context, name string) (Tag, error) {
+	if _, err := r.registry.Create(ctx, scope(ctx, name)); err != nil {
+		return Tag{}, err
+	}
+
+	return Tag{Name: name}, nil
+}
+
+// Link implements media.TagRegistry.
+func (r *tenantRegistry) Link(ctx context.Context, tagID string, mediaID string) error {
+	return r.registry.Link(ctx, scope(ctx, tagID), mediaID)
+}
+
+// Unlink implements media.TagRegistry.
+func (r *tenantRegistry) Unlink(ctx context.Context, tagID string, mediaID string) error {
+	return r.registry.Unlink(ctx, scope(ctx, tagID), mediaID)
+}
+
+// scope returns the name of a tag as it is stored
+func scope(ctx context.Context, name string) string {
+	return auth.TenantFrom(ctx) + "/" + name
+}
+
+// unscope returns the name of a stored tag, if it belongs to the tenant
+func unscope(ctx context.Context, stored string) (string, bool) {
+	tenant, name, found := strings.Cut(stored, "/")
+	return name, found && tenant == auth.TenantFrom(ctx)
+}
@@ -0,0 +1,68 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/tag/fake"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	acme := auth.WithTenant(ctx, "acme")
+	globex := auth.WithTenant(ctx, "globex")
+
+	registry := NewRegistry(fake.NewFake())
+
+	tag, err := registry.Create(acme, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error while creating a tag : %s", err)
+	}
+
+	if tag.Name != "foo" {
+		t.Fatalf("expected the tag to be named %q, got %q", "foo", tag.Name)
+	}
+
+	registry.Link(acme, "foo", "media-1")
+	registry.Link(globex, "foo", "media-2")
+	registry.Link(ctx, "bar", "media-3")
+
+	tags, _ := registry.GetAll(acme)
+	if len(tags) != 1 || tags["foo"].Name != "foo" {
+		t.Errorf("expected only the tags of the tenant, got %+v", tags)
+	}
+
+	tags, _ = registry.GetAll(ctx)
+	if len(tags) != 1 || tags["bar"].Name != "bar" {
+		t.Errorf("expected only the tags of the default tenant, got %+v", tags)
+	}
+
+	ids, _ := registry.GetMediaIDsForTag(globex, "foo")
+	if len(ids) != 1 || ids[0] != "media-2" {
+		t.Errorf("expected only the medias of the tenant, got %v", ids)
+	}
+
+	mediaTags, _ := registry.GetTagsForMedias(acme, "media-1", "media-2")
+	if len(mediaTags["media-1"]) != 1 || mediaTags["media-1"][0].Name != "foo" {
+		t.Errorf("expected the tags of the media of the tenant, got %+v", mediaTags["media-1"])
+	}
+
+	if len(mediaTags["media-2"]) != 0 {
+		t.Errorf("expected the tags of another tenant not to be returned, got %+v", mediaTags["media-2"])
+	}
+
+	// the tag of another tenant is not the same tag
+	registry.Unlink(acme, "foo", "media-2")
+	if ids, _ := registry.GetMediaIDsForTag(globex, "foo"); len(ids) != 1 {
+		t.Errorf("expected the links of another tenant to be kept, got %v", ids)
+	}
+
+	registry.Unlink(acme, "foo", "media-1")
+	if ids, _ := registry.GetMediaIDsForTag(acme, "foo"); len(ids) != 0 {
+		t.Errorf("expected the media to be unlinked, got %v", ids)
+	}
+}
@@ -0,0 +1,68 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewRegistry decorates a tag registry so that each of its calls is traced in
+// a span.
+func NewRegistry(registry TagRegistry, tracer *tracing.Tracer) TagRegistry {
+	return &tracedRegistry{registry, tracer}
+}
+
+type tracedRegistry struct {
+	registry TagRegistry
+	tracer   *tracing.Tracer
+}
+
+// GetAll implements media.TagRegistry.
+func (r *tracedRegistry) GetAll(ctx context.Context) (tags map[string]Tag, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "TagRegistry.GetAll")
+	defer func() { span.Finish(err) }()
+
+	return r.registry.GetAll(ctx)
+}
+
+// GetMediaIDsForTag implements media.TagRegistry.
+func (r *tracedRegistry) GetMediaIDsForTag(ctx context.Context, name string) (mediaIDs []string, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "TagRegistry.GetMediaIDsForTag", "tag", name)
+	defer func() { span.Finish(err) }()
+
+	return r.registry.GetMediaIDsForTag(ctx, name)
+}
+
+// GetTagsForMedias implements media.TagRegistry.
+func (r *tracedRegistry) GetTagsForMedias(ctx context.Context, mediasID ...string) (tags map[string][]Tag, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "TagRegistry.GetTagsForMedias", "medias.count", len(mediasID))
+	defer func() { span.Finish(err) }()
+
+	return r.registry.GetTagsForMedias(ctx, mediasID...)
+}
+
+// Create implements media.TagRegistry.
+func (r *tracedRegistry) Create(ctx context.Context, name string) (tag Tag, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "TagRegistry.Create", "tag", name)
+	defer func() { span.Finish(err) }()
+
+	return r.registry.Create(ctx, name)
+}
+
+// Link implements media.TagRegistry.
+func (r *tracedRegistry) Link(ctx context.Context, tagID, mediaID string) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "TagRegistry.Link", "tag", tagID, "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return r.registry.Link(ctx, tagID, mediaID)
+}
+
+// Unlink implements media.TagRegistry.
+func (r *tracedRegistry) Unlink(ctx context.Context, tagID, mediaID string) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "TagRegistry.Unlink", "tag", tagID, "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return r.registry.Unlink(ctx, tagID, mediaID)
+}
@@ -0,0 +1,129 @@
+package fake
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/google/uuid"
+)
+
+// NewFake returns a repository keeping the uploads and their content in
+// memory
+func NewFake() UploadRepository {
+	return &repository{
+		uploads:  make(map[string]Upload),
+		contents: make(map[string][]byte),
+	}
+}
+
+type repository struct {
+	uploads  map[string]Upload
+	contents map[string][]byte
+	mtx      sync.RWMutex
+}
+
+func (r *repository) Create(ctx context.Context, upload Upload) (Upload, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	upload.ID = uuid.NewString()
+	upload.Offset = 0
+
+	r.uploads[upload.ID] = upload
+	r.contents[upload.ID] = make([]byte, 0, min(upload.Length, 1<<20))
+
+	return upload, nil
+}
+
+func (r *repository) Get(ctx context.Context, id string) (Upload, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	upload, exists := r.uploads[id]
+	if !exists {
+		return Upload{}, UploadNotFound(id)
+	}
+
+	return upload, nil
+}
+
+func (r *repository) GetAll(ctx context.Context) (map[string]Upload, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	result := make(map[string]Upload, len(r.uploads))
+	for id, upload := range r.uploads {
+		result[id] = upload
+	}
+
+	return result, nil
+}
+
+func (r *repository) Append(ctx context.Context, id string, offset int64, chunk []byte) (Upload, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	upload, exists := r.uploads[id]
+	if !exists {
+		return Upload{}, UploadNotFound(id)
+	}
+
+	if upload.Offset != offset {
+		return Upload{}, UploadOffsetMismatch(id, upload.Offset, offset)
+	}
+
+	if offset+int64(len(chunk)) > upload.Length {
+		return Upload{}, UploadTooLarge(id, upload.Length)
+	}
+
+	r.contents[id] = append(r.contents[id], chunk...)
+	upload.Offset += int64(len(chunk))
+	r.uploads[id] = upload
+
+	return upload, nil
+}
+
+func (r *repository) Content(ctx context.Context, id string) ([]byte, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	content, exists := r.contents[id]
+	if !exists {
+		return nil, UploadNotFound(id)
+	}
+
+	return slices.Clone(content), nil
+}
+
+func (r *repository) Complete(ctx context.Context, id string, mediaID string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	upload, exists := r.uploads[id]
+	if !exists {
+		return UploadNotFound(id)
+	}
+
+	upload.MediaID = mediaID
+	r.uploads[id] = upload
+	delete(r.contents, id)
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, exists := r.uploads[id]; !exists {
+		return UploadNotFound(id)
+	}
+
+	delete(r.uploads, id)
+	delete(r.contents, id)
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestAppend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+	upload, err := repository.Create(ctx, Upload{Length: 10, Name: "foo"})
+	if err != nil {
+		t.Fatalf("error while creating upload object : %s", err)
+	}
+
+	if upload.ID == "" || upload.Offset != 0 {
+		t.Fatalf("expected a new upload with an id at offset 0, got %+v", upload)
+	}
+
+	upload, err = repository.Append(ctx, upload.ID, 0, []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error while appending : %s", err)
+	}
+
+	if upload.Offset != 5 {
+		t.Fatalf("expected the upload to be at offset %d, got %d", 5, upload.Offset)
+	}
+
+	if _, err := repository.Append(ctx, upload.ID, 0, []byte("hello")); !errors.Is(err, ErrUploadOffsetMismatch) {
+		t.Fatalf("expected an offset mismatch error, got %s", err)
+	}
+
+	if _, err := repository.Append(ctx, upload.ID, 5, []byte("world!")); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("expected a too large error, got %s", err)
+	}
+
+	upload, _ = repository.Append(ctx, upload.ID, 5, []byte("world"))
+	if !upload.Complete() {
+		t.Fatalf("expected the upload to be complete, got %+v", upload)
+	}
+
+	content, _ := repository.Content(ctx, upload.ID)
+	if string(content) != "helloworld" {
+		t.Fatalf("expected the content to be %q, got %q", "helloworld", content)
+	}
+
+	if _, err := repository.Append(ctx, "oops", 0, nil); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected a not found error, got %s", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+	upload, _ := repository.Create(ctx, Upload{Length: 5})
+	repository.Append(ctx, upload.ID, 0, []byte("hello"))
+
+	if err := repository.Complete(ctx, upload.ID, "media-1"); err != nil {
+		t.Fatalf("unexpected error while completing : %s", err)
+	}
+
+	upload, _ = repository.Get(ctx, upload.ID)
+	if upload.MediaID != "media-1" {
+		t.Fatalf("expected the upload to be linked to %q, got %q", "media-1", upload.MediaID)
+	}
+
+	if _, err := repository.Content(ctx, upload.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected the content to be discarded, got %s", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	repository := NewFake()
+	upload, _ := repository.Create(ctx, Upload{Length: 5})
+	repository.Create(ctx, Upload{Length: 5})
+
+	if err := repository.Delete(ctx, upload.ID); err != nil {
+		t.Fatalf("unexpected error while deleting : %s", err)
+	}
+
+	if _, err := repository.Get(ctx, upload.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected the upload to be deleted, got %s", err)
+	}
+
+	all, _ := repository.GetAll(ctx)
+	if len(all) != 1 {
+		t.Fatalf("expected 1 upload to be left, got %d", len(all))
+	}
+
+	if err := repository.Delete(ctx, upload.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected a not found error, got %s", err)
+	}
+}
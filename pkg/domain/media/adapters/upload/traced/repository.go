@@ -0,0 +1,76 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewRepository decorates an upload repository so that each of its calls is
+// traced in a span.
+func NewRepository(repository UploadRepository, tracer *tracing.Tracer) UploadRepository {
+	return &tracedRepository{repository, tracer}
+}
+
+type tracedRepository struct {
+	repository UploadRepository
+	tracer     *tracing.Tracer
+}
+
+// Create implements media.UploadRepository.
+func (r *tracedRepository) Create(ctx context.Context, upload Upload) (created Upload, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.Create", "upload.length", upload.Length)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Create(ctx, upload)
+}
+
+// Get implements media.UploadRepository.
+func (r *tracedRepository) Get(ctx context.Context, id string) (upload Upload, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.Get", "upload.id", id)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Get(ctx, id)
+}
+
+// Append implements media.UploadRepository.
+func (r *tracedRepository) Append(ctx context.Context, id string, offset int64, chunk []byte) (upload Upload, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.Append", "upload.id", id, "upload.offset", offset, "chunk.size", len(chunk))
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Append(ctx, id, offset, chunk)
+}
+
+// Content implements media.UploadRepository.
+func (r *tracedRepository) Content(ctx context.Context, id string) (content []byte, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.Content", "upload.id", id)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Content(ctx, id)
+}
+
+// Complete implements media.UploadRepository.
+func (r *tracedRepository) Complete(ctx context.Context, id string, mediaID string) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.Complete", "upload.id", id, "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Complete(ctx, id, mediaID)
+}
+
+// Delete implements media.UploadRepository.
+func (r *tracedRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.Delete", "upload.id", id)
+	defer func() { span.Finish(err) }()
+
+	return r.repository.Delete(ctx, id)
+}
+
+// GetAll implements media.UploadRepository.
+func (r *tracedRepository) GetAll(ctx context.Context) (uploads map[string]Upload, err error) {
+	ctx, span := r.tracer.StartClient(ctx, "UploadRepository.GetAll")
+	defer func() { span.Finish(err) }()
+
+	return r.repository.GetAll(ctx)
+}
@@ -2,6 +2,7 @@ package fake
 
 import (
 	"context"
+	"sync"
 
 	//lint:ignore ST1001 it's the domain
 	. "github.com/Taluu/media-go/pkg/domain/media"
@@ -16,9 +17,13 @@ func NewUploader() MediaUploader {
 // Uploads a file in memory rather than disk
 type fakeUploader struct {
 	files map[string][]byte
+	mtx   sync.RWMutex
 }
 
 func (u *fakeUploader) GetContent(ctx context.Context, mediaID string) (fileContent []byte, err error) {
+	u.mtx.RLock()
+	defer u.mtx.RUnlock()
+
 	fileContent, exists := u.files[mediaID]
 	if !exists {
 		err = FileError(mediaID, FileNotFound(mediaID))
@@ -32,6 +37,17 @@ func (u *fakeUploader) Upload(ctx context.Context, id string, fileContent []byte
 	contentCopy := make([]byte, len(fileContent))
 	copy(contentCopy, fileContent)
 
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
 	u.files[id] = contentCopy
 	return nil
 }
+
+func (u *fakeUploader) Delete(ctx context.Context, id string) error {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	delete(u.files, id)
+	return nil
+}
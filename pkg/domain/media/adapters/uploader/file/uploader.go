@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
 )
 
 func NewUploader(dir string) media.MediaUploader {
@@ -31,5 +33,46 @@ func (u *fileUploader) GetContent(ctx context.Context, id string) (fileContent [
 }
 
 func (u *fileUploader) Upload(ctx context.Context, id string, fileContent []byte) error {
-	return os.WriteFile(fmt.Sprintf("%s/%s", u.directory, id), fileContent, 0644)
+	path := fmt.Sprintf("%s/%s", u.directory, id)
+
+	// the keys may be scoped in sub directories, such as the tenant ones
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, fileContent, 0644); err != nil {
+		return err
+	}
+
+	logging.LoggerFrom(ctx).Debug("file stored", "path", path, "size", len(fileContent))
+	return nil
+}
+
+func (u *fileUploader) Delete(ctx context.Context, id string) error {
+	path := fmt.Sprintf("%s/%s", u.directory, id)
+
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logging.LoggerFrom(ctx).Debug("file already deleted", "path", path)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	logging.LoggerFrom(ctx).Debug("file deleted", "path", path)
+	return nil
+}
+
+// ProbeHealth implements media.HealthProber, by writing a file in the
+// directory
+func (u *fileUploader) ProbeHealth(ctx context.Context) error {
+	file, err := os.CreateTemp(u.directory, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("directory %q not writable : %w", u.directory, err)
+	}
+
+	file.Close()
+	return os.Remove(file.Name())
 }
@@ -0,0 +1,37 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestProbeHealth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+
+	t.Run("writable directory", func(t *testing.T) {
+		prober := NewUploader(dir).(media.HealthProber)
+		if err := prober.ProbeHealth(ctx); err != nil {
+			t.Fatalf("expected the uploader to be healthy, got %s", err)
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("expected the probe not to leave any file, got %d", len(entries))
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		prober := NewUploader(filepath.Join(dir, "missing")).(media.HealthProber)
+		if err := prober.ProbeHealth(ctx); err == nil {
+			t.Fatalf("expected the uploader to be unhealthy")
+		}
+	})
+}
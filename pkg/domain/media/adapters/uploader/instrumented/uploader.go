@@ -0,0 +1,80 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/metrics"
+)
+
+// NewUploader decorates an uploader so that the duration of its calls is
+// observed in the storage_operation_duration_seconds histogram of the
+// registry, by operation and outcome.
+//
+// The presigned urls are observed the same way, if the uploader hands them
+// out.
+func NewUploader(uploader MediaUploader, registry *metrics.Registry) MediaUploader {
+	instrumented := &instrumentedUploader{
+		uploader:  uploader,
+		durations: registry.NewHistogram("storage_operation_duration_seconds", "Duration of the calls to the storage, in seconds", metrics.DefaultBuckets, "operation", "outcome"),
+	}
+
+	if presigner, ok := uploader.(MediaPresigner); ok {
+		return &instrumentedPresigner{instrumented, presigner}
+	}
+
+	return instrumented
+}
+
+type instrumentedUploader struct {
+	uploader  MediaUploader
+	durations *metrics.Histogram
+}
+
+// observe observes the duration of an operation started at the given time,
+// once its error is known
+func (u *instrumentedUploader) observe(operation string, start time.Time, err *error) {
+	outcome := "success"
+	if *err != nil {
+		outcome = "error"
+	}
+
+	u.durations.Observe(time.Since(start).Seconds(), operation, outcome)
+}
+
+// GetContent implements media.MediaUploader.
+func (u *instrumentedUploader) GetContent(ctx context.Context, mediaID string) (fileContent []byte, err error) {
+	defer u.observe("get", time.Now(), &err)
+	return u.uploader.GetContent(ctx, mediaID)
+}
+
+// Upload implements media.MediaUploader.
+func (u *instrumentedUploader) Upload(ctx context.Context, mediaID string, fileContent []byte) (err error) {
+	defer u.observe("upload", time.Now(), &err)
+	return u.uploader.Upload(ctx, mediaID, fileContent)
+}
+
+// Delete implements media.MediaUploader.
+func (u *instrumentedUploader) Delete(ctx context.Context, mediaID string) (err error) {
+	defer u.observe("delete", time.Now(), &err)
+	return u.uploader.Delete(ctx, mediaID)
+}
+
+type instrumentedPresigner struct {
+	*instrumentedUploader
+	presigner MediaPresigner
+}
+
+// PresignUpload implements media.MediaPresigner.
+func (u *instrumentedPresigner) PresignUpload(ctx context.Context, mediaID string, expiresAt time.Time) (url PresignedURL, err error) {
+	defer u.observe("presign_upload", time.Now(), &err)
+	return u.presigner.PresignUpload(ctx, mediaID, expiresAt)
+}
+
+// PresignDownload implements media.MediaPresigner.
+func (u *instrumentedPresigner) PresignDownload(ctx context.Context, mediaID string, expiresAt time.Time) (url PresignedURL, err error) {
+	defer u.observe("presign_download", time.Now(), &err)
+	return u.presigner.PresignDownload(ctx, mediaID, expiresAt)
+}
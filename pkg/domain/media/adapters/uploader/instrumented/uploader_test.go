@@ -0,0 +1,74 @@
+package instrumented
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/presigner/hmac"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
+	"github.com/Taluu/media-go/pkg/metrics"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+func TestUploader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	registry := metrics.NewRegistry()
+	uploader := NewUploader(fake.NewUploader(), registry)
+
+	if _, ok := uploader.(MediaPresigner); ok {
+		t.Fatalf("expected the uploader not to presign urls if the decorated one doesn't")
+	}
+
+	uploader.Upload(ctx, "media", []byte("content"))
+	uploader.GetContent(ctx, "media")
+	uploader.GetContent(ctx, "unknown")
+	uploader.Delete(ctx, "media")
+
+	var output bytes.Buffer
+	registry.WriteText(&output)
+
+	expectedLines := []string{
+		`storage_operation_duration_seconds_count{operation="upload",outcome="success"} 1`,
+		`storage_operation_duration_seconds_count{operation="get",outcome="success"} 1`,
+		`storage_operation_duration_seconds_count{operation="get",outcome="error"} 1`,
+		`storage_operation_duration_seconds_count{operation="delete",outcome="success"} 1`,
+	}
+
+	for _, line := range expectedLines {
+		if !strings.Contains(output.String(), line+"\n") {
+			t.Errorf("expected the metrics to hold %q, got\n%s", line, output.String())
+		}
+	}
+}
+
+func TestPresigner(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	registry := metrics.NewRegistry()
+	uploader := NewUploader(hmac.NewUploader(fake.NewUploader(), signature.NewSigner([]byte("key"))), registry)
+
+	presigner, ok := uploader.(MediaPresigner)
+	if !ok {
+		t.Fatalf("expected the uploader to presign urls as the decorated one does")
+	}
+
+	presigner.PresignUpload(ctx, "media", time.Now().Add(time.Minute))
+	presigner.PresignDownload(ctx, "media", time.Now().Add(time.Minute))
+
+	var output bytes.Buffer
+	registry.WriteText(&output)
+
+	for _, operation := range []string{"presign_upload", "presign_download"} {
+		line := `storage_operation_duration_seconds_count{operation="` + operation + `",outcome="success"} 1`
+		if !strings.Contains(output.String(), line+"\n") {
+			t.Errorf("expected the metrics to hold %q, got\n%s", line, output.String())
+		}
+	}
+}
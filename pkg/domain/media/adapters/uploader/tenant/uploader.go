@@ -0,0 +1,68 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewUploader decorates an uploader so that the content of the medias is
+// stored under a key prefixed by their tenant, as "tenant/id". The medias of
+// the default tenant are stored under their id, as without any tenant.
+//
+// The presigned urls are scoped the same way, if the uploader hands them out.
+func NewUploader(uploader MediaUploader) MediaUploader {
+	scoped := &tenantUploader{uploader}
+
+	if presigner, ok := uploader.(MediaPresigner); ok {
+		return &tenantPresigner{scoped, presigner}
+	}
+
+	return scoped
+}
+
+// Key is the key under which the content of a media of the tenant is stored
+func Key(tenant string, mediaID string) string {
+	if tenant == "" {
+		return mediaID
+	}
+
+	return tenant + "/" + mediaID
+}
+
+type tenantUploader struct {
+	uploader MediaUploader
+}
+
+// GetContent implements media.MediaUploader.
+func (u *tenantUploader) GetContent(ctx context.Context, mediaID string) ([]byte, error) {
+	return u.uploader.GetContent(ctx, Key(auth.TenantFrom(ctx), mediaID))
+}
+
+// Upload implements media.MediaUploader.
+func (u *tenantUploader) Upload(ctx context.Context, mediaID string, fileContent []byte) error {
+	return u.uploader.Upload(ctx, Key(auth.TenantFrom(ctx), mediaID), fileContent)
+}
+
+// Delete implements media.MediaUploader.
+func (u *tenantUploader) Delete(ctx context.Context, mediaID string) error {
+	return u.uploader.Delete(ctx, Key(auth.TenantFrom(ctx), mediaID))
+}
+
+type tenantPresigner struct {
+	*tenantUploader
+	presigner MediaPresigner
+}
+
+// PresignUpload implements media.MediaPresigner.
+func (u *tenantPresigner) PresignUpload(ctx context.Context, mediaID string, expiresAt time.Time) (PresignedURL, error) {
+	return u.presigner.PresignUpload(ctx, Key(auth.TenantFrom(ctx), mediaID), expiresAt)
+}
+
+// PresignDownload implements media.MediaPresigner.
+func (u *tenantPresigner) PresignDownload(ctx context.Context, mediaID string, expiresAt time.Time) (PresignedURL, error) {
+	return u.presigner.PresignDownload(ctx, Key(auth.TenantFrom(ctx), mediaID), expiresAt)
+}
@@ -0,0 +1,61 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/presigner/hmac"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+func TestUploader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	acme := auth.WithTenant(ctx, "acme")
+	globex := auth.WithTenant(ctx, "globex")
+
+	storage := fake.NewUploader()
+	uploader := NewUploader(storage)
+
+	if _, ok := uploader.(MediaPresigner); ok {
+		t.Fatalf("expected the uploader not to presign urls if the decorated one doesn't")
+	}
+
+	uploader.Upload(acme, "media", []byte("acme"))
+	uploader.Upload(ctx, "media", []byte("default"))
+
+	if content, _ := storage.GetContent(ctx, "acme/media"); string(content) != "acme" {
+		t.Errorf("expected the content to be stored under a key prefixed by the tenant, got %q", content)
+	}
+
+	if content, _ := storage.GetContent(ctx, "media"); string(content) != "default" {
+		t.Errorf("expected the content of the default tenant to be stored under its id, got %q", content)
+	}
+
+	if _, err := uploader.GetContent(globex, "media"); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected the content of another tenant not to be found, got %v", err)
+	}
+
+	t.Run("presigned urls", func(t *testing.T) {
+		presigner, ok := NewUploader(hmac.NewUploader(storage, signature.NewSigner([]byte("key")))).(MediaPresigner)
+		if !ok {
+			t.Fatalf("expected the uploader to presign urls")
+		}
+
+		url, err := presigner.PresignDownload(acme, "media", time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("unexpected error while presigning : %s", err)
+		}
+
+		if expected := hmac.Path("acme/media"); !strings.HasPrefix(url.URL, expected) {
+			t.Errorf("expected the url to target %q, got %q", expected, url.URL)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewUploader decorates an uploader so that each of its calls is traced in a
+// span.
+//
+// The presigned urls are traced the same way, if the uploader hands them out.
+func NewUploader(uploader MediaUploader, tracer *tracing.Tracer) MediaUploader {
+	traced := &tracedUploader{uploader, tracer}
+
+	if presigner, ok := uploader.(MediaPresigner); ok {
+		return &tracedPresigner{traced, presigner}
+	}
+
+	return traced
+}
+
+type tracedUploader struct {
+	uploader MediaUploader
+	tracer   *tracing.Tracer
+}
+
+// GetContent implements media.MediaUploader.
+func (u *tracedUploader) GetContent(ctx context.Context, mediaID string) (fileContent []byte, err error) {
+	ctx, span := u.tracer.StartClient(ctx, "MediaUploader.GetContent", "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return u.uploader.GetContent(ctx, mediaID)
+}
+
+// Upload implements media.MediaUploader.
+func (u *tracedUploader) Upload(ctx context.Context, mediaID string, fileContent []byte) (err error) {
+	ctx, span := u.tracer.StartClient(ctx, "MediaUploader.Upload", "media.id", mediaID, "media.size", len(fileContent))
+	defer func() { span.Finish(err) }()
+
+	return u.uploader.Upload(ctx, mediaID, fileContent)
+}
+
+// Delete implements media.MediaUploader.
+func (u *tracedUploader) Delete(ctx context.Context, mediaID string) (err error) {
+	ctx, span := u.tracer.StartClient(ctx, "MediaUploader.Delete", "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return u.uploader.Delete(ctx, mediaID)
+}
+
+type tracedPresigner struct {
+	*tracedUploader
+	presigner MediaPresigner
+}
+
+// PresignUpload implements media.MediaPresigner.
+func (u *tracedPresigner) PresignUpload(ctx context.Context, mediaID string, expiresAt time.Time) (url PresignedURL, err error) {
+	ctx, span := u.tracer.StartClient(ctx, "MediaPresigner.PresignUpload", "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return u.presigner.PresignUpload(ctx, mediaID, expiresAt)
+}
+
+// PresignDownload implements media.MediaPresigner.
+func (u *tracedPresigner) PresignDownload(ctx context.Context, mediaID string, expiresAt time.Time) (url PresignedURL, err error) {
+	ctx, span := u.tracer.StartClient(ctx, "MediaPresigner.PresignDownload", "media.id", mediaID)
+	defer func() { span.Finish(err) }()
+
+	return u.presigner.PresignDownload(ctx, mediaID, expiresAt)
+}
@@ -0,0 +1,90 @@
+package traced
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/presigner/hmac"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/fake"
+	"github.com/Taluu/media-go/pkg/signature"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+func TestUploader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter := tracing.NewInMemoryExporter()
+	uploader := NewUploader(fake.NewUploader(), tracing.NewTracer(exporter))
+
+	if _, ok := uploader.(MediaPresigner); ok {
+		t.Fatalf("expected the uploader not to presign urls if the decorated one doesn't")
+	}
+
+	ctx, parent := tracing.NewTracer(tracing.NoopExporter{}).Start(ctx, "parent")
+
+	uploader.Upload(ctx, "media", []byte("content"))
+	uploader.GetContent(ctx, "unknown")
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	expectedNames := []string{"MediaUploader.Upload", "MediaUploader.GetContent"}
+	for i, span := range spans {
+		if span.Name != expectedNames[i] {
+			t.Errorf("expected span %d to be named %q, got %q", i, expectedNames[i], span.Name)
+		}
+
+		if span.Kind != tracing.SpanKindClient {
+			t.Errorf("expected span %d to be a client span, got %d", i, span.Kind)
+		}
+
+		if span.Context.TraceID != parent.Context.TraceID || span.Parent != parent.Context.SpanID {
+			t.Errorf("expected span %d to be a child of the span of the context", i)
+		}
+	}
+
+	if spans[0].Attributes["media.id"] != "media" {
+		t.Errorf("expected the media id to be recorded, got %v", spans[0].Attributes["media.id"])
+	}
+
+	if spans[0].Err != nil {
+		t.Errorf("expected the upload to succeed, got %v", spans[0].Err)
+	}
+
+	if spans[1].Err == nil {
+		t.Errorf("expected the failure to fetch an unknown media to be recorded")
+	}
+}
+
+func TestPresigner(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter := tracing.NewInMemoryExporter()
+	uploader := NewUploader(hmac.NewUploader(fake.NewUploader(), signature.NewSigner([]byte("key"))), tracing.NewTracer(exporter))
+
+	presigner, ok := uploader.(MediaPresigner)
+	if !ok {
+		t.Fatalf("expected the uploader to presign urls as the decorated one does")
+	}
+
+	presigner.PresignUpload(ctx, "media", time.Now().Add(time.Minute))
+	presigner.PresignDownload(ctx, "media", time.Now().Add(time.Minute))
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	expectedNames := []string{"MediaPresigner.PresignUpload", "MediaPresigner.PresignDownload"}
+	for i, span := range spans {
+		if span.Name != expectedNames[i] {
+			t.Errorf("expected span %d to be named %q, got %q", i, expectedNames[i], span.Name)
+		}
+	}
+}
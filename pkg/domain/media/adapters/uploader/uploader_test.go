@@ -17,7 +17,7 @@ func TestFakeUploader(t *testing.T) {
 }
 
 func TestFileUploader(t *testing.T) {
-	test(t, file.NewUploader("/tmp"))
+	test(t, file.NewUploader(t.TempDir()))
 }
 
 // this test both the upload and the content fetching
@@ -42,6 +42,17 @@ func test(t *testing.T, uploader media.MediaUploader) {
 		}
 	})
 
+	t.Run("scoped key", func(t *testing.T) {
+		if err := uploader.Upload(ctx, "tenant/media", []byte("scoped")); err != nil {
+			t.Fatalf("could not upload a file under a scoped key : %s", err)
+		}
+
+		content, err := uploader.GetContent(ctx, "tenant/media")
+		if err != nil || string(content) != "scoped" {
+			t.Fatalf("did not get the scoped content : %q (%v)", content, err)
+		}
+	})
+
 	t.Run("file not found", func(t *testing.T) {
 		_, err := uploader.GetContent(ctx, "oops")
 		if !errors.Is(err, media.ErrFileNotFound) {
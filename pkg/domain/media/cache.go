@@ -0,0 +1,20 @@
+package media
+
+import "context"
+
+// PurgeEvent tells why the cached content of a media is stale
+type PurgeEvent string
+
+const (
+	PurgeReplaced PurgeEvent = "replaced"
+	PurgeDeleted  PurgeEvent = "deleted"
+	// PurgeRestricted medias are not public anymore, so that the shared
+	// caches must not serve them
+	PurgeRestricted PurgeEvent = "restricted"
+)
+
+// CachePurger tells the caches of the medias, such as a CDN, to forget the
+// content of a media.
+type CachePurger interface {
+	Purge(ctx context.Context, media Media, event PurgeEvent) error
+}
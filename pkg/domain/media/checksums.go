@@ -0,0 +1,41 @@
+package media
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Checksums of the content of a media, hex encoded
+type Checksums struct {
+	SHA256 string
+	// MD5 is only computed if enabled, for compatibility with storages such as
+	// S3
+	MD5 string
+}
+
+func ComputeChecksums(fileContent []byte, withMD5 bool) Checksums {
+	sha256Sum := sha256.Sum256(fileContent)
+	checksums := Checksums{SHA256: hex.EncodeToString(sha256Sum[:])}
+
+	if withMD5 {
+		md5Sum := md5.Sum(fileContent)
+		checksums.MD5 = hex.EncodeToString(md5Sum[:])
+	}
+
+	return checksums
+}
+
+// Verify checks that the given content matches the checksums. Only the
+// checksums that were computed are verified.
+func (c Checksums) Verify(fileContent []byte) bool {
+	computed := ComputeChecksums(fileContent, c.MD5 != "")
+	return computed.SHA256 == c.SHA256 && computed.MD5 == c.MD5
+}
+
+type IntegrityScrubber interface {
+	// Scrub verifies the content of every stored media against its checksums,
+	// and returns the ids of the corrupted ones.
+	Scrub(ctx context.Context) (corrupted []string, err error)
+}
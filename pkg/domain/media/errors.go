@@ -6,6 +6,25 @@ var (
 	ErrMediaNotFound = fmt.Errorf("media not found")
 	ErrFileNotFound  = fmt.Errorf("file not found")
 	ErrFile          = fmt.Errorf("file error")
+
+	ErrInvalidVisibility = fmt.Errorf("invalid visibility")
+
+	ErrUnsupportedMedia = fmt.Errorf("unsupported media")
+	ErrChecksumMismatch = fmt.Errorf("checksum mismatch")
+
+	ErrUploadNotFound       = fmt.Errorf("upload not found")
+	ErrUploadExpired        = fmt.Errorf("upload expired")
+	ErrUploadOffsetMismatch = fmt.Errorf("upload offset mismatch")
+	ErrUploadTooLarge       = fmt.Errorf("upload too large")
+
+	ErrPresignUnsupported = fmt.Errorf("presigned urls unsupported")
+
+	ErrQuotaExceeded   = fmt.Errorf("quota exceeded")
+	ErrLargerThanQuota = fmt.Errorf("larger than the quota")
+
+	ErrRemoteMedia          = fmt.Errorf("remote media error")
+	ErrRemoteMediaForbidden = fmt.Errorf("remote media forbidden")
+	ErrRemoteMediaTooLarge  = fmt.Errorf("remote media too large")
 )
 
 func FileNotFound(id string) error {
@@ -19,3 +38,51 @@ func FileError(id string, err error) error {
 func MediaNotFound(id string) error {
 	return fmt.Errorf("%w : %q", ErrMediaNotFound, id)
 }
+
+func InvalidVisibility(visibility Visibility) error {
+	return fmt.Errorf("%w : %q", ErrInvalidVisibility, visibility)
+}
+
+func UnsupportedMedia(mimetype string) error {
+	return fmt.Errorf("%w : %q", ErrUnsupportedMedia, mimetype)
+}
+
+func ChecksumMismatch(id string) error {
+	return fmt.Errorf("%w : media %q", ErrChecksumMismatch, id)
+}
+
+func UploadNotFound(id string) error {
+	return fmt.Errorf("%w : %q", ErrUploadNotFound, id)
+}
+
+func UploadExpired(id string) error {
+	return fmt.Errorf("%w : %q", ErrUploadExpired, id)
+}
+
+func UploadOffsetMismatch(id string, expected, got int64) error {
+	return fmt.Errorf("%w : upload %q is at offset %d, got %d", ErrUploadOffsetMismatch, id, expected, got)
+}
+
+func UploadTooLarge(id string, length int64) error {
+	return fmt.Errorf("%w : upload %q is limited to %d bytes", ErrUploadTooLarge, id, length)
+}
+
+func QuotaExceeded(account string) error {
+	return fmt.Errorf("%w : %q", ErrQuotaExceeded, account)
+}
+
+func LargerThanQuota(account string, limit int64) error {
+	return fmt.Errorf("%w : %q is limited to %d bytes", ErrLargerThanQuota, account, limit)
+}
+
+func RemoteMediaError(url string, err error) error {
+	return fmt.Errorf("%w : %q : %w", ErrRemoteMedia, url, err)
+}
+
+func RemoteMediaForbidden(address string) error {
+	return fmt.Errorf("%w : %q is not a public address", ErrRemoteMediaForbidden, address)
+}
+
+func RemoteMediaTooLarge(url string, maxSize int64) error {
+	return fmt.Errorf("%w : %q is larger than %d bytes", ErrRemoteMediaTooLarge, url, maxSize)
+}
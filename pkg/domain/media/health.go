@@ -0,0 +1,10 @@
+package media
+
+import "context"
+
+// HealthProber is an optional capability of the adapters, such as a
+// MediaRepository, a TagRegistry or a MediaUploader, telling whether what
+// they rely on (a database, a storage, ...) is able to serve them.
+type HealthProber interface {
+	ProbeHealth(ctx context.Context) error
+}
@@ -2,23 +2,116 @@ package media
 
 import (
 	"context"
+	"math/bits"
+	"time"
 )
 
 type Media struct {
-	ID       string
-	Name     string
-	Mimetype string
+	ID          string
+	Name        string
+	Mimetype    string
+	Properties  Properties
+	Placeholder Placeholder
+	// PerceptualHash is nil if the media is not an image
+	PerceptualHash *PerceptualHash
+	Checksums      Checksums
+	// Size of the stored content, in bytes
+	Size   int64
+	Access Access
+	// Tenant the media belongs to, the default one being empty
+	Tenant string
+	// Pending is true while the content of a media prepared for a presigned
+	// upload is not completed : it can be uploaded, but is not served yet.
+	Pending bool
+}
+
+// Properties are the technical properties of a media. Depending on the kind
+// of media, only some of them are relevant ; the others are left to their
+// zero value.
+type Properties struct {
+	Width    int
+	Height   int
+	Duration time.Duration
+	Bitrate  int // in bits per second
+	Pages    int
+}
+
+// Placeholder is a low quality preview of an image, that can be shown while
+// the full image is loading.
+type Placeholder struct {
+	BlurHash string
+	// Colors are the dominant colors, as hex strings (#rrggbb), the most
+	// dominant first.
+	Colors []string
+}
+
+// PerceptualHash is a fingerprint of an image, visually similar images having
+// close hashes.
+type PerceptualHash uint64
+
+// Distance is the Hamming distance between two hashes, that is the number of
+// bits that differ. The lower, the more similar.
+func (h PerceptualHash) Distance(other PerceptualHash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+type SimilarMedia struct {
+	Media
+	Distance int
 }
 
 type MediaRepository interface {
+	GetAll(ctx context.Context) (map[string]Media, error)
 	GetByIDs(ctx context.Context, mediaIDs ...string) (map[string]Media, error)
-	Create(ctx context.Context, name string, mimetype string) (Media, error)
+	// Create stores a new media, with a generated id, and returns it
+	Create(ctx context.Context, media Media) (Media, error)
+	// Update replaces a stored media. A ErrMediaNotFound is returned if it
+	// does not exist.
+	Update(ctx context.Context, media Media) error
+	// Delete removes a media. A ErrMediaNotFound is returned if it does not
+	// exist.
+	Delete(ctx context.Context, id string) error
 }
 
 type MediaService interface {
 	SearchByTag(ctx context.Context, tagName string) ([]Media, map[string][]Tag, error)
+	Get(ctx context.Context, id string) (Media, []Tag, error)
 	Create(ctx context.Context, name string, tags []string, fileContent []byte, mimetype string) (Media, []Tag, error)
-	View(ctx context.Context, id string) (fileContent []byte, mimetype string, err error)
+	View(ctx context.Context, id string) (media Media, fileContent []byte, err error)
+	// Similar returns the medias visually similar to the given one, the most
+	// similar first.
+	Similar(ctx context.Context, id string, maxDistance int) ([]SimilarMedia, map[string][]Tag, error)
+
+	// Prepare creates a media whose content, of the declared size, is then
+	// sent directly to the storage, through the returned url. A
+	// ErrPresignUnsupported is returned if the storage does not support it.
+	Prepare(ctx context.Context, name string, tags []string, mimetype string, size int64) (Media, []Tag, PresignedURL, error)
+	// Complete computes what depends on the content of a prepared media, once
+	// it was sent to the storage. If the media is rejected, the content is
+	// deleted, so that it can be sent again.
+	Complete(ctx context.Context, id string) (Media, []Tag, error)
+	// Presign returns an url to get the content of a media directly from the
+	// storage.
+	Presign(ctx context.Context, id string) (PresignedURL, error)
+	// Share changes who can see a media, which only its owner can do.
+	Share(ctx context.Context, id string, visibility Visibility, users []string, groups []string) (Media, error)
+
+	// Replace changes the content of a media, keeping its id and tags.
+	Replace(ctx context.Context, id string, fileContent []byte, mimetype string) (Media, []Tag, error)
+	// Delete removes a media and its content.
+	Delete(ctx context.Context, id string) error
+}
+
+type MediaSanitizer interface {
+	// Sanitize rewrites the content of a media before it is uploaded, e.g to
+	// strip some of its metadata. The given content must not be modified.
+	Sanitize(ctx context.Context, mediaID string, fileContent []byte) ([]byte, error)
+}
+
+type MediaProber interface {
+	// Probe extracts the technical properties of a media from its content.
+	// A ErrUnsupportedMedia is returned if the kind of media is not handled.
+	Probe(ctx context.Context, mimetype string, fileContent []byte) (Properties, error)
 }
 
 type MediaUploader interface {
@@ -28,4 +121,35 @@ type MediaUploader interface {
 	// GetContent gets the content for a media, and returns it.
 	// A ErrFile will be returned if something goes wrong.
 	GetContent(ctx context.Context, mediaID string) (fileContent []byte, err error)
+
+	// Delete removes the content of a media, if it exists.
+	Delete(ctx context.Context, mediaID string) error
+}
+
+type MediaFetcher interface {
+	// Fetch downloads a media hosted elsewhere, guessing its filename and
+	// mimetype. A ErrRemoteMediaForbidden is returned if the url points to a
+	// forbidden address, and a ErrRemoteMediaTooLarge if it is too large.
+	Fetch(ctx context.Context, url string) (fileContent []byte, filename string, mimetype string, err error)
+}
+
+type PlaceholderGenerator interface {
+	// Generate computes the placeholder of an image. A ErrUnsupportedMedia is
+	// returned if the media is not an image, or not a supported one.
+	Generate(ctx context.Context, mimetype string, fileContent []byte) (Placeholder, error)
+}
+
+type PerceptualHasher interface {
+	// Hash computes the perceptual hash of an image. A ErrUnsupportedMedia is
+	// returned if the media is not an image, or not a supported one.
+	Hash(ctx context.Context, mimetype string, fileContent []byte) (PerceptualHash, error)
+}
+
+type SimilarityIndex interface {
+	Add(ctx context.Context, mediaID string, hash PerceptualHash) error
+	// Search returns the ids of the medias whose hash is within the given
+	// distance of the given hash, along with their distance.
+	Search(ctx context.Context, hash PerceptualHash, maxDistance int) (map[string]int, error)
+	// Remove removes a media indexed with the given hash, if it was.
+	Remove(ctx context.Context, mediaID string, hash PerceptualHash) error
 }
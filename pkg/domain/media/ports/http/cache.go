@@ -0,0 +1,89 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// immutableMaxAge is how long the content behind a versioned link is cached,
+// as it never changes
+const immutableMaxAge = 365 * 24 * time.Hour
+
+// CachePolicy tells how long the clients and the shared caches, such as a
+// CDN, may keep the content of the medias.
+type CachePolicy struct {
+	// MaxAges are the durations the content of the medias may be kept, by
+	// family of mimetypes, such as "image" or "video"
+	MaxAges map[string]time.Duration
+	// DefaultMaxAge is the one of the families without their own, 0 to
+	// revalidate them on each request
+	DefaultMaxAge time.Duration
+	// Vary are the headers of the requests choosing which media is viewed,
+	// such as the header choosing the tenant
+	Vary []string
+	// PrivateOnly prevents the shared caches from keeping any media, such as
+	// when they can only be viewed by authenticated clients
+	PrivateOnly bool
+}
+
+// headers sets the caching headers of the content of a media. The content
+// behind a versioned link never changes, so it is cached for a year. The
+// medias that are not public are only cached by the clients, and the content
+// behind a signed link is not cached after the link expires.
+func (p CachePolicy) headers(header http.Header, media media.Media, versioned bool, expires time.Time) {
+	shared := media.Access.IsPublic() && !p.PrivateOnly
+
+	directives := make([]string, 0, 3)
+	if shared {
+		directives = append(directives, "public")
+	} else {
+		directives = append(directives, "private")
+	}
+
+	maxAge := p.maxAge(media.Mimetype)
+	if versioned {
+		maxAge = immutableMaxAge
+	}
+
+	if !expires.IsZero() {
+		maxAge = min(maxAge, time.Until(expires).Truncate(time.Second))
+	}
+
+	switch {
+	case maxAge <= 0:
+		directives = append(directives, "no-cache")
+	case versioned:
+		directives = append(directives, fmt.Sprintf("max-age=%d", int(maxAge.Seconds())), "immutable")
+	default:
+		directives = append(directives, fmt.Sprintf("max-age=%d", int(maxAge.Seconds())))
+	}
+
+	header.Set("Cache-Control", strings.Join(directives, ", "))
+
+	// the content of a media viewed with credentials depends on them
+	vary := p.Vary
+	if !shared {
+		vary = append(vary[:len(vary):len(vary)], "Authorization", "X-Api-Key")
+	}
+
+	if len(vary) > 0 {
+		header.Set("Vary", strings.Join(vary, ", "))
+	}
+
+	// lets the CDN supporting it purge every cached link to the media
+	header.Set("Surrogate-Key", media.ID)
+}
+
+// maxAge returns the max age of the family of the mimetype
+func (p CachePolicy) maxAge(mimetype string) time.Duration {
+	family, _, _ := strings.Cut(mimetype, "/")
+	if maxAge, ok := p.MaxAges[strings.ToLower(strings.TrimSpace(family))]; ok {
+		return maxAge
+	}
+
+	return p.DefaultMaxAge
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestCacheHeaders(t *testing.T) {
+	policy := CachePolicy{
+		MaxAges:       map[string]time.Duration{"image": 24 * time.Hour},
+		DefaultMaxAge: time.Hour,
+		Vary:          []string{"X-Tenant"},
+	}
+
+	public := media.Media{ID: "media-1", Mimetype: "text/plain", Access: media.Access{Visibility: media.VisibilityPublic}}
+	image := media.Media{ID: "media-2", Mimetype: "image/png"}
+	private := media.Media{ID: "media-3", Mimetype: "text/plain", Access: media.Access{Owner: "user-1", Visibility: media.VisibilityPrivate}}
+
+	testCases := []struct {
+		name         string
+		policy       CachePolicy
+		media        media.Media
+		versioned    bool
+		expires      time.Time
+		cacheControl string
+		vary         string
+	}{
+		{
+			name:         "public",
+			policy:       policy,
+			media:        public,
+			cacheControl: "public, max-age=3600",
+			vary:         "X-Tenant",
+		},
+		{
+			name:         "max age of the family",
+			policy:       policy,
+			media:        image,
+			cacheControl: "public, max-age=86400",
+			vary:         "X-Tenant",
+		},
+		{
+			name:         "private",
+			policy:       policy,
+			media:        private,
+			cacheControl: "private, max-age=3600",
+			vary:         "X-Tenant, Authorization, X-Api-Key",
+		},
+		{
+			name:         "private only",
+			policy:       CachePolicy{DefaultMaxAge: time.Hour, PrivateOnly: true},
+			media:        public,
+			cacheControl: "private, max-age=3600",
+			vary:         "Authorization, X-Api-Key",
+		},
+		{
+			name:         "versioned",
+			policy:       policy,
+			media:        public,
+			versioned:    true,
+			cacheControl: "public, max-age=31536000, immutable",
+			vary:         "X-Tenant",
+		},
+		{
+			name:         "no max age",
+			policy:       CachePolicy{},
+			media:        public,
+			cacheControl: "public, no-cache",
+		},
+		{
+			name:         "signed link",
+			policy:       policy,
+			media:        public,
+			versioned:    true,
+			expires:      time.Now().Add(10*time.Minute + 500*time.Millisecond),
+			cacheControl: "public, max-age=600, immutable",
+			vary:         "X-Tenant",
+		},
+		{
+			name:         "expired signed link",
+			policy:       policy,
+			media:        public,
+			expires:      time.Now().Add(-time.Minute),
+			cacheControl: "public, no-cache",
+			vary:         "X-Tenant",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			tc.policy.headers(header, tc.media, tc.versioned, tc.expires)
+
+			if header.Get("Cache-Control") != tc.cacheControl {
+				t.Errorf("expected the cache control %q, got %q", tc.cacheControl, header.Get("Cache-Control"))
+			}
+
+			if header.Get("Vary") != tc.vary {
+				t.Errorf("expected to vary on %q, got %q", tc.vary, header.Get("Vary"))
+			}
+
+			if header.Get("Surrogate-Key") != tc.media.ID {
+				t.Errorf("expected the surrogate key %q, got %q", tc.media.ID, header.Get("Surrogate-Key"))
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+var errDigestMismatch = fmt.Errorf("digest mismatch")
+
+// supported algorithms of the Content-Digest header (RFC 9530)
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"md5":     md5.New,
+}
+
+// parseContentDigest parses a Content-Digest header (e.g
+// `sha-256=:base64==:, md5=:base64==:`), and returns the expected digests by
+// algorithm. The unsupported algorithms are ignored.
+func parseContentDigest(header string) (map[string][]byte, error) {
+	digests := make(map[string][]byte)
+	if header == "" {
+		return digests, nil
+	}
+
+	for _, member := range strings.Split(header, ",") {
+		algorithm, value, found := strings.Cut(strings.TrimSpace(member), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid digest %q", member)
+		}
+
+		algorithm = strings.ToLower(algorithm)
+		if _, supported := digestAlgorithms[algorithm]; !supported {
+			continue
+		}
+
+		// drop the parameters, if any
+		value, _, _ = strings.Cut(value, ";")
+		if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+			return nil, fmt.Errorf("invalid digest %q", member)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest %q : %w", member, err)
+		}
+
+		digests[algorithm] = decoded
+	}
+
+	return digests, nil
+}
+
+// digester computes the digests of a content while it is read, to check
+// them against the expected ones
+type digester struct {
+	expected map[string][]byte
+	hashes   map[string]hash.Hash
+}
+
+func newDigester(expected map[string][]byte) *digester {
+	hashes := make(map[string]hash.Hash, len(expected))
+	for algorithm := range expected {
+		hashes[algorithm] = digestAlgorithms[algorithm]()
+	}
+
+	return &digester{expected, hashes}
+}
+
+// reader returns a reader computing the digests of what is read from r
+func (d *digester) reader(r io.Reader) io.Reader {
+	writers := make([]io.Writer, 0, len(d.hashes))
+	for _, hash := range d.hashes {
+		writers = append(writers, hash)
+	}
+
+	return io.TeeReader(r, io.MultiWriter(writers...))
+}
+
+// verify returns a errDigestMismatch if any of the digests doesn't match
+func (d *digester) verify() error {
+	for algorithm, digest := range d.expected {
+		if !bytes.Equal(d.hashes[algorithm].Sum(nil), digest) {
+			return errDigestMismatch
+		}
+	}
+
+	return nil
+}
+
+// digestBody wraps the body of the request so that its digests are computed
+// while it is read. As per RFC 9530, the Content-Digest header of a request is
+// the digest of its whole content (i.e the multipart body, the digest of a
+// file being sent on its part). The returned function reads what remains of
+// the body, and checks it against the header.
+func digestBody(r *http.Request) (func() error, error) {
+	expected, err := parseContentDigest(r.Header.Get("Content-Digest"))
+	if err != nil {
+		return nil, err
+	}
+
+	digester := newDigester(expected)
+	body := digester.reader(r.Body)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, r.Body}
+
+	return func() error {
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return err
+		}
+
+		return digester.verify()
+	}, nil
+}
+
+// digestHeaders returns the value of the Digest (RFC 3230) and Content-Digest
+// (RFC 9530) headers for the given checksums.
+func digestHeaders(checksums media.Checksums) (digest string, contentDigest string) {
+	var digests, contentDigests []string
+
+	for _, checksum := range []struct{ algorithm, value string }{
+		{"sha-256", checksums.SHA256},
+		{"md5", checksums.MD5},
+	} {
+		raw, err := hex.DecodeString(checksum.value)
+		if checksum.value == "" || err != nil {
+			continue
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(raw)
+		digests = append(digests, fmt.Sprintf("%s=%s", checksum.algorithm, encoded))
+		contentDigests = append(contentDigests, fmt.Sprintf("%s=:%s:", checksum.algorithm, encoded))
+	}
+
+	return strings.Join(digests, ","), strings.Join(contentDigests, ", ")
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestForwardedLinks(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+	links := NewLinkBuilder(nil, 0, WithTrustedProxies(proxies...))
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{
+			name:       "direct request",
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "http://example.com/uploads/upload-1",
+		},
+		{
+			name:       "untrusted peer",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string][]string{"Forwarded": {"proto=https;host=evil.example.net"}},
+			expected:   "http://example.com/uploads/upload-1",
+		},
+		{
+			name:       "forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {`for=192.0.2.60;proto=https;host="medias.example.org"`}},
+			expected:   "https://medias.example.org/uploads/upload-1",
+		},
+		{
+			name:       "forwarded by a chain of trusted proxies",
+			remoteAddr: "[2001:db8::2]:1234",
+			headers:    map[string][]string{"Forwarded": {"for=192.0.2.60;proto=https;host=medias.example.org", `for="[2001:db8::1]:4711";proto=http;host=internal`}},
+			expected:   "https://medias.example.org/uploads/upload-1",
+		},
+		{
+			name:       "forwarded element forged by the client",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=10.0.0.5;host=evil.example.net, for=192.0.2.60;proto=https;host=medias.example.org"}},
+			expected:   "https://medias.example.org/uploads/upload-1",
+		},
+		{
+			name:       "x-forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.0.2.60"}, "X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"medias.example.org"}},
+			expected:   "https://medias.example.org/uploads/upload-1",
+		},
+		{
+			name:       "x-forwarded by a chain of trusted proxies",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.0.2.60, 10.0.0.1"}, "X-Forwarded-Proto": {"https, http"}, "X-Forwarded-Host": {"medias.example.org", "internal"}},
+			expected:   "https://medias.example.org/uploads/upload-1",
+		},
+		{
+			name:       "x-forwarded values forged by the client",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.5, 192.0.2.60"}, "X-Forwarded-Host": {"evil.example.net, medias.example.org"}},
+			expected:   "http://medias.example.org/uploads/upload-1",
+		},
+		{
+			name:       "invalid values",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-Proto": {"javascript"}, "X-Forwarded-Host": {"evil.example.net/path"}},
+			expected:   "http://example.com/uploads/upload-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/uploads", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for name, values := range tc.headers {
+				r.Header[name] = values
+			}
+
+			if link := links.URL(r, "/uploads/upload-1"); link != tc.expected {
+				t.Errorf("expected the link %q, got %q", tc.expected, link)
+			}
+		})
+	}
+
+	t.Run("public url prevails", func(t *testing.T) {
+		public, _ := url.Parse("https://cdn.example.org")
+		links := NewLinkBuilder(nil, 0, WithPublicURL(public), WithTrustedProxies(proxies...))
+
+		r := httptest.NewRequest("POST", "/uploads", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-Host", "medias.example.org")
+
+		if link := links.URL(r, "/uploads/upload-1"); link != "https://cdn.example.org/uploads/upload-1" {
+			t.Errorf("expected the public url to be used, got %q", link)
+		}
+	})
+}
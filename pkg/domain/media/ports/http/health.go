@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+	// healthUnchecked is the status of the components unable to probe their
+	// health, which are assumed to be available
+	healthUnchecked = "unchecked"
+)
+
+// NewLivenessHTTPServer tells that the application is alive, as long as it
+// serves the requests. The components are not probed, as restarting the
+// application would not make them available again.
+func NewLivenessHTTPServer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonResponse(w, healthHttp{Status: healthOK}, http.StatusOK)
+	})
+}
+
+// NewReadinessHTTPServer tells whether the application is ready to serve the
+// requests, that is whether every component able to probe its health (the
+// media.HealthProber ones) is available, within the timeout.
+func NewReadinessHTTPServer(components map[string]any, timeout time.Duration) http.Handler {
+	return &readinessServer{components, timeout}
+}
+
+type readinessServer struct {
+	components map[string]any
+	timeout    time.Duration
+}
+
+func (s *readinessServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	response := healthHttp{Status: healthOK, Components: make(map[string]componentHealthHttp, len(s.components))}
+
+	type probe struct {
+		name string
+		err  error
+	}
+
+	// buffered, so that the probes which are not waited for anymore can
+	// still end
+	probes := make(chan probe, len(s.components))
+	pending := make(map[string]bool)
+
+	for name, component := range s.components {
+		prober, ok := component.(media.HealthProber)
+		if !ok {
+			response.Components[name] = componentHealthHttp{Status: healthUnchecked}
+			continue
+		}
+
+		pending[name] = true
+		go func() {
+			probes <- probe{name, prober.ProbeHealth(ctx)}
+		}()
+	}
+
+	report := func(name string, err error) {
+		health := componentHealthHttp{Status: healthOK}
+		if err != nil {
+			logging.LoggerFrom(r.Context()).Warn("component unavailable", "component", name, "error", err)
+			health = componentHealthHttp{Status: healthUnavailable, Error: err.Error()}
+			response.Status = healthUnavailable
+		}
+
+		response.Components[name] = health
+	}
+
+	// the probes ignoring the context are not waited for past the timeout
+	for len(pending) > 0 {
+		select {
+		case result := <-probes:
+			delete(pending, result.name)
+			report(result.name, result.err)
+		case <-ctx.Done():
+			for name := range pending {
+				report(name, ctx.Err())
+			}
+
+			clear(pending)
+		}
+	}
+
+	code := http.StatusOK
+	if response.Status != healthOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	jsonResponse(w, response, code)
+}
+
+type healthHttp struct {
+	Status     string                         `json:"status"`
+	Components map[string]componentHealthHttp `json:"components,omitempty"`
+}
+
+type componentHealthHttp struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
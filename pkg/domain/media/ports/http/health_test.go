@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeHealthProber struct {
+	err error
+}
+
+func (p fakeHealthProber) ProbeHealth(ctx context.Context) error {
+	return p.err
+}
+
+type slowHealthProber struct{}
+
+func (slowHealthProber) ProbeHealth(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// hungHealthProber ignores the context, and never ends until it is released
+type hungHealthProber struct {
+	release chan struct{}
+}
+
+func (p hungHealthProber) ProbeHealth(ctx context.Context) error {
+	<-p.release
+	return nil
+}
+
+func TestLivenessServer(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewLivenessHTTPServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected a 200, got %d", w.Code)
+	}
+
+	var response healthHttp
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if response.Status != healthOK {
+		t.Errorf("expected the status %q, got %q", healthOK, response.Status)
+	}
+}
+
+func TestReadinessServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hung := hungHealthProber{make(chan struct{})}
+	defer close(hung.release)
+
+	testCases := []struct {
+		name               string
+		components         map[string]any
+		expectedCode       int
+		expectedStatus     string
+		expectedComponents map[string]string
+	}{
+		{
+			name:               "no components",
+			components:         map[string]any{},
+			expectedCode:       http.StatusOK,
+			expectedStatus:     healthOK,
+			expectedComponents: map[string]string{},
+		},
+		{
+			name:               "available components",
+			components:         map[string]any{"storage": fakeHealthProber{}, "repository": struct{}{}},
+			expectedCode:       http.StatusOK,
+			expectedStatus:     healthOK,
+			expectedComponents: map[string]string{"storage": healthOK, "repository": healthUnchecked},
+		},
+		{
+			name:               "unavailable component",
+			components:         map[string]any{"storage": fakeHealthProber{errors.New("down")}, "repository": fakeHealthProber{}},
+			expectedCode:       http.StatusServiceUnavailable,
+			expectedStatus:     healthUnavailable,
+			expectedComponents: map[string]string{"storage": healthUnavailable, "repository": healthOK},
+		},
+		{
+			name:               "timed out component",
+			components:         map[string]any{"storage": slowHealthProber{}},
+			expectedCode:       http.StatusServiceUnavailable,
+			expectedStatus:     healthUnavailable,
+			expectedComponents: map[string]string{"storage": healthUnavailable},
+		},
+		{
+			name:               "hung component",
+			components:         map[string]any{"storage": hung, "repository": fakeHealthProber{}},
+			expectedCode:       http.StatusServiceUnavailable,
+			expectedStatus:     healthUnavailable,
+			expectedComponents: map[string]string{"storage": healthUnavailable, "repository": healthOK},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/readyz", nil)
+			NewReadinessHTTPServer(tc.components, 50*time.Millisecond).ServeHTTP(w, r)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, w.Code)
+			}
+
+			var response healthHttp
+			json.NewDecoder(w.Body).Decode(&response)
+
+			if response.Status != tc.expectedStatus {
+				t.Errorf("expected the status %q, got %q", tc.expectedStatus, response.Status)
+			}
+
+			if len(response.Components) != len(tc.expectedComponents) {
+				t.Fatalf("expected %d components, got %d", len(tc.expectedComponents), len(response.Components))
+			}
+
+			for name, expected := range tc.expectedComponents {
+				component := response.Components[name]
+				if component.Status != expected {
+					t.Errorf("expected the component %q to be %q, got %q", name, expected, component.Status)
+				}
+
+				if expected == healthUnavailable && component.Error == "" {
+					t.Errorf("expected the component %q to tell why it is unavailable", name)
+				}
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 
+	"github.com/Taluu/media-go/pkg/auth"
 	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
 )
 
 type httpError struct {
@@ -37,13 +40,64 @@ func toHttpCode(err error) (code int) {
 	switch {
 	case err == nil:
 		code = http.StatusOK
+	case errors.Is(err, auth.ErrForbidden):
+		code = http.StatusForbidden
 	case errors.Is(err, media.ErrFileNotFound):
 		fallthrough
 	case errors.Is(err, media.ErrMediaNotFound):
+		fallthrough
+	case errors.Is(err, media.ErrUploadNotFound):
 		code = http.StatusNotFound
+	case errors.Is(err, media.ErrUploadExpired):
+		code = http.StatusGone
+	case errors.Is(err, media.ErrUploadOffsetMismatch):
+		code = http.StatusConflict
+	case errors.Is(err, media.ErrUploadTooLarge):
+		fallthrough
+	case errors.Is(err, media.ErrLargerThanQuota):
+		fallthrough
+	case errors.Is(err, media.ErrRemoteMediaTooLarge):
+		code = http.StatusRequestEntityTooLarge
+	case errors.Is(err, media.ErrRemoteMediaForbidden):
+		fallthrough
+	case errors.Is(err, media.ErrInvalidVisibility):
+		code = http.StatusBadRequest
+	case errors.Is(err, media.ErrRemoteMedia):
+		code = http.StatusBadGateway
+	case errors.Is(err, media.ErrPresignUnsupported):
+		code = http.StatusNotImplemented
+	case errors.Is(err, media.ErrQuotaExceeded):
+		code = http.StatusInsufficientStorage
 	default:
 		code = http.StatusInternalServerError
 	}
 
 	return
 }
+
+// errorMessage returns the message of an error returned by a service, the
+// given one unless the principal is not allowed to do the operation, or the
+// quotas don't allow it
+func errorMessage(err error, message string) string {
+	switch {
+	case errors.Is(err, auth.ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, media.ErrQuotaExceeded):
+		return "quota exceeded"
+	case errors.Is(err, media.ErrLargerThanQuota):
+		return "media larger than the quota"
+	default:
+		return message
+	}
+}
+
+// logError logs why a service failed to handle a request, as an error only if
+// it is not the fault of the client
+func logError(r *http.Request, message string, err error, args ...any) {
+	level := slog.LevelWarn
+	if toHttpCode(err) >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	logging.LoggerFrom(r.Context()).Log(r.Context(), level, message, append(args, "error", err)...)
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/forwarded"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+// NewLinkBuilder returns a builder of the links exposed by the endpoints. If
+// a signer is given, the links to the viewer are signed, and expire after the
+// given duration.
+func NewLinkBuilder(signer *signature.Signer, expiration time.Duration, options ...LinkOption) *LinkBuilder {
+	b := &LinkBuilder{signer: signer, expiration: expiration}
+	for _, option := range options {
+		option(b)
+	}
+
+	return b
+}
+
+type LinkOption func(b *LinkBuilder)
+
+// WithPublicURL makes the links relative to the url this application is
+// publicly reachable at, such as https://medias.example.com/api, instead of
+// the host and the scheme of the requests.
+func WithPublicURL(base *url.URL) LinkOption {
+	return func(b *LinkBuilder) {
+		b.base = base
+	}
+}
+
+// WithTrustedProxies makes the links relative to the scheme and the host the
+// clients sent their requests to, as told by the Forwarded or X-Forwarded-*
+// headers of the given proxies. The public url, if any, prevails.
+func WithTrustedProxies(proxies ...netip.Prefix) LinkOption {
+	return func(b *LinkBuilder) {
+		b.proxies = proxies
+	}
+}
+
+type LinkBuilder struct {
+	signer     *signature.Signer
+	expiration time.Duration
+	base       *url.URL
+	proxies    []netip.Prefix
+}
+
+// versionParameter holds the version of the content of a media in the links
+// to the viewer, so that the links change along with the content, and can be
+// cached forever
+const versionParameter = "v"
+
+// Viewer returns the link to the content of a media. The links to the medias
+// of a tenant other than the default one hold their tenant, so that they can
+// be followed without any header.
+func (b *LinkBuilder) Viewer(r *http.Request, media media.Media) string {
+	path := "/viewer/" + media.ID
+
+	query := url.Values{}
+	if b.signer != nil {
+		query = b.signer.Sign(http.MethodGet, path, time.Now().Add(b.expiration))
+	}
+
+	if tenant := auth.TenantFrom(r.Context()); tenant != "" {
+		query.Set("tenant", tenant)
+	}
+
+	if version := mediaVersion(media); version != "" {
+		query.Set(versionParameter, version)
+	}
+
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return b.URL(r, path)
+}
+
+// mediaVersion identifies the content of a media, empty if it is not known
+// yet
+func mediaVersion(media media.Media) string {
+	if len(media.Checksums.SHA256) < 16 {
+		return ""
+	}
+
+	return media.Checksums.SHA256[:16]
+}
+
+// URL makes absolute a link to this application, such as /uploads/{id}. The
+// links which already are absolute, such as the ones to another storage, are
+// kept as is.
+func (b *LinkBuilder) URL(r *http.Request, link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.IsAbs() {
+		return link
+	}
+
+	base := b.baseURL(r)
+
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	u.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+	if u.RawPath != "" {
+		u.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + u.RawPath
+	}
+
+	return u.String()
+}
+
+// baseURL returns the public url if any, else the one the request was sent to
+func (b *LinkBuilder) baseURL(r *http.Request) *url.URL {
+	if b.base != nil {
+		return b.base
+	}
+
+	base := &url.URL{Scheme: "http", Host: r.Host}
+	if r.TLS != nil {
+		base.Scheme = "https"
+	}
+
+	scheme, host := forwarded.Origin(r, b.proxies)
+	if scheme != "" {
+		base.Scheme = scheme
+	}
+
+	if host != "" {
+		base.Host = host
+	}
+
+	return base
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+// links as built when the viewer is not signed
+var unsignedLinks = NewLinkBuilder(nil, 0)
+
+func TestSignedViewerLinks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	tagRegistry := adapters.NewFakeTagRegistry()
+	service := services.NewMediaService(mediaRepository, tagRegistry, adapters.NewFakeUploader())
+
+	previous := signature.NewSigner([]byte("previous"))
+	signer := signature.NewSigner([]byte("current"), []byte("previous"))
+	links := NewLinkBuilder(signer, time.Hour)
+
+	createServer := NewMediaCreateHTTPServer(service, links)
+	searchServer := NewMediaSearchHTTPPort(service, links)
+	viewerServer := NewMediaViewerHTTPServer(service, signer, CachePolicy{})
+
+	r := prepareRequest(ctx, `{"tags": ["tag-1"]}`, ".txt", true)
+	w := httptest.NewRecorder()
+	createServer.ServeHTTP(w, r)
+
+	var created mediaCreateResponse
+	json.NewDecoder(w.Result().Body).Decode(&created)
+
+	r = httptest.NewRequest("GET", "/medias/tag-1", nil).WithContext(ctx)
+	r.SetPathValue("tag", "tag-1")
+	w = httptest.NewRecorder()
+	searchServer.ServeHTTP(w, r)
+
+	var found mediasSearchHTTP
+	json.NewDecoder(w.Result().Body).Decode(&found)
+
+	if len(found.Medias) != 1 {
+		t.Fatalf("expected to find the created media, got %d medias", len(found.Medias))
+	}
+
+	view := func(link string) *http.Response {
+		u, _ := url.Parse(link)
+		r := httptest.NewRequest("GET", u.RequestURI(), nil).WithContext(ctx)
+		r.SetPathValue("id", created.ID)
+
+		w := httptest.NewRecorder()
+		viewerServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	testCases := []struct {
+		name         string
+		link         string
+		expectedCode int
+	}{
+		{name: "link from the creation", link: created.File, expectedCode: http.StatusOK},
+		{name: "link from the search", link: found.Medias[0].File, expectedCode: http.StatusOK},
+		{name: "link signed with the previous key", link: previous.SignURL("GET", "/viewer/"+created.ID, time.Now().Add(time.Minute)), expectedCode: http.StatusOK},
+		{name: "unsigned link", link: "/viewer/" + created.ID, expectedCode: http.StatusForbidden},
+		{name: "expired link", link: signer.SignURL("GET", "/viewer/"+created.ID, time.Now().Add(-time.Minute)), expectedCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if resp := view(tc.link); resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+
+	u, _ := url.Parse(created.File)
+	expires := u.Query().Get(signature.ExpiresParameter)
+	if expires == "" || u.Host != "example.com" {
+		t.Errorf("expected an absolute signed link, got %q", created.File)
+	}
+}
+
+func TestTenantViewerLinks(t *testing.T) {
+	signer := signature.NewSigner([]byte("current"))
+
+	testCases := []struct {
+		name     string
+		links    *LinkBuilder
+		tenant   string
+		expected string
+	}{
+		{name: "default tenant", links: unsignedLinks, expected: "http://example.com/viewer/media-1"},
+		{name: "unsigned link", links: unsignedLinks, tenant: "acme", expected: "http://example.com/viewer/media-1?tenant=acme"},
+		{name: "signed link", links: NewLinkBuilder(signer, time.Hour), tenant: "acme"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/medias/tag-1", nil)
+			r = r.WithContext(auth.WithTenant(r.Context(), tc.tenant))
+
+			link := tc.links.Viewer(r, media.Media{ID: "media-1"})
+			if tc.expected != "" && link != tc.expected {
+				t.Fatalf("expected the link %q, got %q", tc.expected, link)
+			}
+
+			u, _ := url.Parse(link)
+			if err := signer.Verify("GET", u.Path, u.Query(), time.Now()); tc.links != unsignedLinks && err != nil {
+				t.Errorf("expected the signature to still be valid, got %s", err)
+			}
+
+			if u.Query().Get("tenant") != tc.tenant {
+				t.Errorf("expected the link to hold the tenant %q, got %q", tc.tenant, link)
+			}
+		})
+	}
+}
+
+func TestLinkURL(t *testing.T) {
+	public, _ := url.Parse("https://medias.example.org/api/")
+
+	testCases := []struct {
+		name     string
+		links    *LinkBuilder
+		tls      bool
+		link     string
+		expected string
+	}{
+		{name: "plain connection", links: unsignedLinks, link: "/uploads/upload-1", expected: "http://example.com/uploads/upload-1"},
+		{name: "tls connection", links: unsignedLinks, tls: true, link: "/uploads/upload-1", expected: "https://example.com/uploads/upload-1"},
+		{name: "public url", links: NewLinkBuilder(nil, 0, WithPublicURL(public)), link: "/uploads/upload-1", expected: "https://medias.example.org/api/uploads/upload-1"},
+		{name: "public url, tls connection", links: NewLinkBuilder(nil, 0, WithPublicURL(public)), tls: true, link: "/storage/media-1?expires=1", expected: "https://medias.example.org/api/storage/media-1?expires=1"},
+		{name: "absolute link", links: NewLinkBuilder(nil, 0, WithPublicURL(public)), link: "https://bucket.example.net/media-1", expected: "https://bucket.example.net/media-1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := "http://example.com/medias"
+			if tc.tls {
+				target = "https://example.com/medias"
+			}
+
+			r := httptest.NewRequest("POST", target, nil)
+			if link := tc.links.URL(r, tc.link); link != tc.expected {
+				t.Errorf("expected the link %q, got %q", tc.expected, link)
+			}
+		})
+	}
+}
+
+func TestSignedViewerGrant(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+
+	service := services.NewMediaService(
+		adapters.NewFakeMediaRepository(),
+		adapters.NewFakeTagRegistry(),
+		adapters.NewFakeUploader(),
+		services.WithDefaultVisibility(media.VisibilityPrivate),
+	)
+
+	signer := signature.NewSigner([]byte("current"))
+	viewerServer := NewMediaViewerHTTPServer(service, signer, CachePolicy{})
+
+	private, _, _ := service.Create(owner, "private", nil, []byte("private content"), "text/plain")
+	other, _, _ := service.Create(owner, "other", nil, []byte("other content"), "text/plain")
+
+	view := func(id string, link string) *http.Response {
+		r := httptest.NewRequest("GET", link, nil).WithContext(ctx)
+		r.SetPathValue("id", id)
+
+		w := httptest.NewRecorder()
+		viewerServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	// anonymous requests, the signature being the only credential
+	if resp := view(private.ID, signer.SignURL("GET", "/viewer/"+private.ID, time.Now().Add(time.Minute))); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a signed link to grant the view of a private media, got %d", resp.StatusCode)
+	}
+
+	if resp := view(other.ID, "/viewer/"+other.ID+"?"+signer.Sign("GET", "/viewer/"+private.ID, time.Now().Add(time.Minute)).Encode()); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the link of a media not to grant the view of another one, got %d", resp.StatusCode)
+	}
+
+	if _, _, err := service.Get(ctx, private.ID); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected the private media to stay hidden without a signed link, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+	"golang.org/x/sync/errgroup"
+)
+
+// maximum memory used to parse the multipart body, the rest of the files
+// being stored on disk
+const batchMaxMemory = 32 << 20
+
+func NewMediaBatchHTTPServer(service media.MediaService, links *LinkBuilder, workers int) http.Handler {
+	return &mediaBatchServer{service, links, workers}
+}
+
+type mediaBatchServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+	workers int
+}
+
+// ServeHTTP creates a media for each of the media parts of the request. The
+// data parts are matched with the media parts in their order of appearance,
+// and are optional for the last medias.
+func (m *mediaBatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	verifyBody, err := digestBody(r)
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the digest", "error", err)
+		jsonError(w, "invalid digest", http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseMultipartForm(batchMaxMemory); err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the multipart body", "error", err)
+		jsonError(w, "invalid multipart body", http.StatusBadRequest)
+		return
+	}
+
+	defer r.MultipartForm.RemoveAll()
+
+	if err := verifyBody(); err != nil {
+		logging.LoggerFrom(r.Context()).Warn("the digest of the body does not match", "error", err)
+		jsonError(w, errDigestMismatch.Error(), http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["media"]
+	data := r.MultipartForm.Value["data"]
+
+	if len(files) == 0 {
+		jsonError(w, "file not found", http.StatusBadRequest)
+		return
+	}
+
+	if len(data) > len(files) {
+		jsonError(w, "more data than files", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]mediaBatchItemHttp, len(files))
+
+	group := new(errgroup.Group)
+	group.SetLimit(max(m.workers, 1))
+
+	for k := range files {
+		var itemData string
+		if k < len(data) {
+			itemData = data[k]
+		}
+
+		group.Go(func() error {
+			// net/http only recovers the panics of the goroutine of the
+			// handler : one of an item would take the whole server down
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					logging.LoggerFrom(r.Context()).Error("panic while creating a media", "index", k, "panic", recovered, "stack", string(debug.Stack()))
+					results[k] = mediaBatchItemHttp{Index: k, Status: http.StatusInternalServerError, Error: "media creation failed"}
+				}
+			}()
+
+			results[k] = m.create(r, k, itemData)
+			return nil
+		})
+	}
+
+	group.Wait()
+
+	jsonResponse(w, mediaBatchResponse{Results: results}, http.StatusMultiStatus)
+}
+
+// create creates one of the medias of the batch ; the failures are reported
+// within the item, so that the rest of the batch can still be processed.
+func (m *mediaBatchServer) create(r *http.Request, index int, data string) mediaBatchItemHttp {
+	item := mediaBatchItemHttp{Index: index}
+	fail := func(error string, code int) mediaBatchItemHttp {
+		item.Status = code
+		item.Error = error
+		return item
+	}
+
+	var request mediaCreateRequest
+	if err := json.Unmarshal([]byte(data), &request); data != "" && err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not deserialize data into proper json", "index", index, "error", err)
+		return fail("json error", http.StatusBadRequest)
+	}
+
+	fileContent, fileName, mimetype, err := readFile(r.Context(), r.MultipartForm.File["media"][index])
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("problem while fetching file upload", "index", index, "error", err)
+		return fail(err.Error(), http.StatusBadRequest)
+	}
+
+	if request.Name == "" {
+		request.Name = fileName
+	}
+
+	media, tags, err := m.service.Create(r.Context(), request.Name, request.Tags, fileContent, mimetype)
+	if err != nil {
+		logError(r, "could not create media", err, "index", index)
+		return fail(errorMessage(err, "media creation failed"), toHttpCode(err))
+	}
+
+	response := toMediaCreateResponse(r, m.links, media, tags)
+
+	item.Status = http.StatusCreated
+	item.Media = &response
+	return item
+}
+
+type mediaBatchResponse struct {
+	Results []mediaBatchItemHttp `json:"results"`
+}
+
+type mediaBatchItemHttp struct {
+	Index  int                  `json:"index"`
+	Status int                  `json:"status"`
+	Media  *mediaCreateResponse `json:"media,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
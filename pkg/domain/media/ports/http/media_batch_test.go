@@ -0,0 +1,234 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+)
+
+type batchPart struct {
+	filename string
+	data     string
+	digest   string
+}
+
+func prepareBatchRequest(ctx context.Context, parts []batchPart) *http.Request {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+
+	for _, part := range parts {
+		if part.data != "" {
+			w.WriteField("data", part.data)
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="media"; filename=%q`, part.filename))
+		header.Set("Content-Type", "application/octet-stream")
+		if part.digest != "" {
+			header.Set("Content-Digest", part.digest)
+		}
+
+		p, _ := w.CreatePart(header)
+		p.Write([]byte("sample fixture test"))
+	}
+
+	w.Close()
+
+	request := httptest.NewRequest("POST", "/medias/batch", body).WithContext(ctx)
+	request.Header.Add("Content-Type", w.FormDataContentType())
+
+	return request
+}
+
+func TestMediaBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	service := services.NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	server := NewMediaBatchHTTPServer(service, unsignedLinks, 2)
+
+	t.Run("without any file", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, prepareBatchRequest(ctx, nil))
+
+		if resp := w.Result(); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected a 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("partial failures", func(t *testing.T) {
+		r := prepareBatchRequest(ctx, []batchPart{
+			{filename: "first.png", data: `{"name": "first", "tags": ["foo"]}`},
+			{filename: "second.png", data: `{not valid}`},
+			{filename: "third.png", data: `{}`, digest: "sha-256=:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=:"},
+			{filename: "fourth.png"},
+		})
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		resp := w.Result()
+
+		if resp.StatusCode != http.StatusMultiStatus {
+			t.Fatalf("expected a 207, got %d", resp.StatusCode)
+		}
+
+		var gotResponse mediaBatchResponse
+		json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+		if len(gotResponse.Results) != 4 {
+			t.Fatalf("expected 4 results, got %d", len(gotResponse.Results))
+		}
+
+		expected := []struct {
+			status int
+			name   string
+			error  string
+		}{
+			{status: http.StatusCreated, name: "first"},
+			{status: http.StatusBadRequest, error: "json error"},
+			{status: http.StatusBadRequest, error: "digest mismatch"},
+			{status: http.StatusCreated, name: "fourth.png"},
+		}
+
+		for k, item := range gotResponse.Results {
+			if item.Index != k || item.Status != expected[k].status || item.Error != expected[k].error {
+				t.Errorf("expected item %d to be %+v, got %+v", k, expected[k], item)
+				continue
+			}
+
+			if item.Status != http.StatusCreated {
+				continue
+			}
+
+			if item.Media == nil || item.Media.Name != expected[k].name {
+				t.Errorf("expected item %d to be a media named %q, got %+v", k, expected[k].name, item.Media)
+				continue
+			}
+
+			medias, _ := mediaRepository.GetByIDs(ctx, item.Media.ID)
+			if medias[item.Media.ID].Mimetype != "image/png" {
+				t.Errorf("expected the media %d to be created as an image/png, got %+v", k, medias[item.Media.ID])
+			}
+		}
+	})
+
+	t.Run("more data than files", func(t *testing.T) {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		mw.WriteField("data", "{}")
+		mw.WriteField("data", "{}")
+		p, _ := mw.CreateFormFile("media", "fixture.png")
+		p.Write([]byte("sample fixture test"))
+		mw.Close()
+
+		r := httptest.NewRequest("POST", "/medias/batch", body).WithContext(ctx)
+		r.Header.Add("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+
+		if resp := w.Result(); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected a 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+// slowMediaService keeps track of the number of concurrent creations
+type slowMediaService struct {
+	media.MediaService
+	current, peak atomic.Int32
+}
+
+func (s *slowMediaService) Create(ctx context.Context, name string, tags []string, fileContent []byte, mimetype string) (media.Media, []media.Tag, error) {
+	current := s.current.Add(1)
+	defer s.current.Add(-1)
+
+	for peak := s.peak.Load(); current > peak && !s.peak.CompareAndSwap(peak, current); peak = s.peak.Load() {
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	return s.MediaService.Create(ctx, name, tags, fileContent, mimetype)
+}
+
+func TestMediaBatchWorkers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	service := &slowMediaService{
+		MediaService: services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader()),
+	}
+
+	server := NewMediaBatchHTTPServer(service, unsignedLinks, 3)
+
+	parts := make([]batchPart, 10)
+	for k := range parts {
+		parts[k].filename = fmt.Sprintf("fixture-%d.png", k)
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, prepareBatchRequest(ctx, parts))
+
+	if resp := w.Result(); resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("expected a 207, got %d", resp.StatusCode)
+	}
+
+	if peak := service.peak.Load(); peak > 3 || peak < 2 {
+		t.Errorf("expected at most 3 concurrent creations, got %d", peak)
+	}
+}
+
+// panickingMediaService panics when creating the medias named panic.png
+type panickingMediaService struct {
+	media.MediaService
+}
+
+func (s panickingMediaService) Create(ctx context.Context, name string, tags []string, fileContent []byte, mimetype string) (media.Media, []media.Tag, error) {
+	if name == "panic.png" {
+		panic("corrupted media")
+	}
+
+	return s.MediaService.Create(ctx, name, tags, fileContent, mimetype)
+}
+
+func TestMediaBatchPanic(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	service := panickingMediaService{services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())}
+	server := NewMediaBatchHTTPServer(service, unsignedLinks, 2)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, prepareBatchRequest(ctx, []batchPart{{filename: "panic.png"}, {filename: "fixture.png"}}))
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("expected a 207, got %d", resp.StatusCode)
+	}
+
+	var response mediaBatchResponse
+	json.NewDecoder(resp.Body).Decode(&response)
+
+	if len(response.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(response.Results))
+	}
+
+	if item := response.Results[0]; item.Index != 0 || item.Status != http.StatusInternalServerError || item.Error != "media creation failed" {
+		t.Errorf("expected the panicking item to fail with a 500, got %+v", item)
+	}
+
+	if item := response.Results[1]; item.Status != http.StatusCreated {
+		t.Errorf("expected the other item to be created, got %+v", item)
+	}
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewMediaCompleteHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaCompleteServer{service, links}
+}
+
+type mediaCompleteServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+}
+
+func (m *mediaCompleteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	media, tags, err := m.service.Complete(ctx, r.PathValue("id"))
+	if err != nil {
+		logError(r, "could not complete media", err)
+		jsonError(w, errorMessage(err, "media not found"), toHttpCode(err))
+		return
+	}
+
+	jsonResponse(w, toMediaCreateResponse(r, m.links, media, tags), http.StatusOK)
+}
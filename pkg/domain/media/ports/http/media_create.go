@@ -1,43 +1,59 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
 
 	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
 )
 
-func NewMediaCreateHTTPServer(service media.MediaService) http.Handler {
-	return &mediaCreateServer{service}
+func NewMediaCreateHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaCreateServer{service, links}
 }
 
 type mediaCreateServer struct {
 	service media.MediaService
+	links   *LinkBuilder
 }
 
 func (m *mediaCreateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	verifyBody, err := digestBody(r)
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the digest", "error", err)
+		jsonError(w, "invalid digest", http.StatusBadRequest)
+		return
+	}
+
 	var request mediaCreateRequest
 	data := r.FormValue("data")
 	if err := json.Unmarshal([]byte(r.FormValue("data")), &request); data != "" && err != nil && err != io.EOF {
-		log.Printf("could not deserialize body into proper json : %s", err)
+		logging.LoggerFrom(r.Context()).Warn("could not deserialize body into proper json", "error", err)
 		jsonError(w, "json error", http.StatusBadRequest)
 		return
 	}
 
 	fileContent, fileName, mimetype, err := getFile(r)
 	if err != nil {
-		log.Printf("Problem while fetching file upload : %s", err)
+		logging.LoggerFrom(r.Context()).Warn("problem while fetching file upload", "error", err)
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if err := verifyBody(); err != nil {
+		logging.LoggerFrom(r.Context()).Warn("the digest of the body does not match", "error", err)
+		jsonError(w, errDigestMismatch.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// use the flename as a name if not provided
 	if request.Name == "" {
 		request.Name = fileName
@@ -45,39 +61,75 @@ func (m *mediaCreateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	media, tags, err := m.service.Create(ctx, request.Name, request.Tags, fileContent, mimetype)
 	if err != nil {
-		log.Printf("could not create media : %s", err)
-		jsonError(w, "media creation failed", http.StatusInternalServerError)
+		logError(r, "could not create media", err)
+		jsonError(w, errorMessage(err, "media creation failed"), toHttpCode(err))
 		return
 	}
 
+	jsonResponse(w, toMediaCreateResponse(r, m.links, media, tags), http.StatusCreated)
+}
+
+func toMediaCreateResponse(r *http.Request, links *LinkBuilder, media media.Media, tags []media.Tag) mediaCreateResponse {
 	tagsListHttp := make([]string, len(tags))
 	for k, tag := range tags {
 		tagsListHttp[k] = tag.Name
 	}
 
-	mediaResponse := mediaCreateResponse{
-		ID:   media.ID,
-		Name: media.Name,
-		File: fmt.Sprintf("http://%s/viewer/%s", r.Host, media.ID),
-		Tags: tagsListHttp,
+	return mediaCreateResponse{
+		ID:          media.ID,
+		Name:        media.Name,
+		File:        links.Viewer(r, media),
+		Tags:        tagsListHttp,
+		Placeholder: toPlaceholderHttp(media.Placeholder),
 	}
-	jsonResponse(w, mediaResponse, http.StatusCreated)
 }
 
+// getFile reads the file uploaded in the media field
 func getFile(r *http.Request) (content []byte, filename string, mimetype string, err error) {
 	file, header, err := r.FormFile("media")
 	if err != nil {
-		log.Printf("could not get file : %s", err)
+		logging.LoggerFrom(r.Context()).Warn("could not get file", "error", err)
 		err = fmt.Errorf("file not found")
 		return
 	}
 
+	file.Close()
+
+	return readFile(r.Context(), header)
+}
+
+// readFile reads an uploaded file. If the file part has a Content-Digest
+// header, the digests are computed while reading it, and checked against the
+// expected ones (the Content-Digest header of the request being the digest of
+// the whole multipart body).
+func readFile(ctx context.Context, header *multipart.FileHeader) (content []byte, filename string, mimetype string, err error) {
+	file, err := header.Open()
+	if err != nil {
+		logging.LoggerFrom(ctx).Warn("could not open file", "error", err)
+		err = fmt.Errorf("file not readable")
+		return
+	}
+
 	defer file.Close()
 
-	content, err = io.ReadAll(file)
+	expected, err := parseContentDigest(header.Header.Get("Content-Digest"))
+	if err != nil {
+		logging.LoggerFrom(ctx).Warn("could not parse the digest", "error", err)
+		err = fmt.Errorf("invalid digest")
+		return
+	}
+
+	digester := newDigester(expected)
+	content, err = io.ReadAll(digester.reader(file))
 	if err != nil {
-		log.Printf("could not read file : %s", err)
+		logging.LoggerFrom(ctx).Warn("could not read file", "error", err)
 		err = fmt.Errorf("file not readable")
+		return
+	}
+
+	if err = digester.verify(); err != nil {
+		logging.LoggerFrom(ctx).Warn("the digest of the file does not match")
+		return
 	}
 
 	filename = header.Filename
@@ -97,8 +149,9 @@ type mediaCreateRequest struct {
 }
 
 type mediaCreateResponse struct {
-	ID   string   `json:"id"`
-	Name string   `json:"name"`
-	File string   `json:"file"`
-	Tags []string `json:"tags"`
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	File        string                `json:"file"`
+	Tags        []string              `json:"tags"`
+	Placeholder *mediaPlaceholderHttp `json:"placeholder,omitempty"`
 }
@@ -3,11 +3,15 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,7 +27,7 @@ func TestMediaCreate(t *testing.T) {
 	tagRegistry := adapters.NewFakeTagRegistry()
 	fakeUploader := adapters.NewFakeUploader()
 	service := services.NewMediaService(mediaRepository, tagRegistry, fakeUploader)
-	server := NewMediaCreateHTTPServer(service)
+	server := NewMediaCreateHTTPServer(service, unsignedLinks)
 
 	testCases := []struct {
 		name     string
@@ -111,8 +115,9 @@ func TestMediaCreate(t *testing.T) {
 					return
 				}
 
-				expectedFile := fmt.Sprintf("http://%s/viewer/%s", req.Host, gotResponse.ID)
-				if gotResponse.File != expectedFile {
+				// the link is versioned by the content of the media
+				expectedFile := fmt.Sprintf("http://%s/viewer/%s?v=", req.Host, gotResponse.ID)
+				if !strings.HasPrefix(gotResponse.File, expectedFile) {
 					t.Errorf("expected the response to expose a file with %q, got %q", expectedFile, gotResponse.File)
 					return
 				}
@@ -149,8 +154,9 @@ func TestMediaCreate(t *testing.T) {
 					return
 				}
 
-				expectedFile := fmt.Sprintf("http://%s/viewer/%s", req.Host, gotResponse.ID)
-				if gotResponse.File != expectedFile {
+				// the link is versioned by the content of the media
+				expectedFile := fmt.Sprintf("http://%s/viewer/%s?v=", req.Host, gotResponse.ID)
+				if !strings.HasPrefix(gotResponse.File, expectedFile) {
 					t.Errorf("expected the response to expose a file with %q, got %q", expectedFile, gotResponse.File)
 					return
 				}
@@ -194,8 +200,9 @@ func TestMediaCreate(t *testing.T) {
 					return
 				}
 
-				expectedFile := fmt.Sprintf("http://%s/viewer/%s", req.Host, gotResponse.ID)
-				if gotResponse.File != expectedFile {
+				// the link is versioned by the content of the media
+				expectedFile := fmt.Sprintf("http://%s/viewer/%s?v=", req.Host, gotResponse.ID)
+				if !strings.HasPrefix(gotResponse.File, expectedFile) {
 					t.Errorf("expected the response to expose a file with %q, got %q", expectedFile, gotResponse.File)
 					return
 				}
@@ -245,3 +252,140 @@ func prepareRequest(ctx context.Context, data string, ext string, attachFile boo
 
 	return request
 }
+
+func TestMediaCreateDigest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	service := services.NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	server := NewMediaCreateHTTPServer(service, unsignedLinks)
+
+	// sha-256 and md5 of "sample fixture test"
+	sha256Digest := "sha-256=:KFwU9E81Aq6c+vm56+Rn4wBNvWl2EAma9w5U5EBynyM=:"
+	md5Digest := "md5=:2Bb1Xs1rlgJTyxzEVLbn9w==:"
+
+	testCases := []struct {
+		name          string
+		digest        string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "sha-256", digest: sha256Digest, expectedCode: 201},
+		{name: "sha-256 and md5", digest: sha256Digest + ", " + md5Digest, expectedCode: 201},
+		{name: "unsupported algorithm", digest: "sha-512=:AAAA:", expectedCode: 201},
+		{name: "mismatch", digest: "sha-256=:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=:", expectedCode: 400, expectedError: "digest mismatch"},
+		{name: "partial mismatch", digest: sha256Digest + ", md5=:AAAAAAAAAAAAAAAAAAAAAA==:", expectedCode: 400, expectedError: "digest mismatch"},
+		{name: "invalid digest", digest: "sha-256=not base64", expectedCode: 400, expectedError: "invalid digest"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Disposition", `form-data; name="media"; filename="fixture.txt"`)
+			header.Set("Content-Type", "application/octet-stream")
+			header.Set("Content-Digest", tc.digest)
+
+			p, _ := mw.CreatePart(header)
+			p.Write([]byte("sample fixture test"))
+			mw.Close()
+
+			r := httptest.NewRequest("POST", "/medias", body).WithContext(ctx)
+			r.Header.Add("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError == "" {
+				var gotResponse mediaCreateResponse
+				json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+				medias, _ := mediaRepository.GetByIDs(ctx, gotResponse.ID)
+				if medias[gotResponse.ID].Checksums.SHA256 != "285c14f44f3502ae9cfaf9b9ebe467e3004dbd697610099af70e54e440729f23" {
+					t.Errorf("expected the checksum of the media to be stored, got %q", medias[gotResponse.ID].Checksums.SHA256)
+				}
+
+				return
+			}
+
+			var gotResponse httpError
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Error != tc.expectedError {
+				t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+			}
+		})
+	}
+}
+
+func TestMediaCreateBodyDigest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	service := services.NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	server := NewMediaCreateHTTPServer(service, unsignedLinks)
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	p, _ := mw.CreateFormFile("media", "fixture.txt")
+	p.Write([]byte("sample fixture test"))
+	mw.Close()
+
+	// the digest of a request is the one of its whole body
+	sum := sha256.Sum256(body.Bytes())
+	bodyDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+
+	testCases := []struct {
+		name          string
+		digest        string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "body digest", digest: bodyDigest, expectedCode: 201},
+		{name: "file digest", digest: "sha-256=:KFwU9E81Aq6c+vm56+Rn4wBNvWl2EAma9w5U5EBynyM=:", expectedCode: 400, expectedError: "digest mismatch"},
+		{name: "invalid digest", digest: "sha-256=not base64", expectedCode: 400, expectedError: "invalid digest"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/medias", bytes.NewReader(body.Bytes())).WithContext(ctx)
+			r.Header.Add("Content-Type", mw.FormDataContentType())
+			r.Header.Add("Content-Digest", tc.digest)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError == "" {
+				return
+			}
+
+			var gotResponse httpError
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Error != tc.expectedError {
+				t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+			}
+		})
+	}
+
+	medias, _ := mediaRepository.GetAll(ctx)
+	if len(medias) != 1 {
+		t.Errorf("expected only the verified media to be created, got %d medias", len(medias))
+	}
+}
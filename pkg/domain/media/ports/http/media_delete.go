@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewMediaDeleteHTTPServer(service media.MediaService) http.Handler {
+	return &mediaDeleteServer{service}
+}
+
+type mediaDeleteServer struct {
+	service media.MediaService
+}
+
+func (m *mediaDeleteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := m.service.Delete(ctx, r.PathValue("id")); err != nil {
+		logError(r, "could not delete media", err)
+		jsonError(w, errorMessage(err, "media not found"), toHttpCode(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
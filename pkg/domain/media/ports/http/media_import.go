@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+func NewMediaImportHTTPServer(service media.MediaService, links *LinkBuilder, fetcher media.MediaFetcher) http.Handler {
+	return &mediaImportServer{service, links, fetcher}
+}
+
+type mediaImportServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+	fetcher media.MediaFetcher
+}
+
+func (m *mediaImportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request mediaImportRequest
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&request); err != nil && err != io.EOF {
+		logging.LoggerFrom(r.Context()).Warn("could not deserialize body into proper json", "error", err)
+		jsonError(w, "json error", http.StatusBadRequest)
+		return
+	}
+
+	if request.URL == "" {
+		logging.LoggerFrom(r.Context()).Warn("empty url")
+		jsonError(w, "empty url", http.StatusBadRequest)
+		return
+	}
+
+	fileContent, fileName, mimetype, err := m.fetcher.Fetch(ctx, request.URL)
+	if err != nil {
+		logError(r, "could not fetch the media", err)
+		jsonError(w, fetchErrorMessage(err), toHttpCode(err))
+		return
+	}
+
+	// use the flename as a name if not provided
+	if request.Name == "" {
+		request.Name = fileName
+	}
+
+	media, tags, err := m.service.Create(ctx, request.Name, request.Tags, fileContent, mimetype)
+	if err != nil {
+		logError(r, "could not create media", err)
+		jsonError(w, errorMessage(err, "media creation failed"), toHttpCode(err))
+		return
+	}
+
+	jsonResponse(w, toMediaCreateResponse(r, m.links, media, tags), http.StatusCreated)
+}
+
+func fetchErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, media.ErrRemoteMediaForbidden):
+		return "url not allowed"
+	case errors.Is(err, media.ErrRemoteMediaTooLarge):
+		return "media too large"
+	default:
+		return "media not fetchable"
+	}
+}
+
+type mediaImportRequest struct {
+	URL  string   `json:"url"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+)
+
+func TestMediaImport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	remote := http.NewServeMux()
+	remote.HandleFunc("/horse.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("sample fixture test"))
+	})
+
+	remote.HandleFunc("/large.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 1024)))
+	})
+
+	remoteServer := httptest.NewServer(remote)
+	defer remoteServer.Close()
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	fakeUploader := adapters.NewFakeUploader()
+	service := services.NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), fakeUploader)
+
+	server := NewMediaImportHTTPServer(service, unsignedLinks, adapters.NewHttpFetcher(adapters.HttpFetcherConfig{MaxSize: 512, AllowPrivate: true}))
+	guardedServer := NewMediaImportHTTPServer(service, unsignedLinks, adapters.NewHttpFetcher(adapters.HttpFetcherConfig{}))
+
+	testCases := []struct {
+		name          string
+		server        http.Handler
+		body          string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "invalid json", server: server, body: "not a valid json", expectedCode: 400, expectedError: "json error"},
+		{name: "empty url", server: server, body: `{"name": "foo"}`, expectedCode: 400, expectedError: "empty url"},
+		{name: "private address", server: guardedServer, body: fmt.Sprintf(`{"url": %q}`, remoteServer.URL+"/horse.png"), expectedCode: 400, expectedError: "url not allowed"},
+		{name: "too large", server: server, body: fmt.Sprintf(`{"url": %q}`, remoteServer.URL+"/large.png"), expectedCode: 413, expectedError: "media too large"},
+		{name: "not found", server: server, body: fmt.Sprintf(`{"url": %q}`, remoteServer.URL+"/missing.png"), expectedCode: 502, expectedError: "media not fetchable"},
+		{name: "nominal case", server: server, body: fmt.Sprintf(`{"url": %q, "tags": ["foo"]}`, remoteServer.URL+"/horse.png"), expectedCode: 201},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/medias/import", strings.NewReader(tc.body)).WithContext(ctx)
+			w := httptest.NewRecorder()
+			tc.server.ServeHTTP(w, r)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError != "" {
+				var gotResponse httpError
+				json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+				if gotResponse.Error != tc.expectedError {
+					t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+				}
+
+				return
+			}
+
+			var gotResponse mediaCreateResponse
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Name != "horse.png" || len(gotResponse.Tags) != 1 {
+				t.Errorf("expected a media named %q with one tag, got %+v", "horse.png", gotResponse)
+			}
+
+			medias, _ := mediaRepository.GetByIDs(ctx, gotResponse.ID)
+			if medias[gotResponse.ID].Mimetype != "image/png" {
+				t.Errorf("expected a %q mimetype, got %q", "image/png", medias[gotResponse.ID].Mimetype)
+			}
+
+			content, _ := fakeUploader.GetContent(ctx, gotResponse.ID)
+			if string(content) != "sample fixture test" {
+				t.Errorf("expected the fetched content to be uploaded, got %q", content)
+			}
+		})
+	}
+}
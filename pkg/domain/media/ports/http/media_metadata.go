@@ -0,0 +1,98 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewMediaMetadataHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaMetadataServer{service, links}
+}
+
+type mediaMetadataServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+}
+
+func (m *mediaMetadataServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	media, tags, err := m.service.Get(ctx, r.PathValue("id"))
+	if err != nil {
+		logError(r, "error while trying to fetch media", err)
+		jsonError(w, errorMessage(err, "media not found"), toHttpCode(err))
+		return
+	}
+
+	tagsHttp := make([]string, len(tags))
+	for k, tag := range tags {
+		tagsHttp[k] = tag.Name
+	}
+
+	mediaResponse := mediaMetadataHttp{
+		ID:          media.ID,
+		Name:        media.Name,
+		Mimetype:    media.Mimetype,
+		File:        m.links.Viewer(r, media),
+		Tags:        tagsHttp,
+		Properties:  toPropertiesHttp(media.Properties),
+		Placeholder: toPlaceholderHttp(media.Placeholder),
+		Access:      toAccessHttp(media.Access),
+	}
+
+	if media.PerceptualHash != nil {
+		mediaResponse.PerceptualHash = fmt.Sprintf("%016x", uint64(*media.PerceptualHash))
+	}
+
+	jsonResponse(w, mediaResponse, http.StatusOK)
+}
+
+func toPropertiesHttp(properties media.Properties) mediaPropertiesHttp {
+	return mediaPropertiesHttp{
+		Width:    properties.Width,
+		Height:   properties.Height,
+		Duration: properties.Duration.Seconds(),
+		Bitrate:  properties.Bitrate,
+		Pages:    properties.Pages,
+	}
+}
+
+// toPlaceholderHttp returns nil if there is no placeholder, so that it can
+// be omitted
+func toPlaceholderHttp(placeholder media.Placeholder) *mediaPlaceholderHttp {
+	if placeholder.BlurHash == "" {
+		return nil
+	}
+
+	return &mediaPlaceholderHttp{
+		BlurHash: placeholder.BlurHash,
+		Colors:   placeholder.Colors,
+	}
+}
+
+type mediaMetadataHttp struct {
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Mimetype       string                `json:"mimetype"`
+	File           string                `json:"file"`
+	Tags           []string              `json:"tags"`
+	Properties     mediaPropertiesHttp   `json:"properties"`
+	Placeholder    *mediaPlaceholderHttp `json:"placeholder,omitempty"`
+	PerceptualHash string                `json:"perceptual_hash,omitempty"`
+	Access         mediaAccessHttp       `json:"access"`
+}
+
+type mediaPropertiesHttp struct {
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	Duration float64 `json:"duration,omitempty"` // in seconds
+	Bitrate  int     `json:"bitrate,omitempty"`  // in bits per second
+	Pages    int     `json:"pages,omitempty"`
+}
+
+type mediaPlaceholderHttp struct {
+	BlurHash string   `json:"blurhash"`
+	Colors   []string `json:"colors"`
+}
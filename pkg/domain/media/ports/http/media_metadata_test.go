@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+	"github.com/google/uuid"
+)
+
+type fakeProber struct{}
+
+func (fakeProber) Probe(ctx context.Context, mimetype string, fileContent []byte) (media.Properties, error) {
+	return media.Properties{Width: 640, Height: 480, Duration: 1500 * time.Millisecond}, nil
+}
+
+type fakePlaceholders struct{}
+
+func (fakePlaceholders) Generate(ctx context.Context, mimetype string, fileContent []byte) (media.Placeholder, error) {
+	return media.Placeholder{BlurHash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj", Colors: []string{"#ffffff", "#000000"}}, nil
+}
+
+func TestMediaMetadata(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := services.NewMediaService(
+		adapters.NewFakeMediaRepository(),
+		adapters.NewFakeTagRegistry(),
+		adapters.NewFakeUploader(),
+		services.WithMediaProber(fakeProber{}),
+		services.WithMediaPlaceholders(fakePlaceholders{}),
+	)
+
+	server := NewMediaMetadataHTTPServer(service, unsignedLinks)
+
+	t.Run("media not found", func(t *testing.T) {
+		id := uuid.NewString()
+		r := httptest.NewRequest("GET", fmt.Sprintf("/medias/%s/metadata", id), nil).WithContext(ctx)
+		r.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, r)
+		resp := w.Result()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected a status not found, got %d", resp.StatusCode)
+		}
+
+		var gotResponse httpError
+		decoder := json.NewDecoder(resp.Body)
+		decoder.Decode(&gotResponse)
+
+		if gotResponse.Error != "media not found" {
+			t.Errorf("expected an error %q, got %q", "media not found", gotResponse.Error)
+		}
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		mediaOK, _, _ := service.Create(ctx, "my-media", []string{"foo"}, []byte("file content"), "video/mp4")
+
+		r := httptest.NewRequest("GET", fmt.Sprintf("/medias/%s/metadata", mediaOK.ID), nil).WithContext(ctx)
+		r.SetPathValue("id", mediaOK.ID)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, r)
+		resp := w.Result()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected a status ok, got %d", resp.StatusCode)
+		}
+
+		var gotResponse mediaMetadataHttp
+		decoder := json.NewDecoder(resp.Body)
+		decoder.Decode(&gotResponse)
+
+		if gotResponse.Mimetype != "video/mp4" {
+			t.Errorf("expected a %q mimetype, got %q", "video/mp4", gotResponse.Mimetype)
+		}
+
+		if len(gotResponse.Tags) != 1 || gotResponse.Tags[0] != "foo" {
+			t.Errorf("expected the media to be tagged with %q, got %v", "foo", gotResponse.Tags)
+		}
+
+		expected := mediaPropertiesHttp{Width: 640, Height: 480, Duration: 1.5}
+		if gotResponse.Properties != expected {
+			t.Errorf("expected the properties %+v, got %+v", expected, gotResponse.Properties)
+		}
+
+		if gotResponse.Placeholder == nil || gotResponse.Placeholder.BlurHash != "LEHV6nWB2yk8pyo0adR*.7kCMdnj" {
+			t.Errorf("expected the placeholder to be exposed, got %+v", gotResponse.Placeholder)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+func NewMediaPrepareHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaPrepareServer{service, links}
+}
+
+type mediaPrepareServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+}
+
+func (m *mediaPrepareServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request mediaPrepareRequest
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&request); err != nil && err != io.EOF {
+		logging.LoggerFrom(r.Context()).Warn("could not deserialize body into proper json", "error", err)
+		jsonError(w, "json error", http.StatusBadRequest)
+		return
+	}
+
+	if request.Name == "" {
+		logging.LoggerFrom(r.Context()).Warn("empty media name")
+		jsonError(w, "empty media name", http.StatusBadRequest)
+		return
+	}
+
+	// the quotas are checked against the declared size before anything is
+	// uploaded
+	if request.Size == nil || *request.Size < 0 {
+		logging.LoggerFrom(r.Context()).Warn("invalid media size")
+		jsonError(w, "invalid media size", http.StatusBadRequest)
+		return
+	}
+
+	// same as for the regular creation, guess the mimetype from the name
+	if request.Mimetype == "" {
+		request.Mimetype = mime.TypeByExtension(filepath.Ext(request.Name))
+	}
+
+	if request.Mimetype == "" {
+		request.Mimetype = "application/octet-stream"
+	}
+
+	media, tags, upload, err := m.service.Prepare(ctx, request.Name, request.Tags, request.Mimetype, *request.Size)
+	if err != nil {
+		logError(r, "could not prepare media", err)
+		jsonError(w, errorMessage(err, "media preparation failed"), toHttpCode(err))
+		return
+	}
+
+	tagsListHttp := make([]string, len(tags))
+	for k, tag := range tags {
+		tagsListHttp[k] = tag.Name
+	}
+
+	mediaResponse := mediaPrepareResponse{
+		ID:       media.ID,
+		Name:     media.Name,
+		Tags:     tagsListHttp,
+		Upload:   toPresignedURLHttp(r, m.links, upload),
+		Complete: m.links.URL(r, "/medias/"+media.ID+"/complete"),
+	}
+
+	jsonResponse(w, mediaResponse, http.StatusCreated)
+}
+
+type mediaPrepareRequest struct {
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags"`
+	Mimetype string   `json:"mimetype"`
+	// Size of the content, in bytes
+	Size *int64 `json:"size"`
+}
+
+type mediaPrepareResponse struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Tags     []string         `json:"tags"`
+	Upload   presignedURLHttp `json:"upload"`
+	Complete string           `json:"complete"`
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+func TestMediaPrepare(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	signer := signature.NewSigner([]byte("secret"))
+	mediaRepository := adapters.NewFakeMediaRepository()
+	uploader := adapters.NewHmacPresigner(adapters.NewFakeUploader(), signer)
+	service := services.NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), uploader)
+
+	prepareServer := NewMediaPrepareHTTPServer(service, unsignedLinks)
+	completeServer := NewMediaCompleteHTTPServer(service, unsignedLinks)
+	presignServer := NewMediaPresignHTTPServer(service, unsignedLinks)
+	storageServer := NewStorageHTTPServer(mediaRepository, uploader, signer, 0)
+
+	// goes through the whole flow : preparation, upload to the storage, then
+	// completion and download from the storage
+	r := httptest.NewRequest("POST", "/medias/presigned", strings.NewReader(`{"name": "horse.png", "tags": ["foo"], "size": 19}`)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	prepareServer.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected a 201, got %d", resp.StatusCode)
+	}
+
+	var prepared mediaPrepareResponse
+	json.NewDecoder(resp.Body).Decode(&prepared)
+
+	if prepared.Upload.Method != "PUT" || !strings.HasPrefix(prepared.Upload.URL, "http://example.com/storage/"+prepared.ID+"?") {
+		t.Fatalf("expected an absolute url to upload the media, got %+v", prepared.Upload)
+	}
+
+	if prepared.Complete != "http://example.com/medias/"+prepared.ID+"/complete" {
+		t.Errorf("expected a link to complete the media, got %q", prepared.Complete)
+	}
+
+	toStorage := func(method string, rawURL string, body string) *http.Response {
+		u, _ := url.Parse(rawURL)
+		r := httptest.NewRequest(method, u.RequestURI(), strings.NewReader(body)).WithContext(ctx)
+		r.SetPathValue("id", prepared.ID)
+
+		w := httptest.NewRecorder()
+		storageServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	if resp := toStorage("PUT", prepared.Upload.URL, "sample fixture test"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected a 204 when uploading to the storage, got %d", resp.StatusCode)
+	}
+
+	// nor is it served before it is completed
+	r = httptest.NewRequest("GET", "/medias/"+prepared.ID+"/presigned", nil).WithContext(ctx)
+	r.SetPathValue("id", prepared.ID)
+	w = httptest.NewRecorder()
+	presignServer.ServeHTTP(w, r)
+
+	if resp := w.Result(); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 when downloading a pending media, got %d", resp.StatusCode)
+	}
+
+	r = httptest.NewRequest("POST", "/medias/"+prepared.ID+"/complete", nil).WithContext(ctx)
+	r.SetPathValue("id", prepared.ID)
+	w = httptest.NewRecorder()
+	completeServer.ServeHTTP(w, r)
+
+	if resp := w.Result(); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a 200 when completing, got %d", resp.StatusCode)
+	}
+
+	medias, _ := mediaRepository.GetByIDs(ctx, prepared.ID)
+	if medias[prepared.ID].Mimetype != "image/png" || medias[prepared.ID].Checksums.SHA256 != "285c14f44f3502ae9cfaf9b9ebe467e3004dbd697610099af70e54e440729f23" {
+		t.Errorf("expected the media to be completed, got %+v", medias[prepared.ID])
+	}
+
+	r = httptest.NewRequest("GET", "/medias/"+prepared.ID+"/presigned", nil).WithContext(ctx)
+	r.SetPathValue("id", prepared.ID)
+	w = httptest.NewRecorder()
+	presignServer.ServeHTTP(w, r)
+
+	var download presignedURLHttp
+	json.NewDecoder(w.Result().Body).Decode(&download)
+
+	if download.Method != "GET" {
+		t.Fatalf("expected a GET url to download the media, got %+v", download)
+	}
+
+	resp = toStorage("GET", download.URL, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a 200 when downloading from the storage, got %d", resp.StatusCode)
+	}
+
+	if body, _ := io.ReadAll(resp.Body); string(body) != "sample fixture test" {
+		t.Errorf("expected the content of the media, got %q", body)
+	}
+
+	// once completed, its content can't be changed through the storage
+	if resp := toStorage("PUT", prepared.Upload.URL, "other content"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected a 409 when uploading a completed media, got %d", resp.StatusCode)
+	}
+
+	// the upload url can't be used to download the media
+	if resp := toStorage("GET", prepared.Upload.URL, ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a 403 when downloading with the upload url, got %d", resp.StatusCode)
+	}
+}
+
+func TestMediaPrepareUnsupported(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	server := NewMediaPrepareHTTPServer(service, unsignedLinks)
+
+	testCases := []struct {
+		name          string
+		body          string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "empty name", body: `{}`, expectedCode: 400, expectedError: "empty media name"},
+		{name: "missing size", body: `{"name": "horse.png"}`, expectedCode: 400, expectedError: "invalid media size"},
+		{name: "negative size", body: `{"name": "horse.png", "size": -1}`, expectedCode: 400, expectedError: "invalid media size"},
+		{name: "unsupported storage", body: `{"name": "horse.png", "size": 19}`, expectedCode: 501, expectedError: "media preparation failed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/medias/presigned", strings.NewReader(tc.body)).WithContext(ctx)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			var gotResponse httpError
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Error != tc.expectedError {
+				t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewMediaPresignHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaPresignServer{service, links}
+}
+
+type mediaPresignServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+}
+
+func (m *mediaPresignServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	download, err := m.service.Presign(ctx, r.PathValue("id"))
+	if err != nil {
+		logError(r, "could not presign media", err)
+		jsonError(w, errorMessage(err, "media not found"), toHttpCode(err))
+		return
+	}
+
+	jsonResponse(w, toPresignedURLHttp(r, m.links, download), http.StatusOK)
+}
+
+// toPresignedURLHttp makes the url absolute if it is served by this
+// application
+func toPresignedURLHttp(r *http.Request, links *LinkBuilder, presigned media.PresignedURL) presignedURLHttp {
+	return presignedURLHttp{
+		URL:       links.URL(r, presigned.URL),
+		Method:    presigned.Method,
+		ExpiresAt: presigned.ExpiresAt,
+	}
+}
+
+type presignedURLHttp struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
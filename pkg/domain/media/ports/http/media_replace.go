@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// NewMediaReplaceHTTPServer replaces the content of a media with the file
+// uploaded in the media field, as when it is created.
+func NewMediaReplaceHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaReplaceServer{service, links}
+}
+
+type mediaReplaceServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+}
+
+func (m *mediaReplaceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	verifyBody, err := digestBody(r)
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the digest", "error", err)
+		jsonError(w, "invalid digest", http.StatusBadRequest)
+		return
+	}
+
+	fileContent, _, mimetype, err := getFile(r)
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("problem while fetching file upload", "error", err)
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := verifyBody(); err != nil {
+		logging.LoggerFrom(r.Context()).Warn("the digest of the body does not match", "error", err)
+		jsonError(w, errDigestMismatch.Error(), http.StatusBadRequest)
+		return
+	}
+
+	media, tags, err := m.service.Replace(ctx, r.PathValue("id"), fileContent, mimetype)
+	if err != nil {
+		logError(r, "could not replace media", err)
+		jsonError(w, replaceErrorMessage(err), toHttpCode(err))
+		return
+	}
+
+	jsonResponse(w, toMediaCreateResponse(r, m.links, media, tags), http.StatusOK)
+}
+
+func replaceErrorMessage(err error) string {
+	if errors.Is(err, media.ErrMediaNotFound) {
+		return "media not found"
+	}
+
+	return errorMessage(err, "media replacement failed")
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+)
+
+func TestMediaReplaceAndDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+	stranger := auth.WithPrincipal(ctx, auth.Principal{ID: "stranger"})
+
+	uploader := adapters.NewFakeUploader()
+	service := services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), uploader)
+	replaceServer := NewMediaReplaceHTTPServer(service, unsignedLinks)
+	deleteServer := NewMediaDeleteHTTPServer(service)
+
+	created, _, _ := service.Create(owner, "media", []string{"tag-1"}, []byte("original"), "text/plain")
+
+	replace := func(ctx context.Context, attachFile bool) *http.Response {
+		r := prepareRequest(ctx, "", ".txt", attachFile)
+		r.Method = "PUT"
+		r.SetPathValue("id", created.ID)
+
+		w := httptest.NewRecorder()
+		replaceServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	remove := func(ctx context.Context) *http.Response {
+		r := httptest.NewRequest("DELETE", "/medias/"+created.ID, nil).WithContext(ctx)
+		r.SetPathValue("id", created.ID)
+
+		w := httptest.NewRecorder()
+		deleteServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	testCases := []struct {
+		name          string
+		do            func() *http.Response
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "replace without file", do: func() *http.Response { return replace(owner, false) }, expectedCode: 400, expectedError: "file not found"},
+		{name: "replace by another user", do: func() *http.Response { return replace(stranger, true) }, expectedCode: 404, expectedError: "media not found"},
+		{name: "replace", do: func() *http.Response { return replace(owner, true) }, expectedCode: 200},
+		{name: "delete by another user", do: func() *http.Response { return remove(stranger) }, expectedCode: 404, expectedError: "media not found"},
+		{name: "delete", do: func() *http.Response { return remove(owner) }, expectedCode: 204},
+		{name: "delete a deleted media", do: func() *http.Response { return remove(owner) }, expectedCode: 404, expectedError: "media not found"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := tc.do()
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError == "" {
+				return
+			}
+
+			var gotResponse httpError
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Error != tc.expectedError {
+				t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+			}
+		})
+
+		if tc.name == "replace" {
+			if content, _ := uploader.GetContent(ctx, created.ID); string(content) != "sample fixture test" {
+				t.Errorf("expected the content to be replaced, got %q", content)
+			}
+		}
+	}
+}
@@ -1,19 +1,19 @@
 package http
 
 import (
-	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
 )
 
-func NewMediaSearchHTTPPort(service media.MediaService) http.Handler {
-	return &mediaSearchServer{service}
+func NewMediaSearchHTTPPort(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaSearchServer{service, links}
 }
 
 type mediaSearchServer struct {
 	service media.MediaService
+	links   *LinkBuilder
 }
 
 func (m *mediaSearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -21,15 +21,15 @@ func (m *mediaSearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	tag := r.PathValue("tag")
 	if tag == "" {
-		log.Println("empty tag")
+		logging.LoggerFrom(r.Context()).Warn("empty tag")
 		jsonError(w, "empty tag", http.StatusBadRequest)
 		return
 	}
 
 	medias, tags, err := m.service.SearchByTag(ctx, tag)
 	if err != nil {
-		log.Println("error while getting the medias : ", err)
-		jsonError(w, "internal errror", toHttpCode(err))
+		logError(r, "error while getting the medias", err)
+		jsonError(w, errorMessage(err, "internal errror"), toHttpCode(err))
 		return
 	}
 
@@ -41,10 +41,11 @@ func (m *mediaSearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		mediasHttp[k] = mediaSearchHttp{
-			ID:   media.ID,
-			Name: media.Name,
-			Tags: tagsMedia,
-			File: fmt.Sprintf("http://%s/viewer/%s", r.Host, media.ID),
+			ID:          media.ID,
+			Name:        media.Name,
+			Tags:        tagsMedia,
+			File:        m.links.Viewer(r, media),
+			Placeholder: toPlaceholderHttp(media.Placeholder),
 		}
 	}
 
@@ -57,8 +58,9 @@ type mediasSearchHTTP struct {
 }
 
 type mediaSearchHttp struct {
-	ID   string   `json:"id"`
-	Name string   `json:"name"`
-	File string   `json:"file"`
-	Tags []string `json:"tags"`
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	File        string                `json:"file"`
+	Tags        []string              `json:"tags"`
+	Placeholder *mediaPlaceholderHttp `json:"placeholder,omitempty"`
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,8 +19,8 @@ func TestMediaSearch(t *testing.T) {
 
 	repository := adapters.NewFakeMediaRepository()
 	tagRegistry := adapters.NewFakeTagRegistry()
-	service := services.NewMediaService(repository, tagRegistry, adapters.NewFakeUploader())
-	server := NewMediaSearchHTTPPort(service)
+	service := services.NewMediaService(repository, tagRegistry, adapters.NewFakeUploader(), services.WithMediaPlaceholders(fakePlaceholders{}))
+	server := NewMediaSearchHTTPPort(service, unsignedLinks)
 
 	// fixtures
 	service.Create(ctx, "media-1", []string{"tag-1", "tag-2"}, nil, "")
@@ -125,9 +126,13 @@ func TestMediaSearch(t *testing.T) {
 				t.Fatalf("media %q does not have tag %q", m.ID, "tag-1")
 			}
 
-			if m.File != fmt.Sprintf("http://%s/viewer/%s", r.Host, m.ID) {
+			if !strings.HasPrefix(m.File, fmt.Sprintf("http://%s/viewer/%s?v=", r.Host, m.ID)) {
 				t.Fatalf("expected the file property to set the url to view the media, got %q", m.File)
 			}
+
+			if m.Placeholder == nil || len(m.Placeholder.Colors) != 2 {
+				t.Fatalf("expected the placeholder of the media to be exposed, got %+v", m.Placeholder)
+			}
 		}
 	})
 }
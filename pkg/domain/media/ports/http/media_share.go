@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+func NewMediaShareHTTPServer(service media.MediaService) http.Handler {
+	return &mediaShareServer{service}
+}
+
+type mediaShareServer struct {
+	service media.MediaService
+}
+
+func (m *mediaShareServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request mediaAccessHttp
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&request); err != nil && err != io.EOF {
+		logging.LoggerFrom(r.Context()).Warn("could not deserialize body into proper json", "error", err)
+		jsonError(w, "json error", http.StatusBadRequest)
+		return
+	}
+
+	media, err := m.service.Share(ctx, r.PathValue("id"), media.Visibility(request.Visibility), request.Users, request.Groups)
+	if err != nil {
+		logError(r, "could not share media", err)
+		jsonError(w, shareErrorMessage(err), toHttpCode(err))
+		return
+	}
+
+	jsonResponse(w, toAccessHttp(media.Access), http.StatusOK)
+}
+
+func shareErrorMessage(err error) string {
+	if errors.Is(err, media.ErrInvalidVisibility) {
+		return "invalid visibility"
+	}
+
+	return errorMessage(err, "media not found")
+}
+
+func toAccessHttp(access media.Access) mediaAccessHttp {
+	visibility := access.Visibility
+	if visibility == "" {
+		visibility = media.VisibilityPublic
+	}
+
+	return mediaAccessHttp{
+		Owner:      access.Owner,
+		Visibility: string(visibility),
+		Users:      access.Users,
+		Groups:     access.Groups,
+	}
+}
+
+type mediaAccessHttp struct {
+	// the owner can't be changed, it is only returned
+	Owner      string   `json:"owner,omitempty"`
+	Visibility string   `json:"visibility"`
+	Users      []string `json:"users,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+)
+
+func TestMediaShare(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+	friend := auth.WithPrincipal(ctx, auth.Principal{ID: "friend"})
+	stranger := auth.WithPrincipal(ctx, auth.Principal{ID: "stranger"})
+
+	service := services.NewMediaService(
+		adapters.NewFakeMediaRepository(),
+		adapters.NewFakeTagRegistry(),
+		adapters.NewFakeUploader(),
+		services.WithDefaultVisibility(media.VisibilityPrivate),
+	)
+
+	server := NewMediaShareHTTPServer(service)
+	metadataServer := NewMediaMetadataHTTPServer(service, unsignedLinks)
+
+	created, _, _ := service.Create(owner, "media-1", nil, []byte("content"), "text/plain")
+
+	metadata := func(ctx context.Context) *http.Response {
+		r := httptest.NewRequest("GET", "/medias/"+created.ID+"/metadata", nil).WithContext(ctx)
+		r.SetPathValue("id", created.ID)
+
+		w := httptest.NewRecorder()
+		metadataServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	if resp := metadata(friend); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a private media not to be found, got %d", resp.StatusCode)
+	}
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		body          string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "not the owner", ctx: stranger, body: `{"visibility": "public"}`, expectedCode: 404, expectedError: "media not found"},
+		{name: "anonymous", ctx: ctx, body: `{"visibility": "public"}`, expectedCode: 404, expectedError: "media not found"},
+		{name: "invalid json", ctx: owner, body: `not json`, expectedCode: 400, expectedError: "json error"},
+		{name: "invalid visibility", ctx: owner, body: `{"visibility": "everyone"}`, expectedCode: 400, expectedError: "invalid visibility"},
+		{name: "shared", ctx: owner, body: `{"visibility": "shared", "users": ["friend"]}`, expectedCode: 200},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/medias/"+created.ID+"/access", strings.NewReader(tc.body)).WithContext(tc.ctx)
+			r.SetPathValue("id", created.ID)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError != "" {
+				var gotResponse httpError
+				json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+				if gotResponse.Error != tc.expectedError {
+					t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+				}
+			}
+		})
+	}
+
+	resp := metadata(friend)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the shared media to be found, got %d", resp.StatusCode)
+	}
+
+	var gotResponse mediaMetadataHttp
+	json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+	if gotResponse.Access.Owner != "owner" || gotResponse.Access.Visibility != "shared" || len(gotResponse.Access.Users) != 1 {
+		t.Errorf("expected the access of the media to be returned, got %+v", gotResponse.Access)
+	}
+
+	if resp := metadata(stranger); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the media not to be found by a stranger, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// default maximum distance between two similar medias, out of the 64 bits
+// of their perceptual hashes
+const defaultMaxDistance = 10
+
+func NewMediaSimilarHTTPServer(service media.MediaService, links *LinkBuilder) http.Handler {
+	return &mediaSimilarServer{service, links}
+}
+
+type mediaSimilarServer struct {
+	service media.MediaService
+	links   *LinkBuilder
+}
+
+func (m *mediaSimilarServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	maxDistance := defaultMaxDistance
+	if value := r.URL.Query().Get("max_distance"); value != "" {
+		var err error
+		maxDistance, err = strconv.Atoi(value)
+		if err != nil || maxDistance < 0 || maxDistance > 64 {
+			logging.LoggerFrom(r.Context()).Warn("invalid max distance", "max_distance", value)
+			jsonError(w, "invalid max distance", http.StatusBadRequest)
+			return
+		}
+	}
+
+	medias, tags, err := m.service.Similar(ctx, r.PathValue("id"), maxDistance)
+	if err != nil {
+		logError(r, "error while getting the similar medias", err)
+		jsonError(w, errorMessage(err, "media not found"), toHttpCode(err))
+		return
+	}
+
+	mediasHttp := make([]mediaSimilarHttp, len(medias))
+	for k, media := range medias {
+		tagsMedia := make([]string, len(tags[media.ID]))
+		for kTag, tag := range tags[media.ID] {
+			tagsMedia[kTag] = tag.Name
+		}
+
+		mediasHttp[k] = mediaSimilarHttp{
+			ID:          media.ID,
+			Name:        media.Name,
+			Tags:        tagsMedia,
+			File:        m.links.Viewer(r, media.Media),
+			Placeholder: toPlaceholderHttp(media.Placeholder),
+			Distance:    media.Distance,
+		}
+	}
+
+	list := mediasSimilarHttp{Medias: mediasHttp}
+	jsonResponse(w, list, http.StatusOK)
+}
+
+type mediasSimilarHttp struct {
+	Medias []mediaSimilarHttp `json:"medias"`
+}
+
+type mediaSimilarHttp struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	File        string                `json:"file"`
+	Tags        []string              `json:"tags"`
+	Placeholder *mediaPlaceholderHttp `json:"placeholder,omitempty"`
+	Distance    int                   `json:"distance"`
+}
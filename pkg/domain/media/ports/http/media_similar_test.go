@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+	"github.com/google/uuid"
+)
+
+// fakeHasher uses the first byte of the content as a hash
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(ctx context.Context, mimetype string, fileContent []byte) (media.PerceptualHash, error) {
+	return media.PerceptualHash(fileContent[0]), nil
+}
+
+func TestMediaSimilar(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := services.NewMediaService(
+		adapters.NewFakeMediaRepository(),
+		adapters.NewFakeTagRegistry(),
+		adapters.NewFakeUploader(),
+		services.WithPerceptualHashes(fakeHasher{}, adapters.NewBktreeIndex()),
+	)
+
+	server := NewMediaSimilarHTTPServer(service, unsignedLinks)
+
+	// fixtures
+	reference, _, _ := service.Create(ctx, "reference", nil, []byte{0b0000_1111}, "image/png")
+	service.Create(ctx, "close", []string{"tag-1"}, []byte{0b0000_0111}, "image/png")
+	service.Create(ctx, "far", nil, []byte{0b1111_0000}, "image/png")
+
+	request := func(id string, query string) *http.Response {
+		r := httptest.NewRequest("GET", fmt.Sprintf("/medias/%s/similar%s", id, query), nil).WithContext(ctx)
+		r.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	t.Run("media not found", func(t *testing.T) {
+		resp := request(uuid.NewString(), "")
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected a status not found, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("invalid max distance", func(t *testing.T) {
+		for _, query := range []string{"?max_distance=foo", "?max_distance=-1", "?max_distance=65"} {
+			resp := request(reference.ID, query)
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected a bad request for %q, got %d", query, resp.StatusCode)
+			}
+
+			var gotResponse httpError
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Error != "invalid max distance" {
+				t.Errorf("expected an error %q, got %q", "invalid max distance", gotResponse.Error)
+			}
+		}
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		resp := request(reference.ID, "")
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected a status ok, got %d", resp.StatusCode)
+		}
+
+		var gotResponse mediasSimilarHttp
+		json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+		if len(gotResponse.Medias) != 2 {
+			t.Fatalf("expected 2 similar medias, got %d", len(gotResponse.Medias))
+		}
+
+		if gotResponse.Medias[0].Name != "close" || gotResponse.Medias[0].Distance != 1 {
+			t.Errorf("expected %q to be the most similar media at a distance of 1, got %+v", "close", gotResponse.Medias[0])
+		}
+
+		if len(gotResponse.Medias[0].Tags) != 1 {
+			t.Errorf("expected the tags of the similar media to be exposed, got %v", gotResponse.Medias[0].Tags)
+		}
+	})
+
+	t.Run("with a max distance", func(t *testing.T) {
+		resp := request(reference.ID, "?max_distance=1")
+
+		var gotResponse mediasSimilarHttp
+		json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+		if len(gotResponse.Medias) != 1 {
+			t.Fatalf("expected 1 similar media, got %d", len(gotResponse.Medias))
+		}
+	})
+}
@@ -2,38 +2,93 @@ package http
 
 import (
 	"bytes"
+	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+	"github.com/Taluu/media-go/pkg/signature"
 )
 
-func NewMediaViewerHTTPServer(service media.MediaService) http.Handler {
-	return &mediaViewerServer{service}
+// NewMediaViewerHTTPServer serves the content of the medias, along with the
+// headers telling how long it may be cached. If a signer is given, the
+// requests must be signed by it, the signature granting the view of the media
+// it was issued for.
+func NewMediaViewerHTTPServer(service media.MediaService, signer *signature.Signer, cache CachePolicy) http.Handler {
+	return &mediaViewerServer{service, signer, cache}
 }
 
 type mediaViewerServer struct {
 	service media.MediaService
+	signer  *signature.Signer
+	cache   CachePolicy
 }
 
 func (s *mediaViewerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	content, mimetype, err := s.service.View(ctx, r.PathValue("id"))
+	// the content is not cached after the link expires
+	var expires time.Time
+	if s.signer != nil {
+		if err := s.signer.Verify(http.MethodGet, r.URL.Path, r.URL.Query(), time.Now()); err != nil {
+			logging.LoggerFrom(r.Context()).Warn("could not verify the signature", "error", err)
+			jsonError(w, signatureErrorMessage(err), http.StatusForbidden)
+			return
+		}
+
+		timestamp, _ := strconv.ParseInt(r.URL.Query().Get(signature.ExpiresParameter), 10, 64)
+		expires = time.Unix(timestamp, 0)
+
+		// the links are only handed to whoever can see the media
+		ctx = media.WithGrant(ctx, r.PathValue("id"))
+	}
+
+	media, content, err := s.service.View(ctx, r.PathValue("id"))
 	if err != nil {
-		log.Printf("error while trying to fetch media : %s", err)
-		jsonError(w, "media not found", toHttpCode(err))
+		logError(r, "error while trying to fetch media", err)
+		jsonError(w, errorMessage(err, "media not found"), toHttpCode(err))
 		return
 	}
 
+	version := mediaVersion(media)
+	s.cache.headers(w.Header(), media, version != "" && r.URL.Query().Get(versionParameter) == version, expires)
+
+	if media.Checksums.SHA256 != "" {
+		etag := fmt.Sprintf("%q", media.Checksums.SHA256)
+		digest, contentDigest := digestHeaders(media.Checksums)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Digest", digest)
+		w.Header().Set("Content-Digest", contentDigest)
+
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
 	sendContent := bytes.NewReader(content)
 	sendSize := int64(len(content))
 
-	w.Header().Set("Content-Type", mimetype)
+	w.Header().Set("Content-Type", media.Mimetype)
 	w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
 
 	w.WriteHeader(http.StatusOK)
 	io.CopyN(w, sendContent, sendSize)
 }
+
+// matchesETag checks whether an If-None-Match header matches the given etag
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Taluu/media-go/pkg/domain/media"
 	"github.com/Taluu/media-go/pkg/domain/media/adapters"
 	"github.com/Taluu/media-go/pkg/domain/media/services"
 	"github.com/google/uuid"
@@ -27,7 +28,7 @@ func TestMediaViewer(t *testing.T) {
 		adapters.NewFakeUploader(),
 	)
 
-	server := NewMediaViewerHTTPServer(service)
+	server := NewMediaViewerHTTPServer(service, nil, CachePolicy{})
 
 	t.Run("media not found", func(t *testing.T) {
 		id := uuid.NewString()
@@ -60,7 +61,7 @@ func TestMediaViewer(t *testing.T) {
 
 	})
 	t.Run("file not available", func(t *testing.T) {
-		mediaOK, _ := mediaRepository.Create(ctx, "my-media", "text/plain")
+		mediaOK, _ := mediaRepository.Create(ctx, media.Media{Name: "my-media", Mimetype: "text/plain"})
 
 		r := httptest.NewRequest("GET", fmt.Sprintf("/medias/%s", mediaOK.ID), nil).WithContext(ctx)
 		r.SetPathValue("id", mediaOK.ID)
@@ -112,4 +113,43 @@ func TestMediaViewer(t *testing.T) {
 			t.Errorf("expected %q as file content, got %q", "file content", string(body))
 		}
 	})
+
+	t.Run("checksums", func(t *testing.T) {
+		mediaOK, _, _ := service.Create(ctx, "my-media", nil, []byte("file content"), "text/plain")
+		etag := `"e0ac3601005dfa1864f5392aabaf7d898b1b5bab854f1acb4491bcd806b76b0c"`
+
+		r := httptest.NewRequest("GET", fmt.Sprintf("/medias/%s", mediaOK.ID), nil).WithContext(ctx)
+		r.SetPathValue("id", mediaOK.ID)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, r)
+		resp := w.Result()
+
+		if resp.Header.Get("ETag") != etag {
+			t.Errorf("Expected the %s etag, got %q", etag, resp.Header.Get("ETag"))
+		}
+
+		if resp.Header.Get("Digest") != "sha-256=4Kw2AQBd+hhk9Tkqq699iYsbW6uFTxrLRJG82Aa3aww=" {
+			t.Errorf("Unexpected digest, got %q", resp.Header.Get("Digest"))
+		}
+
+		if resp.Header.Get("Content-Digest") != "sha-256=:4Kw2AQBd+hhk9Tkqq699iYsbW6uFTxrLRJG82Aa3aww=:" {
+			t.Errorf("Unexpected content digest, got %q", resp.Header.Get("Content-Digest"))
+		}
+
+		r.Header.Set("If-None-Match", `"foo", `+etag)
+		w = httptest.NewRecorder()
+
+		server.ServeHTTP(w, r)
+		resp = w.Result()
+
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("Expected a status not modified, got %d", resp.StatusCode)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		if len(body) != 0 {
+			t.Errorf("Expected no content, got %q", string(body))
+		}
+	})
 }
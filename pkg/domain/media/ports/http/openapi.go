@@ -0,0 +1,24 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+	"strconv"
+)
+
+// openAPIDocument describes every endpoint of the application, in the
+// OpenAPI 3.1 format. It is maintained along with the endpoints, the tests
+// checking that they conform to it.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// NewOpenAPIHTTPServer serves the OpenAPI document describing the endpoints
+func NewOpenAPIHTTPServer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(openAPIDocument)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(openAPIDocument)
+	})
+}
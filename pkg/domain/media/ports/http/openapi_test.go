@@ -0,0 +1,472 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	v := newOpenAPIValidator(t)
+
+	t.Run("references", func(t *testing.T) {
+		var walk func(node any)
+		walk = func(node any) {
+			switch node := node.(type) {
+			case map[string]any:
+				if ref, ok := node["$ref"].(string); ok {
+					func() {
+						defer func() {
+							if err := recover(); err != nil {
+								t.Errorf("expected the reference %q to be resolved, got %v", ref, err)
+							}
+						}()
+
+						v.resolve(node)
+					}()
+				}
+
+				for _, child := range node {
+					walk(child)
+				}
+			case []any:
+				for _, child := range node {
+					walk(child)
+				}
+			}
+		}
+
+		walk(v.document)
+	})
+
+	t.Run("operations", func(t *testing.T) {
+		ids := make(map[string]bool)
+		for path, item := range v.document["paths"].(map[string]any) {
+			for method, operation := range item.(map[string]any) {
+				if method == "parameters" {
+					continue
+				}
+
+				id, _ := operation.(map[string]any)["operationId"].(string)
+				if id == "" || ids[id] {
+					t.Errorf("expected a unique operation id for %s %s, got %q", method, path, id)
+				}
+
+				ids[id] = true
+			}
+		}
+	})
+
+	// the routes are compared without the names of their parameters, such as
+	// /medias/{tag} and /medias/{id}, which are the same path
+	t.Run("routes", func(t *testing.T) {
+		main, err := os.ReadFile("../../../../../app/main.go")
+		if err != nil {
+			t.Fatalf("could not read the routes : %s", err)
+		}
+
+		parameter := regexp.MustCompile(`\{[^}]*\}`)
+
+		routes := make(map[string]bool)
+		for _, match := range regexp.MustCompile(`http\.Handle\("([A-Z]+) ([^"]+)"`).FindAllStringSubmatch(string(main), -1) {
+			routes[strings.ToLower(match[1])+" "+parameter.ReplaceAllString(match[2], "{}")] = true
+		}
+
+		if len(routes) == 0 {
+			t.Fatal("expected to find the routes")
+		}
+
+		documented := make(map[string]bool)
+		for path, item := range v.document["paths"].(map[string]any) {
+			for method := range item.(map[string]any) {
+				if method != "parameters" {
+					documented[method+" "+parameter.ReplaceAllString(path, "{}")] = true
+				}
+			}
+		}
+
+		for route := range routes {
+			if !documented[route] {
+				t.Errorf("expected the route %q to be documented", route)
+			}
+		}
+
+		for route := range documented {
+			if !routes[route] {
+				t.Errorf("expected the documented route %q to be routed", route)
+			}
+		}
+	})
+
+	t.Run("served", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/openapi.json", nil)
+		resp := v.serve(t, NewOpenAPIHTTPServer(), r)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected a 200, got %d", resp.StatusCode)
+		}
+	})
+}
+
+// TestOpenAPIConformance checks that the endpoints conform to the OpenAPI
+// document, both on success and on failure. The invalid requests are checked
+// not to conform to it either.
+func TestOpenAPIConformance(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+
+	v := newOpenAPIValidator(t)
+
+	signer := signature.NewSigner([]byte("secret"))
+	uploader := adapters.NewHmacPresigner(adapters.NewFakeUploader(), signer)
+	tagRegistry := adapters.NewFakeTagRegistry()
+	quotas := services.NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{Bytes: 1 << 20}, media.Usage{})
+	mediaRepository := adapters.NewFakeMediaRepository()
+	mediaService := services.NewMediaService(
+		mediaRepository,
+		tagRegistry,
+		uploader,
+		services.WithMediaProber(fakeProber{}),
+		services.WithMediaPlaceholders(fakePlaceholders{}),
+		services.WithPerceptualHashes(fakeHasher{}, adapters.NewBktreeIndex()),
+		services.WithMediaQuotas(quotas),
+	)
+	tagService := services.NewTagService(tagRegistry)
+	uploadService := newUploadService()
+
+	// fixtures
+	created, _, _ := mediaService.Create(owner, "media-1", []string{"tag-1"}, []byte("file content"), "image/png")
+	mediaService.Create(owner, "media-2", []string{"tag-1"}, []byte("file contents"), "image/png")
+	prepared, _, _, _ := mediaService.Prepare(owner, "media-3", nil, "text/plain", 16)
+	uploader.Upload(ctx, prepared.ID, []byte("uploaded content"))
+	upload, _ := uploadService.Start(ctx, media.Upload{Length: 10, Name: "upload-1", Mimetype: "text/plain"})
+	terminated, _ := uploadService.Start(ctx, media.Upload{Length: 10, Name: "upload-2", Mimetype: "text/plain"})
+
+	request := func(ctx context.Context, method string, target string, body string, headers ...string) *http.Request {
+		r := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+		for i := 0; i+1 < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+
+		return r
+	}
+
+	testCases := []struct {
+		name         string
+		handler      http.Handler
+		request      *http.Request
+		pathValues   map[string]string
+		invalid      bool
+		expectedCode int
+	}{
+		{
+			name:         "list the tags",
+			handler:      NewHttpListServer(tagService),
+			request:      request(ctx, "GET", "/tags", ""),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "create a tag",
+			handler:      NewTagsCreateServer(tagService),
+			request:      request(ctx, "POST", "/tags", `{"name": "tag-2"}`, "Content-Type", "application/json"),
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "create a tag without a name",
+			handler:      NewTagsCreateServer(tagService),
+			request:      request(ctx, "POST", "/tags", `{}`, "Content-Type", "application/json"),
+			invalid:      true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "create a tag with an invalid json",
+			handler:      NewTagsCreateServer(tagService),
+			request:      request(ctx, "POST", "/tags", `not a valid json`, "Content-Type", "application/json"),
+			invalid:      true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "search the medias",
+			handler:      NewMediaSearchHTTPPort(mediaService, unsignedLinks),
+			request:      request(ctx, "GET", "/medias/tag-1", ""),
+			pathValues:   map[string]string{"tag": "tag-1"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "search the medias of an unknown tag",
+			handler:      NewMediaSearchHTTPPort(mediaService, unsignedLinks),
+			request:      request(ctx, "GET", "/medias/unknown", ""),
+			pathValues:   map[string]string{"tag": "unknown"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "create a media",
+			handler:      NewMediaCreateHTTPServer(mediaService, unsignedLinks),
+			request:      prepareRequest(owner, `{"name": "media-4", "tags": ["tag-1"]}`, ".png", true),
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "create a media without a file",
+			handler:      NewMediaCreateHTTPServer(mediaService, unsignedLinks),
+			request:      prepareRequest(owner, `{"name": "media-4"}`, ".png", false),
+			invalid:      true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "create a media with invalid data",
+			handler:      NewMediaCreateHTTPServer(mediaService, unsignedLinks),
+			request:      prepareRequest(owner, `{"name": 42}`, ".png", true),
+			invalid:      true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "create several medias",
+			handler:      NewMediaBatchHTTPServer(mediaService, unsignedLinks, 2),
+			request:      prepareBatchRequest(owner, []batchPart{{filename: "a.png", data: `{"tags": ["tag-1"]}`}, {filename: "b.png", digest: "sha-256=:AAAA:"}}),
+			expectedCode: http.StatusMultiStatus,
+		},
+		{
+			name:         "get the metadata of a media",
+			handler:      NewMediaMetadataHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "GET", "/medias/"+created.ID+"/metadata", ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "get the metadata of an unknown media",
+			handler:      NewMediaMetadataHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "GET", "/medias/unknown/metadata", ""),
+			pathValues:   map[string]string{"id": "unknown"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "share a media",
+			handler:      NewMediaShareHTTPServer(mediaService),
+			request:      request(owner, "PUT", "/medias/"+created.ID+"/access", `{"visibility": "shared", "users": ["friend"]}`, "Content-Type", "application/json"),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "share a media with an invalid visibility",
+			handler:      NewMediaShareHTTPServer(mediaService),
+			request:      request(owner, "PUT", "/medias/"+created.ID+"/access", `{"visibility": "everyone"}`, "Content-Type", "application/json"),
+			pathValues:   map[string]string{"id": created.ID},
+			invalid:      true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "find the similar medias",
+			handler:      NewMediaSimilarHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "GET", "/medias/"+created.ID+"/similar?max_distance=64", ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "find the similar medias within an invalid distance",
+			handler:      NewMediaSimilarHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "GET", "/medias/"+created.ID+"/similar?max_distance=65", ""),
+			pathValues:   map[string]string{"id": created.ID},
+			invalid:      true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "prepare a media",
+			handler:      NewMediaPrepareHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "POST", "/medias/presigned", `{"name": "media-5.png", "tags": ["tag-1"], "size": 16}`, "Content-Type", "application/json"),
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "complete a media",
+			handler:      NewMediaCompleteHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "POST", "/medias/"+prepared.ID+"/complete", ""),
+			pathValues:   map[string]string{"id": prepared.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "presign a media",
+			handler:      NewMediaPresignHTTPServer(mediaService, unsignedLinks),
+			request:      request(owner, "GET", "/medias/"+created.ID+"/presigned", ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "view a media",
+			handler:      NewMediaViewerHTTPServer(mediaService, nil, CachePolicy{DefaultMaxAge: time.Hour}),
+			request:      request(owner, "GET", "/viewer/"+created.ID, ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "view an unmodified media",
+			handler:      NewMediaViewerHTTPServer(mediaService, nil, CachePolicy{DefaultMaxAge: time.Hour}),
+			request:      request(owner, "GET", "/viewer/"+created.ID, "", "If-None-Match", `"e0ac3601005dfa1864f5392aabaf7d898b1b5bab854f1acb4491bcd806b76b0c"`),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "view a media without a signature",
+			handler:      NewMediaViewerHTTPServer(mediaService, signer, CachePolicy{}),
+			request:      request(owner, "GET", "/viewer/"+created.ID, ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "get the quotas",
+			handler:      NewQuotaHTTPServer(quotas),
+			request:      request(owner, "GET", "/quota", ""),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "download a media through a presigned url",
+			handler:      NewStorageHTTPServer(mediaRepository, uploader, signer, 0),
+			request:      request(ctx, "GET", "/storage/"+created.ID+"?"+signer.Sign("GET", "/storage/"+created.ID, time.Now().Add(time.Minute)).Encode(), ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "upload a media through an unsigned url",
+			handler:      NewStorageHTTPServer(mediaRepository, uploader, signer, 0),
+			request:      request(ctx, "PUT", "/storage/"+created.ID, "content", "Content-Type", "application/octet-stream"),
+			pathValues:   map[string]string{"id": created.ID},
+			invalid:      true,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "discover the resumable uploads",
+			handler:      NewUploadOptionsHTTPServer(100),
+			request:      request(ctx, "OPTIONS", "/uploads", ""),
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "start a resumable upload",
+			handler:      NewUploadCreateHTTPServer(uploadService, unsignedLinks, 100),
+			request:      request(ctx, "POST", "/uploads", "", "Tus-Resumable", tusVersion, "Upload-Length", "10", "Upload-Metadata", "filename dGVzdC50eHQ="),
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "start a resumable upload with another version",
+			handler:      NewUploadCreateHTTPServer(uploadService, unsignedLinks, 100),
+			request:      request(ctx, "POST", "/uploads", "", "Tus-Resumable", "0.2.2", "Upload-Length", "10"),
+			invalid:      true,
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:         "get the offset of a resumable upload",
+			handler:      NewUploadOffsetHTTPServer(uploadService),
+			request:      request(ctx, "HEAD", "/uploads/"+upload.ID, "", "Tus-Resumable", tusVersion),
+			pathValues:   map[string]string{"id": upload.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "append to a resumable upload",
+			handler:      NewUploadPatchHTTPServer(uploadService),
+			request:      request(ctx, "PATCH", "/uploads/"+upload.ID, "0123456789", "Tus-Resumable", tusVersion, "Upload-Offset", "0", "Content-Type", tusContentType),
+			pathValues:   map[string]string{"id": upload.ID},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "append to a resumable upload at another offset",
+			handler:      NewUploadPatchHTTPServer(uploadService),
+			request:      request(ctx, "PATCH", "/uploads/"+terminated.ID, "56789", "Tus-Resumable", tusVersion, "Upload-Offset", "5", "Content-Type", tusContentType),
+			pathValues:   map[string]string{"id": terminated.ID},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "terminate a resumable upload",
+			handler:      NewUploadTerminateHTTPServer(uploadService),
+			request:      request(ctx, "DELETE", "/uploads/"+terminated.ID, "", "Tus-Resumable", tusVersion),
+			pathValues:   map[string]string{"id": terminated.ID},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "replace a media",
+			handler:      NewMediaReplaceHTTPServer(mediaService, unsignedLinks),
+			request:      withMethod(prepareRequest(owner, "", ".png", true), "PUT", "/medias/"+created.ID),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "delete a media",
+			handler:      NewMediaDeleteHTTPServer(mediaService),
+			request:      request(owner, "DELETE", "/medias/"+created.ID, ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "delete an unknown media",
+			handler:      NewMediaDeleteHTTPServer(mediaService),
+			request:      request(owner, "DELETE", "/medias/"+created.ID, ""),
+			pathValues:   map[string]string{"id": created.ID},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "check the liveness",
+			handler:      NewLivenessHTTPServer(),
+			request:      request(ctx, "GET", "/healthz", ""),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "check the readiness",
+			handler:      NewReadinessHTTPServer(map[string]any{"ok": fakeHealthProber{}, "unchecked": struct{}{}, "down": fakeHealthProber{err: media.ErrFileNotFound}}, time.Second),
+			request:      request(ctx, "GET", "/readyz", ""),
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	// the cases depend on each other, such as the deletion on the replacement,
+	// so they are run in order
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.pathValues {
+				tc.request.SetPathValue(name, value)
+			}
+
+			if tc.invalid {
+				if err := v.validateRequest(tc.request); err == nil {
+					t.Errorf("expected the request not to conform")
+				}
+
+				w := httptest.NewRecorder()
+				tc.handler.ServeHTTP(w, tc.request)
+
+				resp := w.Result()
+				if err := v.validateResponse(tc.request, resp); err != nil {
+					t.Errorf("expected the response to conform, got %s", err)
+				}
+
+				if resp.StatusCode != tc.expectedCode {
+					t.Errorf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+				}
+
+				return
+			}
+
+			resp := v.serve(t, tc.handler, tc.request)
+			if resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+// withMethod changes the method and the target of a request
+func withMethod(r *http.Request, method string, target string) *http.Request {
+	r.Method = method
+	r.URL.Path = target
+	r.RequestURI = target
+
+	return r
+}
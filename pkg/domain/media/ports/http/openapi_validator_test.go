@@ -0,0 +1,521 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openAPIValidator checks that the requests and the responses conform to the
+// OpenAPI document. Only the subset of JSON Schema used by the document is
+// supported, the other keywords being reported as errors so that they are not
+// silently left unchecked.
+type openAPIValidator struct {
+	document map[string]any
+}
+
+func newOpenAPIValidator(t *testing.T) *openAPIValidator {
+	t.Helper()
+
+	var document map[string]any
+	if err := json.Unmarshal(openAPIDocument, &document); err != nil {
+		t.Fatalf("expected a valid json document, got %s", err)
+	}
+
+	return &openAPIValidator{document}
+}
+
+// serve validates the request, serves it, then validates the response
+func (v *openAPIValidator) serve(t *testing.T, handler http.Handler, r *http.Request) *http.Response {
+	t.Helper()
+
+	if err := v.validateRequest(r); err != nil {
+		t.Errorf("expected the request %s %s to conform, got %s", r.Method, r.URL.Path, err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	resp := w.Result()
+
+	if err := v.validateResponse(r, resp); err != nil {
+		t.Errorf("expected the response to %s %s to conform, got %s", r.Method, r.URL.Path, err)
+	}
+
+	return resp
+}
+
+// operation finds the operation of a request, preferring the paths with the
+// most literal segments, as the router does
+func (v *openAPIValidator) operation(method string, path string) (pathItem map[string]any, operation map[string]any, err error) {
+	segments := strings.Split(path, "/")
+	literals := -1
+
+	paths, _ := v.document["paths"].(map[string]any)
+	for template, item := range paths {
+		item := item.(map[string]any)
+		candidate, ok := item[strings.ToLower(method)].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		templateSegments := strings.Split(template, "/")
+		if len(templateSegments) != len(segments) {
+			continue
+		}
+
+		count := 0
+		for i, segment := range templateSegments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && segments[i] != "" {
+				continue
+			}
+
+			if segment != segments[i] {
+				count = -1
+				break
+			}
+
+			count++
+		}
+
+		if count > literals {
+			pathItem, operation, literals = item, candidate, count
+		}
+	}
+
+	if operation == nil {
+		return nil, nil, fmt.Errorf("no operation %s %s", method, path)
+	}
+
+	return pathItem, operation, nil
+}
+
+func (v *openAPIValidator) validateRequest(r *http.Request) error {
+	pathItem, operation, err := v.operation(r.Method, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+
+	parameters := append(v.list(pathItem["parameters"]), v.list(operation["parameters"])...)
+	for _, parameter := range parameters {
+		parameter := v.resolve(parameter)
+		name := parameter["name"].(string)
+
+		var value string
+		switch parameter["in"] {
+		case "query":
+			value = r.URL.Query().Get(name)
+		case "header":
+			value = r.Header.Get(name)
+		default:
+			continue
+		}
+
+		if value == "" {
+			if parameter["required"] == true {
+				errs = append(errs, fmt.Errorf("missing %s parameter %q", parameter["in"], name))
+			}
+
+			continue
+		}
+
+		if err := v.validateString(parameter["schema"], value, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	body, err := readBody(&r.Body)
+	if err != nil {
+		return err
+	}
+
+	requestBody, ok := operation["requestBody"].(map[string]any)
+	if !ok {
+		return errors.Join(errs...)
+	}
+
+	requestBody = v.resolve(requestBody)
+	if len(body) == 0 {
+		if requestBody["required"] == true {
+			errs = append(errs, fmt.Errorf("missing body"))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	content, ok := v.content(requestBody, mediaType)
+	if !ok {
+		return errors.Join(append(errs, fmt.Errorf("undocumented content type %q", mediaType))...)
+	}
+
+	switch mediaType {
+	case "application/json":
+		errs = append(errs, v.validateJSON(content["schema"], body))
+	case "multipart/form-data":
+		errs = append(errs, v.validateMultipart(content["schema"], body, params["boundary"]))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (v *openAPIValidator) validateResponse(r *http.Request, resp *http.Response) error {
+	_, operation, err := v.operation(r.Method, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	responses := operation["responses"].(map[string]any)
+	response, ok := responses[strconv.Itoa(resp.StatusCode)].(map[string]any)
+	if !ok {
+		return fmt.Errorf("undocumented status %d", resp.StatusCode)
+	}
+
+	response = v.resolve(response)
+	errs := make([]error, 0)
+
+	headers, _ := response["headers"].(map[string]any)
+	for name, header := range headers {
+		header := v.resolve(header)
+
+		value := resp.Header.Get(name)
+		if value == "" {
+			if header["required"] == true {
+				errs = append(errs, fmt.Errorf("missing header %q", name))
+			}
+
+			continue
+		}
+
+		if err := v.validateString(header["schema"], value, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := response["content"]; !ok {
+		if len(body) > 0 {
+			errs = append(errs, fmt.Errorf("undocumented body %q", body))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	// the responses to the HEAD requests have no body
+	if r.Method == http.MethodHead {
+		return errors.Join(errs...)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	content, ok := v.content(response, mediaType)
+	if !ok {
+		return errors.Join(append(errs, fmt.Errorf("undocumented content type %q", mediaType))...)
+	}
+
+	if mediaType == "application/json" {
+		errs = append(errs, v.validateJSON(content["schema"], body))
+	}
+
+	return errors.Join(errs...)
+}
+
+// content returns the documented content matching the media type, such as
+// image/* or */* for image/png
+func (v *openAPIValidator) content(node map[string]any, mediaType string) (map[string]any, bool) {
+	contents, _ := node["content"].(map[string]any)
+	family, _, _ := strings.Cut(mediaType, "/")
+
+	for _, candidate := range []string{mediaType, family + "/*", "*/*"} {
+		if content, ok := contents[candidate].(map[string]any); ok {
+			return content, true
+		}
+	}
+
+	return nil, false
+}
+
+func (v *openAPIValidator) validateJSON(schema any, body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid json : %w", err)
+	}
+
+	return v.validate(schema, value, "$")
+}
+
+// validateMultipart validates the parts of a multipart body as the properties
+// of an object, each property being the value of the first part of its name,
+// or the values of all of them if it is an array
+func (v *openAPIValidator) validateMultipart(schema any, body []byte, boundary string) error {
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(32 << 20)
+	if err != nil {
+		return fmt.Errorf("invalid multipart body : %w", err)
+	}
+
+	defer form.RemoveAll()
+
+	parts := make(map[string][]any)
+	for name, values := range form.Value {
+		for _, value := range values {
+			parts[name] = append(parts[name], value)
+		}
+	}
+
+	for name, files := range form.File {
+		for _, file := range files {
+			f, err := file.Open()
+			if err != nil {
+				return err
+			}
+
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			parts[name] = append(parts[name], string(content))
+		}
+	}
+
+	properties, _ := v.resolve(schema)["properties"].(map[string]any)
+
+	value := make(map[string]any, len(parts))
+	for name, values := range parts {
+		if property, ok := properties[name]; ok && v.resolve(property)["type"] == "array" {
+			value[name] = values
+		} else {
+			value[name] = values[0]
+		}
+	}
+
+	return v.validate(schema, value, "$")
+}
+
+// validateString validates the value of a parameter or a header, converted
+// to the type of its schema
+func (v *openAPIValidator) validateString(schema any, raw string, path string) error {
+	var value any = raw
+
+	switch v.resolve(schema)["type"] {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return fmt.Errorf("%s : expected a number, got %q", path, raw)
+		}
+
+		value = json.Number(raw)
+	case "boolean":
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s : expected a boolean, got %q", path, raw)
+		}
+
+		value = parsed
+	}
+
+	return v.validate(schema, value, path)
+}
+
+// validate validates a value decoded from json, numbers as json.Number
+func (v *openAPIValidator) validate(schema any, value any, path string) error {
+	node := v.resolve(schema)
+	errs := make([]error, 0)
+
+	for keyword := range node {
+		switch keyword {
+		case "type", "enum", "properties", "required", "additionalProperties", "items",
+			"minimum", "maximum", "pattern", "format", "contentMediaType", "contentSchema",
+			"description", "default":
+		default:
+			errs = append(errs, fmt.Errorf("%s : unsupported keyword %q", path, keyword))
+		}
+	}
+
+	if types, ok := node["type"]; ok && !matchesType(types, value) {
+		return fmt.Errorf("%s : expected a value of type %v, got %v", path, types, value)
+	}
+
+	if enum, ok := node["enum"].([]any); ok && !slices.Contains(enum, value) {
+		errs = append(errs, fmt.Errorf("%s : expected one of %v, got %v", path, enum, value))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		properties, _ := node["properties"].(map[string]any)
+
+		for _, name := range v.list(node["required"]) {
+			if _, ok := value[name.(string)]; !ok {
+				errs = append(errs, fmt.Errorf("%s : missing property %q", path, name))
+			}
+		}
+
+		for name, property := range value {
+			if schema, ok := properties[name]; ok {
+				errs = append(errs, v.validate(schema, property, path+"."+name))
+				continue
+			}
+
+			switch additional := node["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Errorf("%s : undocumented property %q", path, name))
+				}
+			case map[string]any:
+				errs = append(errs, v.validate(additional, property, path+"."+name))
+			}
+		}
+
+	case []any:
+		if items, ok := node["items"]; ok {
+			for i, item := range value {
+				errs = append(errs, v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)))
+			}
+		}
+
+	case json.Number:
+		number, _ := value.Float64()
+		if minimum, ok := node["minimum"].(float64); ok && number < minimum {
+			errs = append(errs, fmt.Errorf("%s : expected at least %v, got %v", path, minimum, number))
+		}
+
+		if maximum, ok := node["maximum"].(float64); ok && number > maximum {
+			errs = append(errs, fmt.Errorf("%s : expected at most %v, got %v", path, maximum, number))
+		}
+
+	case string:
+		errs = append(errs, v.validateFormat(node, value, path))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateFormat validates the pattern, the format and the content of a
+// string, such as a json encoded one
+func (v *openAPIValidator) validateFormat(node map[string]any, value string, path string) error {
+	if pattern, ok := node["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(value) {
+		return fmt.Errorf("%s : expected to match %q, got %q", path, pattern, value)
+	}
+
+	switch node["format"] {
+	case "uri":
+		if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+			return fmt.Errorf("%s : expected an absolute uri, got %q", path, value)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("%s : expected a date-time, got %q", path, value)
+		}
+	}
+
+	if schema, ok := node["contentSchema"]; ok && node["contentMediaType"] == "application/json" {
+		if err := v.validateJSON(schema, []byte(value)); err != nil {
+			return fmt.Errorf("%s : %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func matchesType(types any, value any) bool {
+	list, ok := types.([]any)
+	if !ok {
+		list = []any{types}
+	}
+
+	for _, expected := range list {
+		switch value := value.(type) {
+		case nil:
+			if expected == "null" {
+				return true
+			}
+		case bool:
+			if expected == "boolean" {
+				return true
+			}
+		case string:
+			if expected == "string" {
+				return true
+			}
+		case json.Number:
+			if expected == "number" || expected == "integer" && !strings.ContainsAny(value.String(), ".eE") {
+				return true
+			}
+		case []any:
+			if expected == "array" {
+				return true
+			}
+		case map[string]any:
+			if expected == "object" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// resolve follows the reference of a node, if any, such as
+// #/components/schemas/Media
+func (v *openAPIValidator) resolve(node any) map[string]any {
+	resolved, _ := node.(map[string]any)
+
+	for {
+		ref, ok := resolved["$ref"].(string)
+		if !ok {
+			return resolved
+		}
+
+		var current any = v.document
+		for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			current = current.(map[string]any)[segment]
+		}
+
+		resolved, ok = current.(map[string]any)
+		if !ok {
+			panic(fmt.Sprintf("unresolved reference %q", ref))
+		}
+	}
+}
+
+func (v *openAPIValidator) list(node any) []any {
+	list, _ := node.([]any)
+	return list
+}
+
+// readBody reads a body, and replaces it with a copy to be read again
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	content, err := io.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+
+	(*body).Close()
+	*body = io.NopCloser(bytes.NewReader(content))
+
+	return content, nil
+}
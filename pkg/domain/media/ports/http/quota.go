@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewQuotaHTTPServer(service media.QuotaService) http.Handler {
+	return &quotaServer{service}
+}
+
+type quotaServer struct {
+	service media.QuotaService
+}
+
+func (q *quotaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, tenant, err := q.service.Get(ctx)
+	if err != nil {
+		logError(r, "could not get the quotas", err)
+		jsonError(w, "internal error", toHttpCode(err))
+		return
+	}
+
+	response := quotasHttp{Tenant: toQuotaHttp(tenant)}
+	if user != nil {
+		userHttp := toQuotaHttp(*user)
+		response.User = &userHttp
+	}
+
+	jsonResponse(w, response, http.StatusOK)
+}
+
+func toQuotaHttp(quota media.Quota) quotaHttp {
+	return quotaHttp{
+		Bytes:     quota.Usage.Bytes,
+		Medias:    quota.Usage.Medias,
+		MaxBytes:  quota.Limits.Bytes,
+		MaxMedias: quota.Limits.Medias,
+	}
+}
+
+type quotasHttp struct {
+	User   *quotaHttp `json:"user,omitempty"`
+	Tenant quotaHttp  `json:"tenant"`
+}
+
+type quotaHttp struct {
+	Bytes  int64 `json:"bytes"`
+	Medias int64 `json:"medias"`
+	// no limit is omitted
+	MaxBytes  int64 `json:"max_bytes,omitempty"`
+	MaxMedias int64 `json:"max_medias,omitempty"`
+}
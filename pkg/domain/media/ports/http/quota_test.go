@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+)
+
+func TestQuotaServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+
+	// the fixtures are 19 bytes long
+	quotas := services.NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{Bytes: 30}, media.Usage{Bytes: 40, Medias: 5})
+	service := services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), services.WithMediaQuotas(quotas))
+	createServer := NewMediaCreateHTTPServer(service, unsignedLinks)
+	server := NewQuotaHTTPServer(quotas)
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "within the quotas", ctx: owner, expectedCode: http.StatusCreated},
+		{name: "user quota exceeded", ctx: owner, expectedCode: http.StatusInsufficientStorage, expectedError: "quota exceeded"},
+		{name: "anonymous, within the tenant quota", ctx: ctx, expectedCode: http.StatusCreated},
+		{name: "tenant quota exceeded", ctx: ctx, expectedCode: http.StatusInsufficientStorage, expectedError: "quota exceeded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			createServer.ServeHTTP(w, prepareRequest(tc.ctx, "", ".txt", true))
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError == "" {
+				return
+			}
+
+			var gotResponse httpError
+			json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+			if gotResponse.Code != tc.expectedCode || gotResponse.Error != tc.expectedError {
+				t.Errorf("expected a %q error, got %+v", tc.expectedError, gotResponse)
+			}
+		})
+	}
+
+	t.Run("larger than the quota", func(t *testing.T) {
+		quotas := services.NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{Bytes: 10}, media.Usage{})
+		service := services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), services.WithMediaQuotas(quotas))
+
+		w := httptest.NewRecorder()
+		NewMediaCreateHTTPServer(service, unsignedLinks).ServeHTTP(w, prepareRequest(owner, "", ".txt", true))
+
+		var gotResponse httpError
+		json.NewDecoder(w.Result().Body).Decode(&gotResponse)
+
+		if gotResponse.Code != http.StatusRequestEntityTooLarge || gotResponse.Error != "media larger than the quota" {
+			t.Errorf("expected a 413, got %+v", gotResponse)
+		}
+	})
+
+	t.Run("get the quotas", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/quota", nil).WithContext(owner)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+
+		var gotResponse quotasHttp
+		json.NewDecoder(w.Result().Body).Decode(&gotResponse)
+
+		expectedUser := quotaHttp{Bytes: 19, Medias: 1, MaxBytes: 30}
+		if gotResponse.User == nil || *gotResponse.User != expectedUser {
+			t.Errorf("expected the user quota %+v, got %+v", expectedUser, gotResponse.User)
+		}
+
+		expectedTenant := quotaHttp{Bytes: 38, Medias: 2, MaxBytes: 40, MaxMedias: 5}
+		if gotResponse.Tenant != expectedTenant {
+			t.Errorf("expected the tenant quota %+v, got %+v", expectedTenant, gotResponse.Tenant)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+// NewStorageHTTPServer serves the content of the medias to the clients
+// holding a presigned url, on GET (download) and PUT (upload) requests. The
+// content of a media can only be uploaded while it is pending, and downloaded
+// once it is completed. With several tenants, the repository must not be
+// scoped to one of them, the id being prefixed by the tenant of the media.
+func NewStorageHTTPServer(medias media.MediaRepository, uploader media.MediaUploader, signer *signature.Signer, maxSize int64) http.Handler {
+	return &storageServer{medias, uploader, signer, maxSize}
+}
+
+type storageServer struct {
+	medias   media.MediaRepository
+	uploader media.MediaUploader
+	signer   *signature.Signer
+	maxSize  int64
+}
+
+func (s *storageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+
+	if err := s.signer.Verify(r.Method, r.URL.Path, r.URL.Query(), time.Now()); err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not verify the signature", "error", err)
+		jsonError(w, signatureErrorMessage(err), http.StatusForbidden)
+		return
+	}
+
+	stored, err := s.media(ctx, id)
+	if err != nil {
+		logError(r, "error while trying to fetch media", err)
+		jsonError(w, "file not found", toHttpCode(err))
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		if stored.Pending {
+			jsonError(w, "file not found", http.StatusNotFound)
+			return
+		}
+
+		fileContent, err := s.uploader.GetContent(ctx, id)
+		if err != nil {
+			logError(r, "error while trying to fetch file", err)
+			jsonError(w, "file not found", toHttpCode(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write(fileContent)
+		return
+	}
+
+	if !stored.Pending {
+		jsonError(w, "media already completed", http.StatusConflict)
+		return
+	}
+
+	if s.maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxSize)
+	}
+
+	fileContent, err := io.ReadAll(r.Body)
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not read file", "error", err)
+
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			jsonError(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		jsonError(w, "file not readable", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.uploader.Upload(ctx, id, fileContent); err != nil {
+		logError(r, "could not upload file", err)
+		jsonError(w, "upload failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// media returns the media whose content is stored under the given key,
+// which is prefixed by its tenant if it has one
+func (s *storageServer) media(ctx context.Context, key string) (media.Media, error) {
+	tenant, id := "", key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		tenant, id = key[:i], key[i+1:]
+	}
+
+	medias, err := s.medias.GetByIDs(ctx, id)
+	if err != nil {
+		return media.Media{}, err
+	}
+
+	found, exists := medias[id]
+	if !exists || found.Tenant != tenant {
+		return media.Media{}, media.MediaNotFound(id)
+	}
+
+	return found, nil
+}
+
+func signatureErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, signature.ErrExpiredSignature):
+		return "expired signature"
+	case errors.Is(err, signature.ErrMissingSignature):
+		return "missing signature"
+	default:
+		return "invalid signature"
+	}
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/signature"
+)
+
+func TestStorage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	signer := signature.NewSigner([]byte("secret"))
+	mediaRepository := adapters.NewFakeMediaRepository()
+	uploader := adapters.NewFakeUploader()
+	server := NewStorageHTTPServer(mediaRepository, uploader, signer, 10)
+
+	// the content of the completed media can be downloaded, while the one of
+	// the pending media can be uploaded
+	completed, _ := mediaRepository.Create(ctx, media.Media{Name: "completed", Mimetype: "text/plain"})
+	pending, _ := mediaRepository.Create(ctx, media.Media{Name: "pending", Mimetype: "text/plain", Pending: true})
+
+	uploader.Upload(ctx, completed.ID, []byte("content"))
+	uploader.Upload(ctx, pending.ID, []byte("content"))
+
+	foo := "/storage/" + completed.ID
+	bar := "/storage/" + pending.ID
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "download", method: "GET", target: signer.SignURL("GET", foo, now.Add(time.Minute)), expectedCode: 200},
+		{name: "upload", method: "PUT", target: signer.SignURL("PUT", bar, now.Add(time.Minute)), body: "other", expectedCode: 204},
+		{name: "missing signature", method: "GET", target: foo, expectedCode: 403, expectedError: "missing signature"},
+		{name: "expired signature", method: "GET", target: signer.SignURL("GET", foo, now.Add(-time.Minute)), expectedCode: 403, expectedError: "expired signature"},
+		{name: "other media", method: "GET", target: strings.Replace(signer.SignURL("GET", foo, now.Add(time.Minute)), completed.ID, pending.ID, 1), expectedCode: 403, expectedError: "invalid signature"},
+		{name: "media not found", method: "GET", target: signer.SignURL("GET", "/storage/unknown", now.Add(time.Minute)), expectedCode: 404, expectedError: "file not found"},
+		{name: "other tenant", method: "GET", target: signer.SignURL("GET", "/storage/acme/"+completed.ID, now.Add(time.Minute)), expectedCode: 404, expectedError: "file not found"},
+		{name: "download pending", method: "GET", target: signer.SignURL("GET", bar, now.Add(time.Minute)), expectedCode: 404, expectedError: "file not found"},
+		{name: "upload completed", method: "PUT", target: signer.SignURL("PUT", foo, now.Add(time.Minute)), body: "other", expectedCode: 409, expectedError: "media already completed"},
+		{name: "too large", method: "PUT", target: signer.SignURL("PUT", bar, now.Add(time.Minute)), body: "way too much content", expectedCode: 413, expectedError: "file too large"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)).WithContext(ctx)
+			r.SetPathValue("id", strings.TrimPrefix(r.URL.Path, "/storage/"))
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if tc.expectedError != "" {
+				var gotResponse httpError
+				json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+				if gotResponse.Error != tc.expectedError {
+					t.Errorf("expected an error %q, got %q", tc.expectedError, gotResponse.Error)
+				}
+			}
+
+			if tc.method == "GET" && tc.expectedCode == 200 {
+				if body, _ := io.ReadAll(resp.Body); string(body) != "content" {
+					t.Errorf("expected the content of the media, got %q", body)
+				}
+			}
+		})
+	}
+
+	if content, _ := uploader.GetContent(ctx, pending.ID); string(content) != "other" {
+		t.Errorf("expected the content of the pending media to be replaced, got %q", content)
+	}
+
+	if content, _ := uploader.GetContent(ctx, completed.ID); string(content) != "content" {
+		t.Errorf("expected the content of the completed media to be kept, got %q", content)
+	}
+}
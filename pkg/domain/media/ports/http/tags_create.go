@@ -3,10 +3,10 @@ package http
 import (
 	"encoding/json"
 	"io"
-	"log"
 	"net/http"
 
 	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
 )
 
 func NewTagsCreateServer(service media.TagService) http.Handler {
@@ -24,21 +24,21 @@ func (t *tagCreateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 
 	if err := decoder.Decode(&request); err != nil && err != io.EOF {
-		log.Printf("could not deserialize body into proper json : %s", err)
+		logging.LoggerFrom(r.Context()).Warn("could not deserialize body into proper json", "error", err)
 		jsonError(w, "json error", http.StatusBadRequest)
 		return
 	}
 
 	if request.Name == "" {
-		log.Printf("empty tag name")
+		logging.LoggerFrom(r.Context()).Warn("empty tag name")
 		jsonError(w, "empty tag name", http.StatusBadRequest)
 		return
 	}
 
 	result, err := t.service.Create(ctx, request.Name)
 	if err != nil {
-		log.Printf("could not create tag : %s", err)
-		jsonError(w, "internal error", toHttpCode(err))
+		logError(r, "could not create tag", err)
+		jsonError(w, errorMessage(err, "internal error"), toHttpCode(err))
 		return
 	}
 
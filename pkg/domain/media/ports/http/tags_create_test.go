@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Taluu/media-go/pkg/auth"
 	"github.com/Taluu/media-go/pkg/domain/media/adapters"
 	"github.com/Taluu/media-go/pkg/domain/media/services"
 )
@@ -107,3 +108,31 @@ func TestTagCreateServer(t *testing.T) {
 		}
 	})
 }
+
+func TestTagCreateServerForbidden(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := services.NewTagService(adapters.NewFakeTagRegistry(), services.WithTagPolicy(auth.DefaultPolicy()))
+	server := NewTagsCreateServer(service)
+
+	uploader := auth.WithPrincipal(ctx, auth.Principal{ID: "bob", Roles: []string{auth.RoleUploader}})
+
+	r := httptest.NewRequest("POST", "/tags", strings.NewReader(`{"name": "test"}`)).WithContext(uploader)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 403 {
+		t.Fatalf("Did not expect HTTP %d (%s)", resp.StatusCode, resp.Status)
+	}
+
+	var gotResponse httpError
+	json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+	if gotResponse.Code != 403 || gotResponse.Error != "forbidden" {
+		t.Fatalf("expected a forbidden error, got %+v", gotResponse)
+	}
+}
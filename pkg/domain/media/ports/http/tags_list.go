@@ -1,7 +1,6 @@
 package http
 
 import (
-	"log"
 	"net/http"
 
 	//lint:ignore ST1001
@@ -21,8 +20,8 @@ func (s *tagListServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	tags, err := s.GetAll(ctx)
 	if err != nil {
-		log.Println("error while getting the tags : ", err)
-		jsonError(w, "internal errror", toHttpCode(err))
+		logError(r, "error while getting the tags", err)
+		jsonError(w, errorMessage(err, "internal errror"), toHttpCode(err))
 		return
 	}
 
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// https://tus.io/protocols/resumable-upload
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+
+	tusContentType = "application/offset+octet-stream"
+)
+
+// checkTusResumable advertises the version of the protocol spoken by the
+// server, and checks that the client speaks the same. A 412 is sent if that
+// is not the case, and false returned.
+func checkTusResumable(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		jsonError(w, "unsupported tus version", http.StatusPreconditionFailed)
+		return false
+	}
+
+	return true
+}
+
+// parseUploadMetadata parses an Upload-Metadata header, which is a comma
+// separated list of keys and their base64 encoded value (which may be absent)
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for the metadata %q : %w", key, err)
+		}
+
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+func formatUploadMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+
+	// the map iteration order being random, keeps the header stable
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// uploadHeaders sets the headers describing the state of an upload
+func uploadHeaders(w http.ResponseWriter, upload media.Upload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if upload.MediaID != "" {
+		w.Header().Set("Media-Id", upload.MediaID)
+	}
+}
+
+func parseUploadInt(header string) (int64, error) {
+	value, err := strconv.ParseInt(header, 10, 64)
+	if err == nil && value < 0 {
+		err = fmt.Errorf("negative value %d", value)
+	}
+
+	return value, err
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+func NewUploadCreateHTTPServer(service media.UploadService, links *LinkBuilder, maxSize int64) http.Handler {
+	return &uploadCreateServer{service, links, maxSize}
+}
+
+type uploadCreateServer struct {
+	service media.UploadService
+	links   *LinkBuilder
+	maxSize int64
+}
+
+func (u *uploadCreateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	length, err := parseUploadInt(r.Header.Get("Upload-Length"))
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the upload length", "error", err)
+		jsonError(w, "invalid upload length", http.StatusBadRequest)
+		return
+	}
+
+	if u.maxSize > 0 && length > u.maxSize {
+		jsonError(w, "upload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the upload metadata", "error", err)
+		jsonError(w, "invalid upload metadata", http.StatusBadRequest)
+		return
+	}
+
+	upload, err := u.service.Start(ctx, toUpload(length, metadata))
+	if err != nil {
+		logError(r, "could not start the upload", err)
+		jsonError(w, errorMessage(err, "upload creation failed"), toHttpCode(err))
+		return
+	}
+
+	w.Header().Set("Location", u.links.URL(r, "/uploads/"+upload.ID))
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// toUpload describes the media to be created from the metadata of the upload.
+// Same as for the regular creation, the name and mimetype are guessed from
+// the filename if not provided.
+func toUpload(length int64, metadata map[string]string) media.Upload {
+	upload := media.Upload{
+		Length:   length,
+		Name:     metadata["name"],
+		Mimetype: metadata["filetype"],
+		Metadata: metadata,
+	}
+
+	if upload.Name == "" {
+		upload.Name = metadata["filename"]
+	}
+
+	if upload.Mimetype == "" {
+		upload.Mimetype = mime.TypeByExtension(filepath.Ext(metadata["filename"]))
+	}
+
+	if upload.Mimetype == "" {
+		upload.Mimetype = "application/octet-stream"
+	}
+
+	for _, tag := range strings.Split(metadata["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			upload.Tags = append(upload.Tags, tag)
+		}
+	}
+
+	return upload
+}
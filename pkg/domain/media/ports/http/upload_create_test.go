@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services"
+)
+
+func newUploadService() media.UploadService {
+	medias := services.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	return services.NewUploadService(adapters.NewFakeUploadRepository(), medias, time.Hour)
+}
+
+func newTusRequest(ctx context.Context, method string, target string, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+	r.Header.Set("Tus-Resumable", tusVersion)
+
+	return r
+}
+
+func TestUploadCreate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := newUploadService()
+	server := NewUploadCreateHTTPServer(service, unsignedLinks, 100)
+
+	encode := func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	testCases := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "unsupported version",
+			headers:  map[string]string{"Tus-Resumable": "0.2.2", "Upload-Length": "10"},
+			expected: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "missing length",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "too large",
+			headers:  map[string]string{"Upload-Length": "101"},
+			expected: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "invalid metadata",
+			headers:  map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename !!!"},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "created",
+			headers: map[string]string{
+				"Upload-Length":   "10",
+				"Upload-Metadata": "filename " + encode("foo.png") + ",tags " + encode("tag-1, tag-2") + ",is_confidential",
+			},
+			expected: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTusRequest(ctx, "POST", "/uploads", "")
+			for header, value := range tc.headers {
+				r.Header.Set(header, value)
+			}
+
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+			resp := w.Result()
+
+			if resp.StatusCode != tc.expected {
+				t.Fatalf("expected a %d, got %d", tc.expected, resp.StatusCode)
+			}
+
+			if resp.Header.Get("Tus-Resumable") != tusVersion {
+				t.Errorf("expected the Tus-Resumable header to be %q, got %q", tusVersion, resp.Header.Get("Tus-Resumable"))
+			}
+
+			if resp.StatusCode != http.StatusCreated {
+				return
+			}
+
+			location := resp.Header.Get("Location")
+			prefix := "http://example.com/uploads/"
+			if !strings.HasPrefix(location, prefix) {
+				t.Fatalf("expected the location to start with %q, got %q", prefix, location)
+			}
+
+			upload, err := service.Get(ctx, strings.TrimPrefix(location, prefix))
+			if err != nil {
+				t.Fatalf("expected the upload to exist, got %s", err)
+			}
+
+			if upload.Name != "foo.png" || upload.Mimetype != "image/png" || len(upload.Tags) != 2 {
+				t.Errorf("expected the upload to be described by its metadata, got %+v", upload)
+			}
+
+			if _, exists := upload.Metadata["is_confidential"]; !exists {
+				t.Errorf("expected the metadata without value to be kept, got %v", upload.Metadata)
+			}
+
+			if _, err := http.ParseTime(resp.Header.Get("Upload-Expires")); err != nil {
+				t.Errorf("expected a valid Upload-Expires header, got %q", resp.Header.Get("Upload-Expires"))
+			}
+		})
+	}
+}
+
+func TestUploadOptions(t *testing.T) {
+	server := NewUploadOptionsHTTPServer(100)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/uploads", nil))
+	resp := w.Result()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected a 204, got %d", resp.StatusCode)
+	}
+
+	expected := map[string]string{
+		"Tus-Version":   tusVersion,
+		"Tus-Extension": tusExtensions,
+		"Tus-Max-Size":  "100",
+	}
+
+	for header, value := range expected {
+		if resp.Header.Get(header) != value {
+			t.Errorf("expected the %s header to be %q, got %q", header, value, resp.Header.Get(header))
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewUploadOffsetHTTPServer(service media.UploadService) http.Handler {
+	return &uploadOffsetServer{service}
+}
+
+type uploadOffsetServer struct {
+	service media.UploadService
+}
+
+func (u *uploadOffsetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	upload, err := u.service.Get(ctx, r.PathValue("id"))
+	if err != nil {
+		logError(r, "error while trying to fetch upload", err)
+		jsonError(w, "upload not found", toHttpCode(err))
+		return
+	}
+
+	uploadHeaders(w, upload)
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if len(upload.Metadata) > 0 {
+		w.Header().Set("Upload-Metadata", formatUploadMetadata(upload.Metadata))
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+)
+
+func NewUploadOptionsHTTPServer(maxSize int64) http.Handler {
+	return &uploadOptionsServer{maxSize}
+}
+
+type uploadOptionsServer struct {
+	maxSize int64
+}
+
+// ServeHTTP lets the clients discover what the server supports. As per the
+// protocol, the Tus-Resumable header is not required for this request.
+func (u *uploadOptionsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+
+	if u.maxSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(u.maxSize, 10))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+func NewUploadPatchHTTPServer(service media.UploadService) http.Handler {
+	return &uploadPatchServer{service}
+}
+
+type uploadPatchServer struct {
+	service media.UploadService
+}
+
+func (u *uploadPatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != tusContentType {
+		jsonError(w, "invalid content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := parseUploadInt(r.Header.Get("Upload-Offset"))
+	if err != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not parse the upload offset", "error", err)
+		jsonError(w, "invalid upload offset", http.StatusBadRequest)
+		return
+	}
+
+	upload, err := u.service.Get(ctx, id)
+	if err != nil {
+		logError(r, "error while trying to fetch upload", err)
+		jsonError(w, "upload not found", toHttpCode(err))
+		return
+	}
+
+	if upload.Offset != offset {
+		jsonError(w, "offset mismatch", http.StatusConflict)
+		return
+	}
+
+	// reads one more byte than expected to detect chunks too large. If the
+	// connection is interrupted, what was received is still kept, so that the
+	// client can resume from there.
+	remaining := upload.Length - upload.Offset
+	chunk, readErr := io.ReadAll(io.LimitReader(r.Body, remaining+1))
+	if readErr != nil {
+		logging.LoggerFrom(r.Context()).Warn("could not read the whole chunk", "error", readErr)
+	}
+
+	if int64(len(chunk)) > remaining {
+		jsonError(w, "upload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	upload, err = u.service.Append(ctx, id, offset, chunk)
+	if err != nil {
+		logError(r, "could not append the chunk", err)
+		jsonError(w, "upload failed", toHttpCode(err))
+		return
+	}
+
+	if readErr != nil {
+		jsonError(w, "chunk not readable", http.StatusBadRequest)
+		return
+	}
+
+	uploadHeaders(w, upload)
+	w.WriteHeader(http.StatusNoContent)
+}
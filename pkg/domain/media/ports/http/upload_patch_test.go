@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestUploadPatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := newUploadService()
+	patchServer := NewUploadPatchHTTPServer(service)
+	offsetServer := NewUploadOffsetHTTPServer(service)
+
+	upload, _ := service.Start(ctx, media.Upload{Length: 10, Name: "foo", Mimetype: "text/plain"})
+
+	patch := func(offset string, contentType string, body string) *http.Response {
+		r := newTusRequest(ctx, "PATCH", "/uploads/"+upload.ID, body)
+		r.SetPathValue("id", upload.ID)
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("Upload-Offset", offset)
+
+		w := httptest.NewRecorder()
+		patchServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	head := func() *http.Response {
+		r := newTusRequest(ctx, "HEAD", "/uploads/"+upload.ID, "")
+		r.SetPathValue("id", upload.ID)
+
+		w := httptest.NewRecorder()
+		offsetServer.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	t.Run("invalid content type", func(t *testing.T) {
+		if resp := patch("0", "text/plain", "hello"); resp.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("expected a 415, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("first chunk", func(t *testing.T) {
+		resp := patch("0", tusContentType, "hello")
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("expected a 204, got %d", resp.StatusCode)
+		}
+
+		if resp.Header.Get("Upload-Offset") != "5" {
+			t.Errorf("expected the offset to be %q, got %q", "5", resp.Header.Get("Upload-Offset"))
+		}
+
+		if resp.Header.Get("Media-Id") != "" {
+			t.Errorf("expected no media to be created yet, got %q", resp.Header.Get("Media-Id"))
+		}
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		resp := head()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected a 200, got %d", resp.StatusCode)
+		}
+
+		if resp.Header.Get("Upload-Offset") != "5" || resp.Header.Get("Upload-Length") != "10" {
+			t.Errorf("expected to be at 5 out of 10 bytes, got %q out of %q", resp.Header.Get("Upload-Offset"), resp.Header.Get("Upload-Length"))
+		}
+
+		if resp.Header.Get("Cache-Control") != "no-store" {
+			t.Errorf("expected the offset not to be cached, got %q", resp.Header.Get("Cache-Control"))
+		}
+	})
+
+	t.Run("offset mismatch", func(t *testing.T) {
+		if resp := patch("0", tusContentType, "hello"); resp.StatusCode != http.StatusConflict {
+			t.Errorf("expected a 409, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("chunk too large", func(t *testing.T) {
+		if resp := patch("5", tusContentType, "world!"); resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected a 413, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("last chunk", func(t *testing.T) {
+		resp := patch("5", tusContentType, "world")
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("expected a 204, got %d", resp.StatusCode)
+		}
+
+		if resp.Header.Get("Media-Id") == "" {
+			t.Fatalf("expected a media to be created")
+		}
+
+		if head().Header.Get("Media-Id") != resp.Header.Get("Media-Id") {
+			t.Errorf("expected the created media to be linked to the upload")
+		}
+	})
+
+	t.Run("upload not found", func(t *testing.T) {
+		r := newTusRequest(ctx, "PATCH", "/uploads/oops", "hello")
+		r.SetPathValue("id", "oops")
+		r.Header.Set("Content-Type", tusContentType)
+		r.Header.Set("Upload-Offset", "0")
+
+		w := httptest.NewRecorder()
+		patchServer.ServeHTTP(w, r)
+
+		if resp := w.Result(); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected a 404, got %d", resp.StatusCode)
+		}
+	})
+}
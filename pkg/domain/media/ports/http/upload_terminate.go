@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func NewUploadTerminateHTTPServer(service media.UploadService) http.Handler {
+	return &uploadTerminateServer{service}
+}
+
+type uploadTerminateServer struct {
+	service media.UploadService
+}
+
+func (u *uploadTerminateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	if err := u.service.Terminate(ctx, r.PathValue("id")); err != nil {
+		logError(r, "could not terminate the upload", err)
+		jsonError(w, "upload not found", toHttpCode(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
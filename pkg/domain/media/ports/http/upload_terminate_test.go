@@ -0,0 +1,38 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media"
+)
+
+func TestUploadTerminate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	service := newUploadService()
+	server := NewUploadTerminateHTTPServer(service)
+
+	upload, _ := service.Start(ctx, media.Upload{Length: 10})
+
+	terminate := func() *http.Response {
+		r := newTusRequest(ctx, "DELETE", "/uploads/"+upload.ID, "")
+		r.SetPathValue("id", upload.ID)
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	if resp := terminate(); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected a 204, got %d", resp.StatusCode)
+	}
+
+	if resp := terminate(); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 once terminated, got %d", resp.StatusCode)
+	}
+}
@@ -3,9 +3,41 @@ package ports
 import "github.com/Taluu/media-go/pkg/domain/media/ports/http"
 
 var (
-	NewHttpTagsList    = http.NewHttpListServer
-	NewHttpTagCreate   = http.NewTagsCreateServer
-	NewHttpMediaSeatch = http.NewMediaSearchHTTPPort
-	NewHttpMediaCreate = http.NewMediaCreateHTTPServer
-	NewHttpMediaViewer = http.NewMediaViewerHTTPServer
+	NewHttpTagsList      = http.NewHttpListServer
+	NewHttpTagCreate     = http.NewTagsCreateServer
+	NewHttpMediaSeatch   = http.NewMediaSearchHTTPPort
+	NewHttpMediaCreate   = http.NewMediaCreateHTTPServer
+	NewHttpMediaBatch    = http.NewMediaBatchHTTPServer
+	NewHttpMediaImport   = http.NewMediaImportHTTPServer
+	NewHttpMediaPrepare  = http.NewMediaPrepareHTTPServer
+	NewHttpMediaComplete = http.NewMediaCompleteHTTPServer
+	NewHttpMediaPresign  = http.NewMediaPresignHTTPServer
+	NewHttpStorage       = http.NewStorageHTTPServer
+
+	NewLinkBuilder       = http.NewLinkBuilder
+	WithPublicURL        = http.WithPublicURL
+	WithTrustedProxies   = http.WithTrustedProxies
+	NewHttpMediaViewer   = http.NewMediaViewerHTTPServer
+	NewHttpMediaMetadata = http.NewMediaMetadataHTTPServer
+	NewHttpMediaSimilar  = http.NewMediaSimilarHTTPServer
+	NewHttpMediaShare    = http.NewMediaShareHTTPServer
+	NewHttpMediaReplace  = http.NewMediaReplaceHTTPServer
+	NewHttpMediaDelete   = http.NewMediaDeleteHTTPServer
+	NewHttpQuota         = http.NewQuotaHTTPServer
+
+	NewHttpUploadOptions   = http.NewUploadOptionsHTTPServer
+	NewHttpUploadCreate    = http.NewUploadCreateHTTPServer
+	NewHttpUploadOffset    = http.NewUploadOffsetHTTPServer
+	NewHttpUploadPatch     = http.NewUploadPatchHTTPServer
+	NewHttpUploadTerminate = http.NewUploadTerminateHTTPServer
+
+	NewHttpLiveness  = http.NewLivenessHTTPServer
+	NewHttpReadiness = http.NewReadinessHTTPServer
+
+	NewHttpOpenAPI = http.NewOpenAPIHTTPServer
+)
+
+type (
+	LinkOption  = http.LinkOption
+	CachePolicy = http.CachePolicy
 )
@@ -0,0 +1,21 @@
+package media
+
+import (
+	"context"
+	"time"
+)
+
+// PresignedURL lets a client send or get the content of a media directly to
+// or from the storage, until it expires
+type PresignedURL struct {
+	URL       string
+	Method    string
+	ExpiresAt time.Time
+}
+
+// MediaPresigner is an optional capability of a MediaUploader. The returned
+// urls may be relative, if they are served by this application.
+type MediaPresigner interface {
+	PresignUpload(ctx context.Context, mediaID string, expiresAt time.Time) (PresignedURL, error)
+	PresignDownload(ctx context.Context, mediaID string, expiresAt time.Time) (PresignedURL, error)
+}
@@ -0,0 +1,45 @@
+package media
+
+import "context"
+
+// Usage is how much of the storage is used, or can be used
+type Usage struct {
+	Bytes  int64
+	Medias int64
+}
+
+// Exceeds tells whether the usage exceeds the limits, a zero limit meaning
+// there is none.
+func (u Usage) Exceeds(limits Usage) bool {
+	return (limits.Bytes > 0 && u.Bytes > limits.Bytes) || (limits.Medias > 0 && u.Medias > limits.Medias)
+}
+
+// Quota is the usage of the storage by a user or a tenant, and its limits
+type Quota struct {
+	Usage  Usage
+	Limits Usage
+}
+
+type QuotaRepository interface {
+	// Get returns the usage of the given accounts, the unknown ones having
+	// used nothing yet.
+	Get(ctx context.Context, accounts ...string) (map[string]Usage, error)
+	// Add adds the delta to the usage of all the given accounts at once. A
+	// ErrQuotaExceeded is returned, and nothing is changed, if any of them
+	// would then exceed its limits.
+	Add(ctx context.Context, delta Usage, limits map[string]Usage) error
+}
+
+type QuotaService interface {
+	// Get returns the quota of the principal behind the context, nil if it
+	// is anonymous, and the quota of its tenant.
+	Get(ctx context.Context) (user *Quota, tenant Quota, err error)
+	// Check tells whether the owner could store the delta more, without
+	// accounting it yet. A ErrLargerThanQuota is returned if the delta can't
+	// fit in the limits at all, and a ErrQuotaExceeded if there is not
+	// enough space left.
+	Check(ctx context.Context, owner string, delta Usage) error
+	// Charge accounts the delta, which may be negative, for the owner and
+	// the tenant behind the context, failing the same way as Check.
+	Charge(ctx context.Context, owner string, delta Usage) error
+}
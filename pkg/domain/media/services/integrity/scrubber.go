@@ -0,0 +1,63 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewScrubber returns a scrubber verifying the medias of the repository. With
+// several tenants, the repository must not be scoped to one of them, while the
+// uploader is scoped to the tenant of each media.
+func NewScrubber(repository MediaRepository, uploader MediaUploader) IntegrityScrubber {
+	return &scrubber{repository, uploader}
+}
+
+type scrubber struct {
+	repository MediaRepository
+	uploader   MediaUploader
+}
+
+// Scrub implements media.IntegrityScrubber.
+//
+// A media whose file can't be found is considered as corrupted, while the
+// medias without any checksum are skipped.
+func (s *scrubber) Scrub(ctx context.Context) ([]string, error) {
+	medias, err := s.repository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	corrupted := make([]string, 0)
+	for id, media := range medias {
+		if err := ctx.Err(); err != nil {
+			return corrupted, err
+		}
+
+		if media.Checksums.SHA256 == "" {
+			continue
+		}
+
+		content, err := s.uploader.GetContent(auth.WithTenant(ctx, media.Tenant), id)
+		if errors.Is(err, ErrFileNotFound) {
+			corrupted = append(corrupted, id)
+			continue
+		}
+
+		if err != nil {
+			return corrupted, err
+		}
+
+		if !media.Checksums.Verify(content) {
+			corrupted = append(corrupted, id)
+		}
+	}
+
+	slices.Sort(corrupted)
+
+	return corrupted, nil
+}
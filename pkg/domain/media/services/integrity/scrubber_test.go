@@ -0,0 +1,83 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters/uploader/file"
+	"github.com/Taluu/media-go/pkg/domain/media/services/media"
+)
+
+func TestScrub(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	directory := t.TempDir()
+	repository := adapters.NewFakeMediaRepository()
+	uploader := file.NewUploader(directory)
+	service := media.NewMediaService(repository, adapters.NewFakeTagRegistry(), uploader, media.WithMD5Checksums())
+
+	// fixtures
+	service.Create(ctx, "healthy", nil, []byte("healthy content"), "text/plain")
+	corrupted, _, _ := service.Create(ctx, "corrupted", nil, []byte("original content"), "text/plain")
+	missing, _, _ := service.Create(ctx, "missing", nil, []byte("missing content"), "text/plain")
+	repository.Create(ctx, Media{Name: "without checksums", Mimetype: "text/plain"})
+
+	os.WriteFile(filepath.Join(directory, corrupted.ID), []byte("0riginal content"), 0644)
+	os.Remove(filepath.Join(directory, missing.ID))
+
+	found, err := NewScrubber(repository, uploader).Scrub(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error while scrubbing : %s", err)
+	}
+
+	expected := []string{corrupted.ID, missing.ID}
+	slices.Sort(expected)
+
+	if !slices.Equal(found, expected) {
+		t.Errorf("expected %v to be reported as corrupted, got %v", expected, found)
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		if _, err := NewScrubber(repository, uploader).Scrub(ctx); err == nil {
+			t.Errorf("expected an error when the context is cancelled")
+		}
+	})
+}
+
+func TestScrubTenants(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	acme := auth.WithTenant(ctx, "acme")
+
+	directory := t.TempDir()
+	repository := adapters.NewFakeMediaRepository()
+	uploader := adapters.NewTenantUploader(file.NewUploader(directory))
+	service := media.NewMediaService(adapters.NewTenantMediaRepository(repository), adapters.NewFakeTagRegistry(), uploader)
+
+	service.Create(ctx, "healthy", nil, []byte("healthy content"), "text/plain")
+	service.Create(acme, "healthy", nil, []byte("healthy content"), "text/plain")
+	corrupted, _, _ := service.Create(acme, "corrupted", nil, []byte("original content"), "text/plain")
+
+	os.WriteFile(filepath.Join(directory, "acme", corrupted.ID), []byte("0riginal content"), 0644)
+
+	found, err := NewScrubber(repository, uploader).Scrub(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error while scrubbing : %s", err)
+	}
+
+	if !slices.Equal(found, []string{corrupted.ID}) {
+		t.Errorf("expected %v to be reported as corrupted, got %v", []string{corrupted.ID}, found)
+	}
+}
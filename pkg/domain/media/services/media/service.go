@@ -1,66 +1,740 @@
 package media
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"fmt"
 	"maps"
 	"slices"
+	"time"
 
+	"github.com/Taluu/media-go/pkg/auth"
 	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
 	"golang.org/x/sync/errgroup"
 )
 
-func NewMediaService(repository MediaRepository, tagRegistry TagRegistry, uploader MediaUploader) MediaService {
-	return &service{repository, tagRegistry, uploader}
+// purgeTimeout bounds the purges, which are not bound by the requests anymore
+const purgeTimeout = 30 * time.Second
+
+func NewMediaService(repository MediaRepository, tagRegistry TagRegistry, uploader MediaUploader, options ...Option) MediaService {
+	s := &service{
+		MediaRepository:   repository,
+		tags:              tagRegistry,
+		uploader:          uploader,
+		presignExpiration: 15 * time.Minute,
+		visibility:        VisibilityPublic,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// Option configures the optional features of the service
+type Option func(*service)
+
+// WithSanitizer rewrites the content of the medias before they are uploaded.
+func WithSanitizer(sanitizer MediaSanitizer) Option {
+	return func(s *service) {
+		s.sanitizer = sanitizer
+	}
+}
+
+// WithProber probes the technical properties of the medias when they are
+// created.
+func WithProber(prober MediaProber) Option {
+	return func(s *service) {
+		s.prober = prober
+	}
+}
+
+// WithPlaceholders generates the placeholders of the images when they are
+// created.
+func WithPlaceholders(generator PlaceholderGenerator) Option {
+	return func(s *service) {
+		s.placeholders = generator
+	}
+}
+
+// WithPerceptualHashes computes the perceptual hashes of the images when
+// they are created, and indexes them so that similar medias can be searched.
+func WithPerceptualHashes(hasher PerceptualHasher, index SimilarityIndex) Option {
+	return func(s *service) {
+		s.hasher = hasher
+		s.similarities = index
+	}
+}
+
+// WithMD5Checksums also computes the MD5 checksum of the medias when they are
+// created, the SHA-256 one being always computed.
+func WithMD5Checksums() Option {
+	return func(s *service) {
+		s.md5 = true
+	}
+}
+
+// WithPresignExpiration sets how long the presigned urls are valid, 15
+// minutes by default.
+func WithPresignExpiration(expiration time.Duration) Option {
+	return func(s *service) {
+		s.presignExpiration = expiration
+	}
+}
+
+// WithDefaultVisibility sets the visibility of the medias created by an
+// authenticated user, public by default. The medias created anonymously are
+// always public, as nobody would own them.
+func WithDefaultVisibility(visibility Visibility) Option {
+	return func(s *service) {
+		s.visibility = visibility
+	}
+}
+
+// WithPolicy checks that the principal behind the context is granted the
+// permission of each operation, which is granted to everybody otherwise. The
+// tags of a new media are then only created if it may create tags.
+func WithPolicy(policy *auth.Policy) Option {
+	return func(s *service) {
+		s.policy = policy
+	}
+}
+
+// WithQuotas accounts the storage used by the medias of each user and tenant,
+// rejecting the medias that would exceed their quotas.
+func WithQuotas(quotas QuotaService) Option {
+	return func(s *service) {
+		s.quotas = quotas
+	}
+}
+
+// WithCachePurger tells the caches of the medias to forget them when they are
+// replaced, deleted or not public anymore.
+func WithCachePurger(purger CachePurger) Option {
+	return func(s *service) {
+		s.purger = purger
+	}
 }
 
 type service struct {
 	MediaRepository
-	tags     TagRegistry
-	uploader MediaUploader
+	tags         TagRegistry
+	uploader     MediaUploader
+	sanitizer    MediaSanitizer
+	prober       MediaProber
+	placeholders PlaceholderGenerator
+	hasher       PerceptualHasher
+	similarities SimilarityIndex
+	md5          bool
+
+	presignExpiration time.Duration
+	visibility        Visibility
+	policy            *auth.Policy
+	quotas            QuotaService
+	purger            CachePurger
 }
 
-// View implements media.MediaService.
-func (s *service) View(ctx context.Context, id string) (fileContent []byte, mimetype string, err error) {
+// canView tells whether the principal behind the context can see the media,
+// or whether the context grants its view
+func canView(ctx context.Context, media Media) bool {
+	if granted, ok := GrantFrom(ctx); ok && granted == media.ID {
+		return true
+	}
+
+	principal, authenticated := auth.PrincipalFrom(ctx)
+	return media.Access.CanView(principal, authenticated)
+}
+
+// get returns a media the principal behind the context can see. The medias
+// it can't see are not found, so that their existence is not disclosed, and
+// neither are the pending ones, whose content was not checked yet.
+func (s *service) get(ctx context.Context, id string) (Media, error) {
+	media, err := s.find(ctx, id)
+	if err != nil {
+		return Media{}, err
+	}
+
+	if media.Pending {
+		return Media{}, MediaNotFound(id)
+	}
+
+	return media, nil
+}
+
+// find returns a media the principal behind the context can see, even if it
+// is pending
+func (s *service) find(ctx context.Context, id string) (Media, error) {
 	medias, err := s.GetByIDs(ctx, id)
 	if err != nil {
+		return Media{}, err
+	}
+
+	media, exists := medias[id]
+	if !exists || !canView(ctx, media) {
+		return Media{}, MediaNotFound(id)
+	}
+
+	return media, nil
+}
+
+// getEditable returns a media the principal behind the context can change,
+// even if it is pending
+func (s *service) getEditable(ctx context.Context, id string) (Media, error) {
+	media, err := s.find(ctx, id)
+	if err != nil {
+		return Media{}, err
+	}
+
+	principal, authenticated := auth.PrincipalFrom(ctx)
+	if !media.Access.CanEdit(principal, authenticated) {
+		return Media{}, MediaNotFound(id)
+	}
+
+	return media, nil
+}
+
+// Get implements media.MediaService.
+func (s *service) Get(ctx context.Context, id string) (Media, []Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionViewMedias); err != nil {
+		return Media{}, nil, err
+	}
+
+	media, err := s.get(ctx, id)
+	if err != nil {
+		return Media{}, nil, err
+	}
+
+	tags, err := s.tags.GetTagsForMedias(ctx, id)
+	return media, tags[id], err
+}
+
+// View implements media.MediaService.
+func (s *service) View(ctx context.Context, id string) (media Media, fileContent []byte, err error) {
+	if err = s.policy.Authorize(ctx, auth.PermissionViewMedias); err != nil {
 		return
 	}
 
-	if _, exists := medias[id]; !exists {
-		err = MediaNotFound(id)
+	media, err = s.get(ctx, id)
+	if err != nil {
 		return
 	}
 
-	uploadedContent, err := s.uploader.GetContent(ctx, id)
-	return uploadedContent, medias[id].Mimetype, err
+	fileContent, err = s.uploader.GetContent(ctx, id)
+	return
 }
 
 // Create implements media.MediaService.
 // Subtle: this method shadows the method (MediaRepository).Create of service.MediaRepository.
 func (s *service) Create(ctx context.Context, name string, tags []string, fileContent []byte, mimetype string) (Media, []Tag, error) {
-	media, err := s.MediaRepository.Create(ctx, name, mimetype)
+	if err := s.policy.Authorize(ctx, auth.PermissionCreateMedias); err != nil {
+		return Media{}, nil, err
+	}
+
+	// rejects what would exceed the quotas before creating anything
+	if s.quotas != nil {
+		if err := s.quotas.Check(ctx, owner(ctx), Usage{Bytes: int64(len(fileContent)), Medias: 1}); err != nil {
+			return Media{}, nil, err
+		}
+	}
+
+	created, tagsSlice, err := s.create(ctx, name, tags, mimetype, false)
 	if err != nil {
 		return Media{}, nil, err
 	}
 
-	tagsSlice := make([]Tag, 0, len(tags))
+	media, err := s.store(ctx, created, fileContent, false)
+	if err != nil {
+		// a media can't be kept without its content, nor its content without
+		// the media
+		if err := s.remove(ctx, created); err != nil {
+			logging.LoggerFrom(ctx).Error("could not remove the media without content", "media_id", created.ID, "error", err)
+		}
 
+		if err := s.uploader.Delete(ctx, created.ID); err != nil {
+			logging.LoggerFrom(ctx).Error("could not delete the content without media", "media_id", created.ID, "error", err)
+		}
+
+		return Media{}, nil, err
+	}
+
+	logging.LoggerFrom(ctx).Info("media created", "media_id", media.ID, "size", media.Size)
+	return media, tagsSlice, nil
+}
+
+// owner returns who owns the medias created by the principal behind the
+// context, nobody if it is anonymous
+func owner(ctx context.Context) string {
+	principal, _ := auth.PrincipalFrom(ctx)
+	return principal.ID
+}
+
+// charge accounts the delta in the quotas of the owner, if they are enabled
+func (s *service) charge(ctx context.Context, owner string, delta Usage) error {
+	if s.quotas == nil {
+		return nil
+	}
+
+	return s.quotas.Charge(ctx, owner, delta)
+}
+
+// refund gives back what was charged for an operation that failed
+func (s *service) refund(ctx context.Context, owner string, charged Usage) {
+	refund := Usage{Bytes: -charged.Bytes, Medias: -charged.Medias}
+	if err := s.charge(ctx, owner, refund); err != nil {
+		logging.LoggerFrom(ctx).Error("could not refund the quotas", "owner", owner, "bytes", refund.Bytes, "medias", refund.Medias, "error", err)
+	}
+}
+
+// create creates a media, without any content yet, owned by the principal
+// behind the context. Its access is stored along with it, so that it is never
+// seen by anybody else, and whatever can fail is done before.
+func (s *service) create(ctx context.Context, name string, tags []string, mimetype string, pending bool) (Media, []Tag, error) {
+	// without the permission to create tags, only the existing ones are
+	// linked
+	var existing map[string]Tag
+	if s.policy.Authorize(ctx, auth.PermissionCreateTags) != nil {
+		var err error
+		if existing, err = s.tags.GetAll(ctx); err != nil {
+			return Media{}, nil, err
+		}
+	}
+
+	if err := s.charge(ctx, owner(ctx), Usage{Medias: 1}); err != nil {
+		return Media{}, nil, err
+	}
+
+	media := Media{Name: name, Mimetype: mimetype, Access: Access{Visibility: VisibilityPublic}, Pending: pending}
+	if _, authenticated := auth.PrincipalFrom(ctx); authenticated {
+		media.Access = Access{Owner: owner(ctx), Visibility: s.visibility}
+	}
+
+	media, err := s.MediaRepository.Create(ctx, media)
+	if err != nil {
+		s.refund(ctx, owner(ctx), Usage{Medias: 1})
+		return Media{}, nil, err
+	}
+
+	tagsSlice := make([]Tag, 0, len(tags))
 	for _, tag := range tags {
-		// silently ignores if this fails
+		if _, exists := existing[tag]; existing != nil && !exists {
+			continue
+		}
+
+		// only logs if this fails
 		// the rationale behind this is "tags are not that important for medias,
 		// so it's okay if it doesn't add them" and also "if it doesn't exist,
 		// then let's create it"
-		if err := s.tags.Link(ctx, tag, media.ID); err == nil {
-			tagsSlice = append(tagsSlice, Tag{Name: tag})
+		if err := s.tags.Link(ctx, tag, media.ID); err != nil {
+			logging.LoggerFrom(ctx).Warn("could not link the tag", "media_id", media.ID, "tag", tag, "error", err)
+			continue
+		}
+
+		tagsSlice = append(tagsSlice, Tag{Name: tag})
+	}
+
+	return media, tagsSlice, nil
+}
+
+// store computes what depends on the content of a media, and uploads it
+// unless it is already stored as is. The difference with the size of its
+// previous content is accounted in the quotas of its owner.
+//
+// The media is only updated once its content is uploaded, so that it never
+// describes a content that is not stored.
+func (s *service) store(ctx context.Context, media Media, fileContent []byte, stored bool) (_ Media, err error) {
+	// everything is computed from what is actually stored
+	if s.sanitizer != nil {
+		sanitized, err := s.sanitizer.Sanitize(ctx, media.ID, fileContent)
+		if err != nil {
+			return Media{}, err
+		}
+
+		stored = stored && bytes.Equal(sanitized, fileContent)
+		fileContent = sanitized
+	}
+
+	delta := Usage{Bytes: int64(len(fileContent)) - media.Size}
+	if err := s.charge(ctx, media.Access.Owner, delta); err != nil {
+		return Media{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.refund(ctx, media.Access.Owner, delta)
+		}
+	}()
+
+	previous := media
+
+	// nothing computed from the previous content is relevant anymore
+	media.Size += delta.Bytes
+	media.Checksums = ComputeChecksums(fileContent, s.md5)
+	media.Pending = false
+	media.Properties = Properties{}
+	media.Placeholder = Placeholder{}
+	media.PerceptualHash = nil
+	s.describe(ctx, &media, fileContent)
+
+	if !stored {
+		if err := s.uploader.Upload(ctx, media.ID, fileContent); err != nil {
+			return Media{}, err
+		}
+	}
+
+	if err := s.MediaRepository.Update(ctx, media); err != nil {
+		return Media{}, err
+	}
+
+	s.unindex(ctx, previous)
+
+	if s.similarities != nil && media.PerceptualHash != nil {
+		// not being able to find this media among the similar ones is not a
+		// reason to fail its creation
+		if err := s.similarities.Add(ctx, media.ID, *media.PerceptualHash); err != nil {
+			logging.LoggerFrom(ctx).Warn("could not index the media", "media_id", media.ID, "error", err)
+		}
+	}
+
+	return media, nil
+}
+
+// Prepare implements media.MediaService.
+func (s *service) Prepare(ctx context.Context, name string, tags []string, mimetype string, size int64) (Media, []Tag, PresignedURL, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionCreateMedias); err != nil {
+		return Media{}, nil, PresignedURL{}, err
+	}
+
+	presigner, ok := s.uploader.(MediaPresigner)
+	if !ok {
+		return Media{}, nil, PresignedURL{}, ErrPresignUnsupported
+	}
+
+	// as for the regular creation, but with the declared size : the actual
+	// size is only accounted once the media is completed
+	if s.quotas != nil {
+		if err := s.quotas.Check(ctx, owner(ctx), Usage{Bytes: size, Medias: 1}); err != nil {
+			return Media{}, nil, PresignedURL{}, err
+		}
+	}
+
+	// the content being sent later, the media is pending until it is
+	// completed
+	media, tagsSlice, err := s.create(ctx, name, tags, mimetype, true)
+	if err != nil {
+		return Media{}, nil, PresignedURL{}, err
+	}
+
+	url, err := presigner.PresignUpload(ctx, media.ID, time.Now().Add(s.presignExpiration))
+	if err != nil {
+		if err := s.remove(ctx, media); err != nil {
+			logging.LoggerFrom(ctx).Error("could not remove the media without upload url", "media_id", media.ID, "error", err)
 		}
+
+		return Media{}, nil, PresignedURL{}, err
+	}
+
+	return media, tagsSlice, url, nil
+}
+
+// Complete implements media.MediaService.
+func (s *service) Complete(ctx context.Context, id string) (Media, []Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionCreateMedias); err != nil {
+		return Media{}, nil, err
+	}
+
+	media, err := s.getEditable(ctx, id)
+	if err != nil {
+		return Media{}, nil, err
+	}
+
+	// its content can't be changed anymore once completed, so there is
+	// nothing more to do
+	if media.Pending {
+		fileContent, err := s.uploader.GetContent(ctx, id)
+		if err != nil {
+			return Media{}, nil, err
+		}
+
+		media, err = s.store(ctx, media, fileContent, true)
+		if err != nil {
+			// what was rejected must not stay in the storage, nor be served
+			if err := s.uploader.Delete(ctx, id); err != nil {
+				logging.LoggerFrom(ctx).Error("could not delete the rejected content", "media_id", id, "error", err)
+			}
+
+			return Media{}, nil, err
+		}
+
+		logging.LoggerFrom(ctx).Info("media completed", "media_id", media.ID, "size", media.Size)
 	}
 
-	err = s.uploader.Upload(ctx, media.ID, fileContent)
+	tags, err := s.tags.GetTagsForMedias(ctx, id)
+	return media, tags[id], err
+}
+
+// Presign implements media.MediaService.
+func (s *service) Presign(ctx context.Context, id string) (PresignedURL, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionViewMedias); err != nil {
+		return PresignedURL{}, err
+	}
+
+	presigner, ok := s.uploader.(MediaPresigner)
+	if !ok {
+		return PresignedURL{}, ErrPresignUnsupported
+	}
 
-	return media, tagsSlice, err
+	if _, err := s.get(ctx, id); err != nil {
+		return PresignedURL{}, err
+	}
+
+	return presigner.PresignDownload(ctx, id, time.Now().Add(s.presignExpiration))
+}
+
+// Share implements media.MediaService.
+func (s *service) Share(ctx context.Context, id string, visibility Visibility, users []string, groups []string) (Media, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionShareMedias); err != nil {
+		return Media{}, err
+	}
+
+	if !visibility.Valid() {
+		return Media{}, InvalidVisibility(visibility)
+	}
+
+	media, err := s.getEditable(ctx, id)
+	if err != nil {
+		return Media{}, err
+	}
+
+	// nobody could see it anymore
+	if media.Access.Owner == "" && visibility != VisibilityPublic {
+		return Media{}, fmt.Errorf("%w : medias created anonymously stay public", InvalidVisibility(visibility))
+	}
+
+	wasPublic := media.Access.IsPublic()
+
+	media.Access.Visibility = visibility
+	media.Access.Users = users
+	media.Access.Groups = groups
+
+	if err := s.MediaRepository.Update(ctx, media); err != nil {
+		return Media{}, err
+	}
+
+	if wasPublic && !media.Access.IsPublic() {
+		s.purge(ctx, media, PurgeRestricted)
+	}
+
+	return media, nil
+}
+
+// Replace implements media.MediaService.
+func (s *service) Replace(ctx context.Context, id string, fileContent []byte, mimetype string) (Media, []Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionCreateMedias); err != nil {
+		return Media{}, nil, err
+	}
+
+	media, err := s.getEditable(ctx, id)
+	if err != nil {
+		return Media{}, nil, err
+	}
+
+	media.Mimetype = cmp.Or(mimetype, media.Mimetype)
+
+	media, err = s.store(ctx, media, fileContent, false)
+	if err != nil {
+		return Media{}, nil, err
+	}
+
+	logging.LoggerFrom(ctx).Info("media replaced", "media_id", media.ID, "size", media.Size)
+	s.purge(ctx, media, PurgeReplaced)
+
+	tags, err := s.tags.GetTagsForMedias(ctx, id)
+	return media, tags[id], err
+}
+
+// Delete implements media.MediaService.
+// Subtle: this method shadows the method (MediaRepository).Delete of service.MediaRepository.
+func (s *service) Delete(ctx context.Context, id string) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionDeleteMedias); err != nil {
+		return err
+	}
+
+	media, err := s.getEditable(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.remove(ctx, media); err != nil {
+		return err
+	}
+
+	logging.LoggerFrom(ctx).Info("media deleted", "media_id", id, "size", media.Size)
+
+	err = s.uploader.Delete(ctx, id)
+	s.purge(ctx, media, PurgeDeleted)
+
+	return err
+}
+
+// remove removes a media, and frees its space in the quotas of its owner
+func (s *service) remove(ctx context.Context, media Media) error {
+	if err := s.MediaRepository.Delete(ctx, media.ID); err != nil {
+		return err
+	}
+
+	s.unindex(ctx, media)
+	s.unlink(ctx, media)
+
+	return s.charge(ctx, media.Access.Owner, Usage{Bytes: -media.Size, Medias: -1})
+}
+
+// purge tells the caches to forget a media, whose changes are kept even if
+// they fail to, until the cached content expires.
+//
+// The caches are told in the background, so that a slow one doesn't slow the
+// request down, nor is interrupted when the request ends.
+func (s *service) purge(ctx context.Context, media Media, event PurgeEvent) {
+	if s.purger == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), purgeTimeout)
+
+	go func() {
+		defer cancel()
+
+		if err := s.purger.Purge(ctx, media, event); err != nil {
+			logging.LoggerFrom(ctx).Warn("could not purge the media from the caches", "media_id", media.ID, "event", event, "error", err)
+		}
+	}()
+}
+
+// unindex removes a media from the similar ones, which is not a reason to
+// fail its removal
+func (s *service) unindex(ctx context.Context, media Media) {
+	if s.similarities == nil || media.PerceptualHash == nil {
+		return
+	}
+
+	if err := s.similarities.Remove(ctx, media.ID, *media.PerceptualHash); err != nil {
+		logging.LoggerFrom(ctx).Warn("could not unindex the media", "media_id", media.ID, "error", err)
+	}
+}
+
+// unlink removes a media from its tags, which, as linking them, is not a
+// reason to fail its removal
+func (s *service) unlink(ctx context.Context, media Media) {
+	tags, err := s.tags.GetTagsForMedias(ctx, media.ID)
+	if err != nil {
+		logging.LoggerFrom(ctx).Warn("could not get the tags to unlink", "media_id", media.ID, "error", err)
+		return
+	}
+
+	for _, tag := range tags[media.ID] {
+		if err := s.tags.Unlink(ctx, tag.Name, media.ID); err != nil {
+			logging.LoggerFrom(ctx).Warn("could not unlink the tag", "media_id", media.ID, "tag", tag.Name, "error", err)
+		}
+	}
+}
+
+// describe fills what can be computed from the content of a media.
+//
+// Same as tags, this is a nice to have : a media that can't be described is
+// still a valid media, so failures are only logged, as they are expected for
+// the mimetypes the adapters don't handle.
+func (s *service) describe(ctx context.Context, media *Media, fileContent []byte) {
+	logger := logging.LoggerFrom(ctx).With("media_id", media.ID, "mimetype", media.Mimetype)
+
+	if s.prober != nil {
+		if properties, err := s.prober.Probe(ctx, media.Mimetype, fileContent); err == nil {
+			media.Properties = properties
+		} else {
+			logger.Debug("could not probe the media", "error", err)
+		}
+	}
+
+	if s.placeholders != nil {
+		if placeholder, err := s.placeholders.Generate(ctx, media.Mimetype, fileContent); err == nil {
+			media.Placeholder = placeholder
+		} else {
+			logger.Debug("could not generate the placeholder", "error", err)
+		}
+	}
+
+	if s.hasher != nil {
+		if hash, err := s.hasher.Hash(ctx, media.Mimetype, fileContent); err == nil {
+			media.PerceptualHash = &hash
+		} else {
+			logger.Debug("could not hash the media", "error", err)
+		}
+	}
+}
+
+// Similar implements media.MediaService.
+func (s *service) Similar(ctx context.Context, id string, maxDistance int) ([]SimilarMedia, map[string][]Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionViewMedias); err != nil {
+		return nil, nil, err
+	}
+
+	reference, err := s.get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	similarSlice := make([]SimilarMedia, 0)
+	tags := make(map[string][]Tag)
+
+	if s.similarities == nil || reference.PerceptualHash == nil {
+		return similarSlice, tags, nil
+	}
+
+	distances, err := s.similarities.Search(ctx, *reference.PerceptualHash, maxDistance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delete(distances, id)
+	mediaIds := slices.Collect(maps.Keys(distances))
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.Go(func() (err error) {
+		medias, err := s.GetByIDs(ctx, mediaIds...)
+		for _, media := range medias {
+			if canView(ctx, media) {
+				similarSlice = append(similarSlice, SimilarMedia{Media: media, Distance: distances[media.ID]})
+			}
+		}
+
+		return
+	})
+
+	group.Go(func() (err error) {
+		tags, err = s.tags.GetTagsForMedias(ctx, mediaIds...)
+		return
+	})
+
+	err = group.Wait()
+
+	// the tags of the medias that can't be seen are not disclosed either
+	maps.DeleteFunc(tags, func(id string, _ []Tag) bool {
+		return !slices.ContainsFunc(similarSlice, func(media SimilarMedia) bool { return media.ID == id })
+	})
+
+	slices.SortFunc(similarSlice, func(a, b SimilarMedia) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), cmp.Compare(a.ID, b.ID))
+	})
+
+	return similarSlice, tags, err
 }
 
 func (s *service) SearchByTag(ctx context.Context, tagName string) ([]Media, map[string][]Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionViewMedias); err != nil {
+		return nil, nil, err
+	}
+
 	mediaIds, err := s.tags.GetMediaIDsForTag(ctx, tagName)
 	if err != nil {
 		return nil, nil, err
@@ -73,6 +747,7 @@ func (s *service) SearchByTag(ctx context.Context, tagName string) ([]Media, map
 	group.Go(func() (err error) {
 		medias, err := s.GetByIDs(ctx, mediaIds...)
 		mediasSlice = slices.Collect(maps.Values(medias))
+		mediasSlice = slices.DeleteFunc(mediasSlice, func(media Media) bool { return media.Pending || !canView(ctx, media) })
 		return
 	})
 
@@ -83,5 +758,10 @@ func (s *service) SearchByTag(ctx context.Context, tagName string) ([]Media, map
 
 	err = group.Wait()
 
+	// the tags of the medias that can't be seen are not disclosed either
+	maps.DeleteFunc(tags, func(id string, _ []Tag) bool {
+		return !slices.ContainsFunc(mediasSlice, func(media Media) bool { return media.ID == id })
+	})
+
 	return mediasSlice, tags, err
 }
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/Taluu/media-go/pkg/auth"
 	"github.com/Taluu/media-go/pkg/domain/media"
 	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services/quota"
+	"github.com/Taluu/media-go/pkg/signature"
 	"github.com/google/uuid"
 )
 
@@ -20,9 +24,9 @@ func TestSearchByTag(t *testing.T) {
 	fakeMediaRepository := adapters.NewFakeMediaRepository()
 
 	// link a few medias
-	media1, _ := fakeMediaRepository.Create(ctx, "media-1", "random/mime")
-	media2, _ := fakeMediaRepository.Create(ctx, "media-2", "random/mime")
-	media3, _ := fakeMediaRepository.Create(ctx, "media-3", "random/mime")
+	media1, _ := fakeMediaRepository.Create(ctx, media.Media{Name: "media-1", Mimetype: "random/mime"})
+	media2, _ := fakeMediaRepository.Create(ctx, media.Media{Name: "media-2", Mimetype: "random/mime"})
+	media3, _ := fakeMediaRepository.Create(ctx, media.Media{Name: "media-3", Mimetype: "random/mime"})
 
 	fakeTagRegistry.Link(ctx, "tag-1", media1.ID)
 	fakeTagRegistry.Link(ctx, "tag-2", media1.ID)
@@ -94,6 +98,270 @@ func TestCreate(t *testing.T) {
 	if err != nil {
 		t.Fatalf("an error occurred while uploading file : %s", err)
 	}
+
+	// sha-256 of "content"
+	if media.Checksums.SHA256 != "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73" {
+		t.Fatalf("expected the sha-256 checksum to be computed, got %q", media.Checksums.SHA256)
+	}
+
+	if media.Checksums.MD5 != "" {
+		t.Fatalf("did not expect the md5 checksum to be computed, got %q", media.Checksums.MD5)
+	}
+}
+
+func TestCreateWithMD5Checksums(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	fakeMediaRepository := adapters.NewFakeMediaRepository()
+	service := NewMediaService(fakeMediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), WithMD5Checksums())
+
+	created, _, err := service.Create(ctx, "media-1", nil, []byte("content"), "random/mime")
+	if err != nil {
+		t.Fatalf("an error ocurred while creating the media : %s", err)
+	}
+
+	medias, _ := fakeMediaRepository.GetByIDs(ctx, created.ID)
+	if medias[created.ID].Checksums.MD5 != "9a0364b9e99bb480dd25e1f0284c8555" {
+		t.Fatalf("expected the md5 checksum to be stored, got %q", medias[created.ID].Checksums.MD5)
+	}
+
+	if !medias[created.ID].Checksums.Verify([]byte("content")) {
+		t.Fatalf("expected the checksums to match the content")
+	}
+
+	if medias[created.ID].Checksums.Verify([]byte("c0ntent")) {
+		t.Fatalf("expected the checksums not to match another content")
+	}
+}
+
+type fakeProber struct {
+	err error
+}
+
+func (p fakeProber) Probe(ctx context.Context, mimetype string, fileContent []byte) (media.Properties, error) {
+	return media.Properties{Width: len(fileContent)}, p.err
+}
+
+func TestCreateWithProber(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	fakeMediaRepository := adapters.NewFakeMediaRepository()
+
+	t.Run("probed", func(t *testing.T) {
+		service := NewMediaService(fakeMediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), WithProber(fakeProber{}))
+
+		created, _, err := service.Create(ctx, "media-1", nil, []byte("content"), "random/mime")
+		if err != nil {
+			t.Fatalf("an error ocurred while creating the media : %s", err)
+		}
+
+		if created.Properties.Width != 7 {
+			t.Errorf("expected the created media to have a width of %d, got %d", 7, created.Properties.Width)
+		}
+
+		medias, _ := fakeMediaRepository.GetByIDs(ctx, created.ID)
+		if medias[created.ID].Properties.Width != 7 {
+			t.Errorf("expected the stored media to have a width of %d, got %d", 7, medias[created.ID].Properties.Width)
+		}
+	})
+
+	t.Run("probe failure", func(t *testing.T) {
+		service := NewMediaService(fakeMediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), WithProber(fakeProber{media.UnsupportedMedia("random/mime")}))
+
+		created, _, err := service.Create(ctx, "media-1", nil, []byte("content"), "random/mime")
+		if err != nil {
+			t.Fatalf("expected a probe failure to be ignored, got %s", err)
+		}
+
+		if created.Properties != (media.Properties{}) {
+			t.Errorf("expected the created media to have no properties, got %+v", created.Properties)
+		}
+	})
+}
+
+type fakePlaceholders struct{}
+
+func (fakePlaceholders) Generate(ctx context.Context, mimetype string, fileContent []byte) (media.Placeholder, error) {
+	if mimetype != "image/png" {
+		return media.Placeholder{}, media.UnsupportedMedia(mimetype)
+	}
+
+	return media.Placeholder{BlurHash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj", Colors: []string{"#ffffff"}}, nil
+}
+
+func TestCreateWithPlaceholders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	fakeMediaRepository := adapters.NewFakeMediaRepository()
+	service := NewMediaService(fakeMediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), WithPlaceholders(fakePlaceholders{}))
+
+	image, _, err := service.Create(ctx, "media-1", nil, []byte("content"), "image/png")
+	if err != nil {
+		t.Fatalf("an error ocurred while creating the media : %s", err)
+	}
+
+	medias, _ := fakeMediaRepository.GetByIDs(ctx, image.ID)
+	if medias[image.ID].Placeholder.BlurHash != "LEHV6nWB2yk8pyo0adR*.7kCMdnj" {
+		t.Errorf("expected the stored media to have a placeholder, got %+v", medias[image.ID].Placeholder)
+	}
+
+	other, _, err := service.Create(ctx, "media-2", nil, []byte("content"), "text/plain")
+	if err != nil {
+		t.Fatalf("expected a placeholder failure to be ignored, got %s", err)
+	}
+
+	if other.Placeholder.BlurHash != "" {
+		t.Errorf("expected the media to have no placeholder, got %+v", other.Placeholder)
+	}
+}
+
+// fakeHasher uses the first byte of the content as a hash
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(ctx context.Context, mimetype string, fileContent []byte) (media.PerceptualHash, error) {
+	if mimetype != "image/png" {
+		return 0, media.UnsupportedMedia(mimetype)
+	}
+
+	return media.PerceptualHash(fileContent[0]), nil
+}
+
+func TestSimilar(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	service := NewMediaService(
+		adapters.NewFakeMediaRepository(),
+		adapters.NewFakeTagRegistry(),
+		adapters.NewFakeUploader(),
+		WithPerceptualHashes(fakeHasher{}, adapters.NewBktreeIndex()),
+	)
+
+	reference, _, _ := service.Create(ctx, "reference", []string{"tag-1"}, []byte{0b0000_1111}, "image/png")
+	near, _, _ := service.Create(ctx, "near", []string{"tag-1"}, []byte{0b0000_0111}, "image/png")
+	closer, _, _ := service.Create(ctx, "closer", nil, []byte{0b0000_1111}, "image/png")
+	service.Create(ctx, "far", nil, []byte{0b1111_0000}, "image/png")
+	text, _, _ := service.Create(ctx, "text", nil, []byte{0b0000_1111}, "text/plain")
+
+	if reference.PerceptualHash == nil || *reference.PerceptualHash != 0b0000_1111 {
+		t.Fatalf("expected the perceptual hash to be stored on the media, got %v", reference.PerceptualHash)
+	}
+
+	t.Run("media does not exists", func(t *testing.T) {
+		_, _, err := service.Similar(ctx, uuid.NewString(), 10)
+		if !errors.Is(err, media.ErrMediaNotFound) {
+			t.Errorf("expected a media not found error, got %q", err)
+		}
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		similar, _, err := service.Similar(ctx, text.ID, 10)
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if len(similar) != 0 {
+			t.Errorf("expected no similar medias, got %d", len(similar))
+		}
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		similar, tags, err := service.Similar(ctx, reference.ID, 2)
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if len(similar) != 2 {
+			t.Fatalf("expected 2 similar medias, got %d", len(similar))
+		}
+
+		if similar[0].ID != closer.ID || similar[0].Distance != 0 {
+			t.Errorf("expected %q to be the most similar, got %q at %d", closer.Name, similar[0].Name, similar[0].Distance)
+		}
+
+		if similar[1].ID != near.ID || similar[1].Distance != 1 {
+			t.Errorf("expected %q to be the second most similar, got %q at %d", near.Name, similar[1].Name, similar[1].Distance)
+		}
+
+		if len(tags[near.ID]) != 1 {
+			t.Errorf("expected the tags of the similar medias to be returned, got %v", tags)
+		}
+	})
+}
+
+type fakeSanitizer struct{}
+
+func (fakeSanitizer) Sanitize(ctx context.Context, mediaID string, fileContent []byte) ([]byte, error) {
+	if string(fileContent) == "invalid" {
+		return nil, media.FileError(mediaID, errors.New("invalid content"))
+	}
+
+	return bytes.ToUpper(fileContent), nil
+}
+
+func TestCreateWithSanitizer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	fakeUploader := adapters.NewFakeUploader()
+	service := NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), fakeUploader, WithSanitizer(fakeSanitizer{}))
+
+	created, _, err := service.Create(ctx, "media-1", nil, []byte("content"), "random/mime")
+	if err != nil {
+		t.Fatalf("an error ocurred while creating the media : %s", err)
+	}
+
+	stored, _ := fakeUploader.GetContent(ctx, created.ID)
+	if string(stored) != "CONTENT" {
+		t.Errorf("expected the sanitized content to be uploaded, got %q", stored)
+	}
+
+	if !created.Checksums.Verify(stored) {
+		t.Errorf("expected the checksums to be computed on the sanitized content")
+	}
+
+	_, _, err = service.Create(ctx, "media-2", nil, []byte("invalid"), "random/mime")
+	if !errors.Is(err, media.ErrFile) {
+		t.Errorf("expected a sanitizer failure to fail the creation, got %s", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	service := NewMediaService(
+		adapters.NewFakeMediaRepository(),
+		adapters.NewFakeTagRegistry(),
+		adapters.NewFakeUploader(),
+	)
+
+	created, _, _ := service.Create(ctx, "media-1", []string{"tag-1", "tag-2"}, []byte("content"), "random/mime")
+
+	t.Run("media does not exists", func(t *testing.T) {
+		_, _, err := service.Get(ctx, uuid.NewString())
+		if !errors.Is(err, media.ErrMediaNotFound) {
+			t.Errorf("expected a media not found error, got %q", err)
+		}
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		found, tags, err := service.Get(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if found.Name != "media-1" {
+			t.Errorf("expected the media to be named %q, got %q", "media-1", found.Name)
+		}
+
+		if len(tags) != 2 {
+			t.Errorf("expected the media to have 2 tags, got %d", len(tags))
+		}
+	})
 }
 
 func TestView(t *testing.T) {
@@ -110,7 +378,7 @@ func TestView(t *testing.T) {
 
 	// fixtures
 	mediaOK, _, _ := service.Create(ctx, "media-1", nil, []byte("file content"), "random/type")
-	mediaNotUploader, _ := fakeMediaRepository.Create(ctx, "media-2", "")
+	mediaNotUploader, _ := fakeMediaRepository.Create(ctx, media.Media{Name: "media-2"})
 
 	t.Run("media does not exists", func(t *testing.T) {
 		_, _, err := service.View(ctx, uuid.NewString())
@@ -136,7 +404,7 @@ func TestView(t *testing.T) {
 	})
 
 	t.Run("nominal", func(t *testing.T) {
-		content, mimetype, err := service.View(ctx, mediaOK.ID)
+		viewed, content, err := service.View(ctx, mediaOK.ID)
 		if err != nil {
 			t.Errorf("Unexpected error")
 		}
@@ -145,8 +413,550 @@ func TestView(t *testing.T) {
 			t.Errorf("Not the expected content : expected %q, got %q", "file content", string(content))
 		}
 
-		if mimetype != "random/type" {
-			t.Errorf("Not the expected type : expected %q, got %q", "random/type", mimetype)
+		if viewed.Mimetype != "random/type" {
+			t.Errorf("Not the expected type : expected %q, got %q", "random/type", viewed.Mimetype)
 		}
 	})
 }
+
+func TestPrepareAndComplete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	t.Run("unsupported storage", func(t *testing.T) {
+		service := NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+
+		if _, _, _, err := service.Prepare(ctx, "media-1", nil, "random/mime", 7); !errors.Is(err, media.ErrPresignUnsupported) {
+			t.Errorf("expected a presign unsupported error, got %v", err)
+		}
+	})
+
+	fakeUploader := adapters.NewHmacPresigner(adapters.NewFakeUploader(), signature.NewSigner([]byte("secret")))
+	service := NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), fakeUploader, WithSanitizer(fakeSanitizer{}), WithPresignExpiration(time.Minute))
+
+	prepared, tags, upload, err := service.Prepare(ctx, "media-1", []string{"tag-1"}, "random/mime", 7)
+	if err != nil {
+		t.Fatalf("an error ocurred while preparing the media : %s", err)
+	}
+
+	if len(tags) != 1 || upload.Method != "PUT" || upload.ExpiresAt.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("expected a tagged media and an upload url valid for a minute, got %v and %+v", tags, upload)
+	}
+
+	if _, _, err := service.Complete(ctx, prepared.ID); !errors.Is(err, media.ErrFileNotFound) {
+		t.Fatalf("expected a file not found error before the upload, got %v", err)
+	}
+
+	if _, _, err := service.Get(ctx, prepared.ID); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected a pending media not to be found, got %v", err)
+	}
+
+	// what the client would send to the storage
+	fakeUploader.Upload(ctx, prepared.ID, []byte("content"))
+
+	completed, tags, err := service.Complete(ctx, prepared.ID)
+	if err != nil {
+		t.Fatalf("an error ocurred while completing the media : %s", err)
+	}
+
+	if len(tags) != 1 {
+		t.Errorf("expected the tags of the media to be returned, got %v", tags)
+	}
+
+	stored, _ := fakeUploader.GetContent(ctx, prepared.ID)
+	if string(stored) != "CONTENT" {
+		t.Errorf("expected the sanitized content to be stored, got %q", stored)
+	}
+
+	if !completed.Checksums.Verify(stored) || completed.Pending {
+		t.Errorf("expected the media to be completed with the checksums of the stored content")
+	}
+
+	// completing it again changes nothing
+	if again, _, err := service.Complete(ctx, prepared.ID); err != nil || again.Checksums != completed.Checksums {
+		t.Errorf("expected the completed media to be returned as is, got %+v (%v)", again, err)
+	}
+
+	download, err := service.Presign(ctx, prepared.ID)
+	if err != nil || download.Method != "GET" {
+		t.Errorf("expected a download url, got %+v (%v)", download, err)
+	}
+
+	if _, err := service.Presign(ctx, uuid.NewString()); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected a media not found error, got %v", err)
+	}
+}
+
+func TestAccess(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+	friend := auth.WithPrincipal(ctx, auth.Principal{ID: "friend"})
+	colleague := auth.WithPrincipal(ctx, auth.Principal{ID: "colleague", Groups: []string{"team"}})
+	stranger := auth.WithPrincipal(ctx, auth.Principal{ID: "stranger", Groups: []string{"other-team"}})
+	anonymous := ctx
+
+	fakeTagRegistry := adapters.NewFakeTagRegistry()
+	service := NewMediaService(adapters.NewFakeMediaRepository(), fakeTagRegistry, adapters.NewFakeUploader(), WithDefaultVisibility(media.VisibilityPrivate))
+
+	private, _, _ := service.Create(owner, "private", []string{"tag-1"}, []byte("content"), "random/mime")
+	shared, _, _ := service.Create(owner, "shared", []string{"tag-1"}, []byte("content"), "random/mime")
+	public, _, _ := service.Create(anonymous, "public", []string{"tag-1"}, []byte("content"), "random/mime")
+
+	if private.Access.Owner != "owner" || private.Access.Visibility != media.VisibilityPrivate {
+		t.Fatalf("expected the media to be owned by its creator, with the default visibility, got %+v", private.Access)
+	}
+
+	if public.Access.Owner != "" || public.Access.Visibility != media.VisibilityPublic {
+		t.Fatalf("expected an anonymous media to be public, got %+v", public.Access)
+	}
+
+	if _, err := service.Share(friend, shared.ID, media.VisibilityShared, []string{"friend"}, []string{"team"}); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Fatalf("expected only the owner to be able to share a media, got %v", err)
+	}
+
+	if _, err := service.Share(owner, shared.ID, "everyone", nil, nil); !errors.Is(err, media.ErrInvalidVisibility) {
+		t.Fatalf("expected an invalid visibility error, got %v", err)
+	}
+
+	if _, err := service.Share(anonymous, public.ID, media.VisibilityPrivate, nil, nil); !errors.Is(err, media.ErrInvalidVisibility) {
+		t.Fatalf("expected an anonymous media to stay public, got %v", err)
+	}
+
+	if _, err := service.Share(owner, shared.ID, media.VisibilityShared, []string{"friend"}, []string{"team"}); err != nil {
+		t.Fatalf("an error ocurred while sharing the media : %s", err)
+	}
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		expected []string
+	}{
+		{name: "owner", ctx: owner, expected: []string{private.ID, shared.ID, public.ID}},
+		{name: "shared with the user", ctx: friend, expected: []string{shared.ID, public.ID}},
+		{name: "shared with a group", ctx: colleague, expected: []string{shared.ID, public.ID}},
+		{name: "not shared", ctx: stranger, expected: []string{public.ID}},
+		{name: "anonymous", ctx: anonymous, expected: []string{public.ID}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			medias, tags, err := service.SearchByTag(tc.ctx, "tag-1")
+			if err != nil {
+				t.Fatalf("an error ocurred while searching : %s", err)
+			}
+
+			if len(medias) != len(tc.expected) || len(tags) != len(tc.expected) {
+				t.Errorf("expected %d medias and their tags, got %d medias and tags for %d medias", len(tc.expected), len(medias), len(tags))
+			}
+
+			for _, created := range []media.Media{private, shared, public} {
+				_, _, err := service.View(tc.ctx, created.ID)
+
+				if slices.Contains(tc.expected, created.ID) && err != nil {
+					t.Errorf("expected to be able to view the media %q, got %s", created.Name, err)
+				}
+
+				if !slices.Contains(tc.expected, created.ID) && !errors.Is(err, media.ErrMediaNotFound) {
+					t.Errorf("expected the media %q not to be found, got %v", created.Name, err)
+				}
+			}
+		})
+	}
+}
+
+// failingTags is a tag registry whose tags can't be listed
+type failingTags struct {
+	media.TagRegistry
+}
+
+func (failingTags) GetAll(ctx context.Context) (map[string]media.Tag, error) {
+	return nil, errors.New("registry unavailable")
+}
+
+func TestCreateAccess(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	uploader := auth.WithPrincipal(ctx, auth.Principal{ID: "uploader", Roles: []string{auth.RoleUploader}})
+
+	t.Run("stored with the media", func(t *testing.T) {
+		mediaRepository := adapters.NewFakeMediaRepository()
+		fakeUploader := adapters.NewHmacPresigner(adapters.NewFakeUploader(), signature.NewSigner([]byte("secret")))
+		service := NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), fakeUploader, WithDefaultVisibility(media.VisibilityPrivate))
+
+		prepared, _, _, err := service.Prepare(uploader, "media", nil, "text/plain", 7)
+		if err != nil {
+			t.Fatalf("an error ocurred while preparing the media : %s", err)
+		}
+
+		medias, _ := mediaRepository.GetByIDs(ctx, prepared.ID)
+		if stored := medias[prepared.ID]; stored.Access.Owner != "uploader" || stored.Access.Visibility != media.VisibilityPrivate {
+			t.Errorf("expected the access of the media to be stored as soon as it is created, got %+v", stored.Access)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mediaRepository := adapters.NewFakeMediaRepository()
+		quotas := quota.NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{}, media.Usage{})
+		service := NewMediaService(mediaRepository, failingTags{adapters.NewFakeTagRegistry()}, adapters.NewFakeUploader(), WithPolicy(auth.DefaultPolicy()), WithQuotas(quotas))
+
+		if _, _, err := service.Create(uploader, "media", []string{"tag-1"}, []byte("content"), "text/plain"); err == nil {
+			t.Fatalf("expected the creation to fail without the existing tags")
+		}
+
+		if all, _ := mediaRepository.GetAll(ctx); len(all) != 0 {
+			t.Errorf("expected no media to be left behind, got %d medias", len(all))
+		}
+
+		if user, _, _ := quotas.Get(uploader); user.Usage != (media.Usage{}) {
+			t.Errorf("expected nothing to be accounted, got %+v", user.Usage)
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	viewer := auth.WithPrincipal(ctx, auth.Principal{ID: "viewer", Roles: []string{auth.RoleViewer}})
+	uploader := auth.WithPrincipal(ctx, auth.Principal{ID: "uploader", Roles: []string{auth.RoleUploader}})
+	curator := auth.WithPrincipal(ctx, auth.Principal{ID: "curator", Roles: []string{auth.RoleCurator}})
+
+	fakeTagRegistry := adapters.NewFakeTagRegistry()
+	fakeTagRegistry.Create(ctx, "tag-1")
+
+	service := NewMediaService(adapters.NewFakeMediaRepository(), fakeTagRegistry, adapters.NewFakeUploader(), WithPolicy(auth.DefaultPolicy()))
+
+	if _, _, err := service.Create(viewer, "media", nil, []byte("content"), "random/mime"); !errors.Is(err, auth.ErrForbidden) {
+		t.Fatalf("expected a viewer not to create medias, got %v", err)
+	}
+
+	created, tags, err := service.Create(uploader, "media", []string{"tag-1", "tag-2"}, []byte("content"), "random/mime")
+	if err != nil {
+		t.Fatalf("unexpected error while creating a media as an uploader : %s", err)
+	}
+
+	if len(tags) != 1 || tags[0].Name != "tag-1" {
+		t.Errorf("expected only the existing tags to be linked, got %+v", tags)
+	}
+
+	if _, tags, _ := service.Create(curator, "media", []string{"tag-1", "tag-2"}, []byte("content"), "random/mime"); len(tags) != 2 {
+		t.Errorf("expected a curator to create the missing tags, got %+v", tags)
+	}
+
+	if _, _, err := service.Get(viewer, created.ID); err != nil {
+		t.Errorf("unexpected error while getting a media as a viewer : %s", err)
+	}
+
+	if _, err := service.Share(viewer, created.ID, media.VisibilityPrivate, nil, nil); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("expected a viewer not to share medias, got %v", err)
+	}
+
+	if _, _, err := service.SearchByTag(ctx, "tag-1"); err != nil {
+		t.Errorf("expected the anonymous requests to be viewers, got %s", err)
+	}
+
+	if _, _, err := service.Create(ctx, "media", nil, []byte("content"), "random/mime"); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("expected the anonymous requests not to create medias, got %v", err)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	acme := auth.WithTenant(ctx, "acme")
+	globex := auth.WithTenant(ctx, "globex")
+
+	service := NewMediaService(
+		adapters.NewTenantMediaRepository(adapters.NewFakeMediaRepository()),
+		adapters.NewTenantTagRegistry(adapters.NewFakeTagRegistry()),
+		adapters.NewTenantUploader(adapters.NewFakeUploader()),
+		WithPerceptualHashes(fakeHasher{}, adapters.NewBktreeIndex()),
+	)
+
+	secret, _, _ := service.Create(acme, "secret", []string{"tag-1"}, []byte{0b0000_1111}, "image/png")
+	other, _, _ := service.Create(globex, "other", []string{"tag-1"}, []byte{0b0000_1111}, "image/png")
+
+	if secret.Tenant != "acme" {
+		t.Fatalf("expected the media to belong to %q, got %q", "acme", secret.Tenant)
+	}
+
+	if _, _, err := service.Get(globex, secret.ID); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected the media of another tenant not to be found, got %v", err)
+	}
+
+	if _, _, err := service.View(globex, secret.ID); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected the media of another tenant not to be viewed, got %v", err)
+	}
+
+	if _, err := service.Share(globex, secret.ID, media.VisibilityPublic, nil, nil); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected the media of another tenant not to be shared, got %v", err)
+	}
+
+	medias, tags, err := service.SearchByTag(globex, "tag-1")
+	if err != nil {
+		t.Fatalf("unexpected error while searching : %s", err)
+	}
+
+	if len(medias) != 1 || medias[0].ID != other.ID || len(tags) != 1 {
+		t.Errorf("expected only the medias of the tenant to be found, got %+v with tags %+v", medias, tags)
+	}
+
+	similar, _, err := service.Similar(globex, other.ID, 10)
+	if err != nil {
+		t.Fatalf("unexpected error while searching similar medias : %s", err)
+	}
+
+	if len(similar) != 0 {
+		t.Errorf("expected the similar medias of another tenant not to be found, got %+v", similar)
+	}
+
+	if medias, _, _ := service.SearchByTag(ctx, "tag-1"); len(medias) != 0 {
+		t.Errorf("expected the default tenant not to see the medias of the others, got %+v", medias)
+	}
+
+	_, content, err := service.View(acme, secret.ID)
+	if err != nil || !bytes.Equal(content, []byte{0b0000_1111}) {
+		t.Errorf("expected the tenant to view its media, got %v (%v)", content, err)
+	}
+}
+
+func TestReplaceAndDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+	stranger := auth.WithPrincipal(ctx, auth.Principal{ID: "stranger"})
+
+	fakeUploader := adapters.NewFakeUploader()
+	index := adapters.NewBktreeIndex()
+	tagRegistry := adapters.NewFakeTagRegistry()
+	service := NewMediaService(adapters.NewFakeMediaRepository(), tagRegistry, fakeUploader, WithPerceptualHashes(fakeHasher{}, index))
+
+	created, _, _ := service.Create(owner, "media", []string{"tag-1"}, []byte{0b0000_1111}, "image/png")
+
+	if _, _, err := service.Replace(stranger, created.ID, []byte("stolen"), "text/plain"); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Fatalf("expected only the owner to replace a media, got %v", err)
+	}
+
+	replaced, tags, err := service.Replace(owner, created.ID, []byte("replaced"), "text/plain")
+	if err != nil {
+		t.Fatalf("unexpected error while replacing a media : %s", err)
+	}
+
+	if replaced.ID != created.ID || replaced.Mimetype != "text/plain" || replaced.Size != 8 || len(tags) != 1 {
+		t.Errorf("expected the media to be replaced, keeping its id and tags, got %+v with tags %v", replaced, tags)
+	}
+
+	if replaced.PerceptualHash != nil || replaced.Checksums == created.Checksums {
+		t.Errorf("expected what is computed from the content to be replaced, got %+v", replaced)
+	}
+
+	if similar, _ := index.Search(ctx, 0b0000_1111, 0); len(similar) != 0 {
+		t.Errorf("expected the previous hash to be removed from the index, got %v", similar)
+	}
+
+	if content, _ := fakeUploader.GetContent(ctx, created.ID); string(content) != "replaced" {
+		t.Errorf("expected the content to be replaced, got %q", content)
+	}
+
+	if err := service.Delete(stranger, created.ID); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Fatalf("expected only the owner to delete a media, got %v", err)
+	}
+
+	if err := service.Delete(owner, created.ID); err != nil {
+		t.Fatalf("unexpected error while deleting a media : %s", err)
+	}
+
+	if _, _, err := service.Get(owner, created.ID); !errors.Is(err, media.ErrMediaNotFound) {
+		t.Errorf("expected a deleted media not to be found, got %v", err)
+	}
+
+	if _, err := fakeUploader.GetContent(ctx, created.ID); !errors.Is(err, media.ErrFileNotFound) {
+		t.Errorf("expected the content of a deleted media to be removed, got %v", err)
+	}
+
+	if ids, _ := tagRegistry.GetMediaIDsForTag(ctx, "tag-1"); len(ids) != 0 {
+		t.Errorf("expected a deleted media to be unlinked from its tags, got %v", ids)
+	}
+}
+
+// failingUploader is an uploader whose uploads fail
+type failingUploader struct {
+	media.MediaUploader
+}
+
+func (failingUploader) Upload(ctx context.Context, mediaID string, fileContent []byte) error {
+	return errors.New("storage unavailable")
+}
+
+func TestReplaceFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	fakeUploader := adapters.NewFakeUploader()
+	index := adapters.NewBktreeIndex()
+
+	created, _, _ := NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), fakeUploader, WithPerceptualHashes(fakeHasher{}, index)).
+		Create(ctx, "media", nil, []byte{0b0000_1111}, "image/png")
+
+	service := NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), failingUploader{fakeUploader}, WithPerceptualHashes(fakeHasher{}, index))
+	if _, _, err := service.Replace(ctx, created.ID, []byte("replaced"), "text/plain"); err == nil {
+		t.Fatalf("expected the replacement to fail")
+	}
+
+	if medias, _ := mediaRepository.GetByIDs(ctx, created.ID); medias[created.ID].Checksums != created.Checksums || medias[created.ID].Mimetype != "image/png" {
+		t.Errorf("expected the media to be left unchanged, got %+v", medias[created.ID])
+	}
+
+	if similar, _ := index.Search(ctx, 0b0000_1111, 0); len(similar) != 1 {
+		t.Errorf("expected the media to stay indexed, got %v", similar)
+	}
+
+	tagRegistry := adapters.NewFakeTagRegistry()
+	service = NewMediaService(mediaRepository, tagRegistry, failingUploader{fakeUploader})
+	if _, _, err := service.Create(ctx, "rejected", []string{"tag-1"}, []byte("content"), "text/plain"); err == nil {
+		t.Fatalf("expected the creation to fail")
+	}
+
+	if ids, _ := tagRegistry.GetMediaIDsForTag(ctx, "tag-1"); len(ids) != 0 {
+		t.Errorf("expected the media rolled back to be unlinked from its tags, got %v", ids)
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+
+	mediaRepository := adapters.NewFakeMediaRepository()
+	quotas := quota.NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{Bytes: 10, Medias: 2}, media.Usage{})
+	service := NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), WithQuotas(quotas))
+
+	usage := func() media.Usage {
+		user, _, _ := quotas.Get(owner)
+		return user.Usage
+	}
+
+	if _, _, err := service.Create(owner, "huge", nil, []byte("more than ten bytes"), "text/plain"); !errors.Is(err, media.ErrLargerThanQuota) {
+		t.Fatalf("expected a media larger than the quota to be rejected, got %v", err)
+	}
+
+	created, _, err := service.Create(owner, "media", nil, []byte("123456"), "text/plain")
+	if err != nil {
+		t.Fatalf("unexpected error while creating a media : %s", err)
+	}
+
+	if _, _, err := service.Create(owner, "media", nil, []byte("123456"), "text/plain"); !errors.Is(err, media.ErrQuotaExceeded) {
+		t.Fatalf("expected the quota to be exceeded, got %v", err)
+	}
+
+	if all, _ := mediaRepository.GetAll(ctx); len(all) != 1 {
+		t.Errorf("expected the rejected medias not to be created, got %d medias", len(all))
+	}
+
+	if got := usage(); got != (media.Usage{Bytes: 6, Medias: 1}) {
+		t.Errorf("expected the created media to be accounted, got %+v", got)
+	}
+
+	if _, _, err := service.Replace(owner, created.ID, []byte("1234567890"), ""); err != nil {
+		t.Fatalf("unexpected error while replacing a media within the quota : %s", err)
+	}
+
+	if got := usage(); got != (media.Usage{Bytes: 10, Medias: 1}) {
+		t.Errorf("expected the replaced media to be accounted, got %+v", got)
+	}
+
+	if err := service.Delete(owner, created.ID); err != nil {
+		t.Fatalf("unexpected error while deleting a media : %s", err)
+	}
+
+	if got := usage(); got != (media.Usage{}) {
+		t.Errorf("expected the deleted media to free its space, got %+v", got)
+	}
+
+	presigned := NewMediaService(mediaRepository, adapters.NewFakeTagRegistry(), adapters.NewHmacPresigner(adapters.NewFakeUploader(), signature.NewSigner([]byte("secret"))), WithQuotas(quotas))
+	if _, _, _, err := presigned.Prepare(owner, "huge", nil, "text/plain", 11); !errors.Is(err, media.ErrLargerThanQuota) {
+		t.Errorf("expected a prepared media declared larger than the quota to be rejected, got %v", err)
+	}
+}
+
+// fakePurger records the purges, once released
+type fakePurger struct {
+	release chan struct{}
+	purges  chan purge
+}
+
+type purge struct {
+	event       media.PurgeEvent
+	err         error
+	hasDeadline bool
+}
+
+func (p *fakePurger) Purge(ctx context.Context, media media.Media, event media.PurgeEvent) error {
+	<-p.release
+
+	_, hasDeadline := ctx.Deadline()
+	p.purges <- purge{event: event, err: ctx.Err(), hasDeadline: hasDeadline}
+
+	return errors.New("cdn unavailable")
+}
+
+func TestCachePurger(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	request, cancelRequest := context.WithCancel(ctx)
+	owner := auth.WithPrincipal(request, auth.Principal{ID: "owner"})
+
+	purger := &fakePurger{release: make(chan struct{}), purges: make(chan purge, 3)}
+	service := NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), WithCachePurger(purger))
+
+	created, _, _ := service.Create(owner, "media", nil, []byte("content"), "text/plain")
+
+	// failing (or being slow) to purge doesn't fail the operations
+	if _, _, err := service.Replace(owner, created.ID, []byte("replaced"), "text/plain"); err != nil {
+		t.Fatalf("unexpected error while replacing a media : %s", err)
+	}
+
+	if _, err := service.Share(owner, created.ID, media.VisibilityPrivate, nil, nil); err != nil {
+		t.Fatalf("unexpected error while sharing a media : %s", err)
+	}
+
+	// a private media was not cached by the shared caches
+	if _, err := service.Share(owner, created.ID, media.VisibilityShared, []string{"friend"}, nil); err != nil {
+		t.Fatalf("unexpected error while sharing a media : %s", err)
+	}
+
+	if err := service.Delete(owner, created.ID); err != nil {
+		t.Fatalf("unexpected error while deleting a media : %s", err)
+	}
+
+	// the purges outlive the requests
+	cancelRequest()
+	close(purger.release)
+
+	purged := make([]media.PurgeEvent, 0)
+	for range 3 {
+		select {
+		case purge := <-purger.purges:
+			if purge.err != nil || !purge.hasDeadline {
+				t.Errorf("expected the purge to have its own deadline, got %+v", purge)
+			}
+
+			purged = append(purged, purge.event)
+		case <-ctx.Done():
+			t.Fatalf("expected 3 purges, got %v", purged)
+		}
+	}
+
+	expected := []media.PurgeEvent{media.PurgeDeleted, media.PurgeReplaced, media.PurgeRestricted}
+	if slices.Sort(purged); !slices.Equal(purged, expected) {
+		t.Errorf("expected the purges %v, got %v", expected, purged)
+	}
+}
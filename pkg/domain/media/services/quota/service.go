@@ -0,0 +1,114 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+)
+
+// NewQuotaService returns a service accounting the storage used by each user
+// and each tenant, within the given limits ; a zero limit means there is
+// none.
+func NewQuotaService(repository QuotaRepository, userLimits Usage, tenantLimits Usage) QuotaService {
+	return &service{repository, userLimits, tenantLimits}
+}
+
+type service struct {
+	repository   QuotaRepository
+	userLimits   Usage
+	tenantLimits Usage
+}
+
+func tenantAccount(tenant string) string {
+	return "tenant:" + tenant
+}
+
+func userAccount(tenant string, user string) string {
+	return "user:" + tenant + "/" + user
+}
+
+// limits returns the limits of the accounts of the owner and of the tenant
+// behind the context. The medias created anonymously are only accounted for
+// their tenant.
+func (s *service) limits(ctx context.Context, owner string) map[string]Usage {
+	tenant := auth.TenantFrom(ctx)
+	limits := map[string]Usage{tenantAccount(tenant): s.tenantLimits}
+
+	if owner != "" {
+		limits[userAccount(tenant, owner)] = s.userLimits
+	}
+
+	return limits
+}
+
+// Get implements media.QuotaService.
+func (s *service) Get(ctx context.Context) (*Quota, Quota, error) {
+	tenant := auth.TenantFrom(ctx)
+	principal, authenticated := auth.PrincipalFrom(ctx)
+
+	usages, err := s.repository.Get(ctx, tenantAccount(tenant), userAccount(tenant, principal.ID))
+	if err != nil {
+		return nil, Quota{}, err
+	}
+
+	var user *Quota
+	if authenticated {
+		user = &Quota{Usage: usages[userAccount(tenant, principal.ID)], Limits: s.userLimits}
+	}
+
+	return user, Quota{Usage: usages[tenantAccount(tenant)], Limits: s.tenantLimits}, nil
+}
+
+// Check implements media.QuotaService.
+func (s *service) Check(ctx context.Context, owner string, delta Usage) error {
+	limits := s.limits(ctx, owner)
+	if err := fits(delta, limits); err != nil {
+		return err
+	}
+
+	accounts := make([]string, 0, len(limits))
+	for account := range limits {
+		accounts = append(accounts, account)
+	}
+
+	usages, err := s.repository.Get(ctx, accounts...)
+	if err != nil {
+		return err
+	}
+
+	for account, limit := range limits {
+		usage := usages[account]
+		usage.Bytes += delta.Bytes
+		usage.Medias += delta.Medias
+
+		if usage.Exceeds(limit) {
+			return QuotaExceeded(account)
+		}
+	}
+
+	return nil
+}
+
+// Charge implements media.QuotaService.
+func (s *service) Charge(ctx context.Context, owner string, delta Usage) error {
+	limits := s.limits(ctx, owner)
+	if err := fits(delta, limits); err != nil {
+		return err
+	}
+
+	return s.repository.Add(ctx, delta, limits)
+}
+
+// fits returns a ErrLargerThanQuota if the delta alone is larger than one of
+// the limits, so that it can never be stored whatever space is freed.
+func fits(delta Usage, limits map[string]Usage) error {
+	for account, limit := range limits {
+		if limit.Bytes > 0 && delta.Bytes > limit.Bytes {
+			return LargerThanQuota(account, limit.Bytes)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+)
+
+func TestQuotas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	alice := auth.WithPrincipal(ctx, auth.Principal{ID: "alice"})
+	bob := auth.WithPrincipal(ctx, auth.Principal{ID: "bob"})
+
+	service := NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{Bytes: 10, Medias: 2}, media.Usage{Bytes: 15})
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		delta         media.Usage
+		expectedError error
+	}{
+		{name: "within the quotas", ctx: alice, delta: media.Usage{Bytes: 8, Medias: 1}},
+		{name: "larger than the user quota", ctx: bob, delta: media.Usage{Bytes: 11, Medias: 1}, expectedError: media.ErrLargerThanQuota},
+		{name: "user quota exceeded", ctx: alice, delta: media.Usage{Bytes: 3, Medias: 1}, expectedError: media.ErrQuotaExceeded},
+		{name: "tenant quota exceeded", ctx: bob, delta: media.Usage{Bytes: 8, Medias: 1}, expectedError: media.ErrQuotaExceeded},
+		{name: "anonymous", ctx: ctx, delta: media.Usage{Bytes: 7, Medias: 1}},
+		{name: "other tenant", ctx: auth.WithTenant(bob, "acme"), delta: media.Usage{Bytes: 8, Medias: 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			owner, _ := auth.PrincipalFrom(tc.ctx)
+
+			if err := service.Check(tc.ctx, owner.ID, tc.delta); !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected the check to return %v, got %v", tc.expectedError, err)
+			}
+
+			if err := service.Charge(tc.ctx, owner.ID, tc.delta); !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected the charge to return %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	user, tenant, err := service.Get(alice)
+	if err != nil {
+		t.Fatalf("unexpected error while getting the quotas : %s", err)
+	}
+
+	if user == nil || user.Usage != (media.Usage{Bytes: 8, Medias: 1}) || user.Limits != (media.Usage{Bytes: 10, Medias: 2}) {
+		t.Errorf("expected the quota of the user to be returned, got %+v", user)
+	}
+
+	if tenant.Usage != (media.Usage{Bytes: 15, Medias: 2}) || tenant.Limits != (media.Usage{Bytes: 15}) {
+		t.Errorf("expected the quota of the tenant to be returned, got %+v", tenant)
+	}
+
+	if user, _, _ := service.Get(ctx); user != nil {
+		t.Errorf("expected no user quota for an anonymous request, got %+v", user)
+	}
+}
@@ -1,11 +1,42 @@
 package services
 
 import (
+	"github.com/Taluu/media-go/pkg/domain/media/services/integrity"
 	"github.com/Taluu/media-go/pkg/domain/media/services/media"
+	"github.com/Taluu/media-go/pkg/domain/media/services/quota"
 	"github.com/Taluu/media-go/pkg/domain/media/services/tag"
+	"github.com/Taluu/media-go/pkg/domain/media/services/traced"
+	"github.com/Taluu/media-go/pkg/domain/media/services/upload"
 )
 
 var (
-	NewTagService   = tag.NewTagService
-	NewMediaService = media.NewMediaService
+	NewTagService    = tag.NewTagService
+	NewMediaService  = media.NewMediaService
+	NewScrubber      = integrity.NewScrubber
+	NewUploadService = upload.NewUploadService
+	NewQuotaService  = quota.NewQuotaService
+
+	NewTracedMediaService = traced.NewMediaService
+	NewTracedTagService   = traced.NewTagService
+
+	WithMediaSanitizer    = media.WithSanitizer
+	WithMediaProber       = media.WithProber
+	WithMediaPlaceholders = media.WithPlaceholders
+	WithPerceptualHashes  = media.WithPerceptualHashes
+	WithMD5Checksums      = media.WithMD5Checksums
+	WithPresignExpiration = media.WithPresignExpiration
+	WithDefaultVisibility = media.WithDefaultVisibility
+	WithMediaPolicy       = media.WithPolicy
+	WithMediaQuotas       = media.WithQuotas
+	WithCachePurger       = media.WithCachePurger
+
+	WithTagPolicy = tag.WithPolicy
+
+	WithUploadQuotas = upload.WithQuotas
+)
+
+type (
+	MediaOption  = media.Option
+	TagOption    = tag.Option
+	UploadOption = upload.Option
 )
@@ -6,19 +6,50 @@ import (
 	"maps"
 	"slices"
 
+	"github.com/Taluu/media-go/pkg/auth"
 	. "github.com/Taluu/media-go/pkg/domain/media"
 )
 
-func NewTagService(registry TagRegistry) TagService {
-	return &service{registry}
+func NewTagService(registry TagRegistry, options ...Option) TagService {
+	s := &service{TagRegistry: registry}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// Option configures the optional features of the service
+type Option func(*service)
+
+// WithPolicy checks that the principal behind the context is granted the
+// permission of each operation, which is granted to everybody otherwise.
+func WithPolicy(policy *auth.Policy) Option {
+	return func(s *service) {
+		s.policy = policy
+	}
 }
 
 type service struct {
 	TagRegistry
+	policy *auth.Policy
 }
 
 func (s *service) GetAll(ctx context.Context) ([]Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionListTags); err != nil {
+		return nil, err
+	}
+
 	tags, err := s.TagRegistry.GetAll(ctx)
 	tagsSlice := slices.Collect(maps.Values(tags))
 	return tagsSlice, err
 }
+
+func (s *service) Create(ctx context.Context, name string) (Tag, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionCreateTags); err != nil {
+		return Tag{}, err
+	}
+
+	return s.TagRegistry.Create(ctx, name)
+}
@@ -2,9 +2,11 @@ package tag
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/Taluu/media-go/pkg/auth"
 	"github.com/Taluu/media-go/pkg/domain/media/adapters"
 )
 
@@ -29,3 +31,31 @@ func TestGetAll(t *testing.T) {
 		t.Fatalf("expected to have 2 elements, got %d", len(all))
 	}
 }
+
+func TestPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	registry := adapters.NewFakeTagRegistry()
+	service := NewTagService(registry, WithPolicy(auth.DefaultPolicy()))
+
+	viewer := auth.WithPrincipal(ctx, auth.Principal{ID: "alice", Roles: []string{auth.RoleViewer}})
+	curator := auth.WithPrincipal(ctx, auth.Principal{ID: "bob", Roles: []string{auth.RoleCurator}})
+
+	if _, err := service.Create(viewer, "foo"); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("expected a viewer not to create tags, got %v", err)
+	}
+
+	if _, err := service.Create(curator, "foo"); err != nil {
+		t.Fatalf("unexpected error while creating a tag as a curator : %s", err)
+	}
+
+	all, err := service.GetAll(viewer)
+	if err != nil {
+		t.Fatalf("unexpected error while listing the tags as a viewer : %s", err)
+	}
+
+	if len(all) != 1 {
+		t.Errorf("expected to have 1 element, got %d", len(all))
+	}
+}
@@ -0,0 +1,114 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewMediaService decorates a media service so that each of its calls is
+// traced in a span, the spans of its adapters being its children.
+func NewMediaService(service MediaService, tracer *tracing.Tracer) MediaService {
+	return &mediaService{service, tracer}
+}
+
+type mediaService struct {
+	service MediaService
+	tracer  *tracing.Tracer
+}
+
+// SearchByTag implements media.MediaService.
+func (s *mediaService) SearchByTag(ctx context.Context, tagName string) (medias []Media, tags map[string][]Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.SearchByTag", "tag", tagName)
+	defer func() { span.Finish(err) }()
+
+	return s.service.SearchByTag(ctx, tagName)
+}
+
+// Get implements media.MediaService.
+func (s *mediaService) Get(ctx context.Context, id string) (media Media, tags []Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Get", "media.id", id)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Get(ctx, id)
+}
+
+// Create implements media.MediaService.
+func (s *mediaService) Create(ctx context.Context, name string, tags []string, fileContent []byte, mimetype string) (media Media, linked []Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Create", "media.size", len(fileContent), "media.mimetype", mimetype)
+	defer func() {
+		span.SetAttributes("media.id", media.ID)
+		span.Finish(err)
+	}()
+
+	return s.service.Create(ctx, name, tags, fileContent, mimetype)
+}
+
+// View implements media.MediaService.
+func (s *mediaService) View(ctx context.Context, id string) (media Media, fileContent []byte, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.View", "media.id", id)
+	defer func() { span.Finish(err) }()
+
+	return s.service.View(ctx, id)
+}
+
+// Similar implements media.MediaService.
+func (s *mediaService) Similar(ctx context.Context, id string, maxDistance int) (medias []SimilarMedia, tags map[string][]Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Similar", "media.id", id, "max_distance", maxDistance)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Similar(ctx, id, maxDistance)
+}
+
+// Prepare implements media.MediaService.
+func (s *mediaService) Prepare(ctx context.Context, name string, tags []string, mimetype string, size int64) (media Media, linked []Tag, url PresignedURL, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Prepare", "media.mimetype", mimetype, "media.size", size)
+	defer func() {
+		span.SetAttributes("media.id", media.ID)
+		span.Finish(err)
+	}()
+
+	return s.service.Prepare(ctx, name, tags, mimetype, size)
+}
+
+// Complete implements media.MediaService.
+func (s *mediaService) Complete(ctx context.Context, id string) (media Media, tags []Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Complete", "media.id", id)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Complete(ctx, id)
+}
+
+// Presign implements media.MediaService.
+func (s *mediaService) Presign(ctx context.Context, id string) (url PresignedURL, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Presign", "media.id", id)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Presign(ctx, id)
+}
+
+// Share implements media.MediaService.
+func (s *mediaService) Share(ctx context.Context, id string, visibility Visibility, users []string, groups []string) (media Media, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Share", "media.id", id, "media.visibility", string(visibility))
+	defer func() { span.Finish(err) }()
+
+	return s.service.Share(ctx, id, visibility, users, groups)
+}
+
+// Replace implements media.MediaService.
+func (s *mediaService) Replace(ctx context.Context, id string, fileContent []byte, mimetype string) (media Media, tags []Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Replace", "media.id", id, "media.size", len(fileContent), "media.mimetype", mimetype)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Replace(ctx, id, fileContent, mimetype)
+}
+
+// Delete implements media.MediaService.
+func (s *mediaService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Delete", "media.id", id)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Delete(ctx, id)
+}
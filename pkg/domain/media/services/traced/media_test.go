@@ -0,0 +1,73 @@
+package traced
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	"github.com/Taluu/media-go/pkg/domain/media/services/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+func TestMediaService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.NewTracer(exporter)
+
+	service := NewMediaService(media.NewMediaService(
+		adapters.NewTracedMediaRepository(adapters.NewFakeMediaRepository(), tracer),
+		adapters.NewTracedTagRegistry(adapters.NewFakeTagRegistry(), tracer),
+		adapters.NewTracedUploader(adapters.NewFakeUploader(), tracer),
+	), tracer)
+
+	created, _, err := service.Create(ctx, "media", nil, []byte("content"), "text/plain")
+	if err != nil {
+		t.Fatalf("expected the media to be created, got %s", err)
+	}
+
+	spans := exporter.Spans()
+	if len(spans) == 0 {
+		t.Fatalf("expected spans to be exported, got none")
+	}
+
+	// the span of the service finishes last, after the ones of its adapters
+	root := spans[len(spans)-1]
+	if root.Name != "MediaService.Create" {
+		t.Fatalf("expected the last span to be \"MediaService.Create\", got %q", root.Name)
+	}
+
+	if root.Attributes["media.id"] != created.ID {
+		t.Errorf("expected the span to hold the id of the media %q, got %v", created.ID, root.Attributes["media.id"])
+	}
+
+	names := make(map[string]bool)
+	for _, span := range spans[:len(spans)-1] {
+		names[span.Name] = true
+
+		if span.Parent != root.Context.SpanID {
+			t.Errorf("expected the span %q to be a child of the span of the service", span.Name)
+		}
+	}
+
+	for _, name := range []string{"MediaRepository.Create", "MediaUploader.Upload"} {
+		if !names[name] {
+			t.Errorf("expected a %q span, got %v", name, names)
+		}
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		exporter.Reset()
+
+		if _, _, err := service.Get(ctx, "unknown"); err == nil {
+			t.Fatalf("expected an error for an unknown media")
+		}
+
+		spans := exporter.Spans()
+		if root := spans[len(spans)-1]; root.Err == nil {
+			t.Errorf("expected the span of the service to record the error")
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package traced
+
+import (
+	"context"
+
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// NewTagService decorates a tag service so that each of its calls is traced
+// in a span, the spans of its adapters being its children.
+func NewTagService(service TagService, tracer *tracing.Tracer) TagService {
+	return &tagService{service, tracer}
+}
+
+type tagService struct {
+	service TagService
+	tracer  *tracing.Tracer
+}
+
+// GetAll implements media.TagService.
+func (s *tagService) GetAll(ctx context.Context) (tags []Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.GetAll")
+	defer func() { span.Finish(err) }()
+
+	return s.service.GetAll(ctx)
+}
+
+// Create implements media.TagService.
+func (s *tagService) Create(ctx context.Context, name string) (tag Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.Create", "tag", name)
+	defer func() { span.Finish(err) }()
+
+	return s.service.Create(ctx, name)
+}
@@ -0,0 +1,153 @@
+package upload
+
+import (
+	"context"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	//lint:ignore ST1001 it's the domain
+	. "github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// NewUploadService returns a service handling resumable uploads, expiring
+// after the given duration. Completed uploads are created as medias through
+// the given media service.
+func NewUploadService(repository UploadRepository, medias MediaService, expiration time.Duration, options ...Option) UploadService {
+	s := &service{
+		UploadRepository: repository,
+		medias:           medias,
+		expiration:       expiration,
+		now:              time.Now,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// Option configures the optional features of the service
+type Option func(*service)
+
+// WithQuotas rejects the uploads whose media would exceed the quotas, as soon
+// as they are started ; the media is then accounted once it is created.
+func WithQuotas(quotas QuotaService) Option {
+	return func(s *service) {
+		s.quotas = quotas
+	}
+}
+
+type service struct {
+	UploadRepository
+	medias     MediaService
+	quotas     QuotaService
+	expiration time.Duration
+	now        func() time.Time
+}
+
+// Start implements media.UploadService.
+func (s *service) Start(ctx context.Context, upload Upload) (Upload, error) {
+	principal, _ := auth.PrincipalFrom(ctx)
+	if s.quotas != nil {
+		if err := s.quotas.Check(ctx, principal.ID, Usage{Bytes: upload.Length, Medias: 1}); err != nil {
+			return Upload{}, err
+		}
+	}
+
+	upload.ExpiresAt = s.now().Add(s.expiration)
+	upload.Tenant = auth.TenantFrom(ctx)
+	upload.Owner = principal.ID
+
+	upload, err := s.UploadRepository.Create(ctx, upload)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	logging.LoggerFrom(ctx).Info("upload started", "upload_id", upload.ID, "length", upload.Length)
+	return upload, nil
+}
+
+// Get implements media.UploadService.
+// Subtle: this method shadows the method (UploadRepository).Get of service.UploadRepository.
+func (s *service) Get(ctx context.Context, id string) (Upload, error) {
+	upload, err := s.UploadRepository.Get(ctx, id)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	// an upload can only be resumed by whoever started it
+	principal, _ := auth.PrincipalFrom(ctx)
+	if upload.Tenant != auth.TenantFrom(ctx) || upload.Owner != principal.ID {
+		return Upload{}, UploadNotFound(id)
+	}
+
+	// a completed upload is kept until it expires, so that the client can
+	// still check its offset
+	if s.now().After(upload.ExpiresAt) {
+		return Upload{}, UploadExpired(id)
+	}
+
+	return upload, nil
+}
+
+// Append implements media.UploadService.
+// Subtle: this method shadows the method (UploadRepository).Append of service.UploadRepository.
+func (s *service) Append(ctx context.Context, id string, offset int64, chunk []byte) (Upload, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return Upload{}, err
+	}
+
+	upload, err := s.UploadRepository.Append(ctx, id, offset, chunk)
+	if err != nil || !upload.Complete() || upload.MediaID != "" {
+		return upload, err
+	}
+
+	content, err := s.Content(ctx, id)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	// the principal of the context being the owner of the upload, the media is
+	// created on their behalf
+	media, _, err := s.medias.Create(ctx, upload.Name, upload.Tags, content, upload.Mimetype)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	logging.LoggerFrom(ctx).Info("upload completed", "upload_id", id, "media_id", media.ID)
+
+	upload.MediaID = media.ID
+	return upload, s.UploadRepository.Complete(ctx, id, media.ID)
+}
+
+// Terminate implements media.UploadService.
+func (s *service) Terminate(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	return s.Delete(ctx, id)
+}
+
+// Expire implements media.UploadService.
+func (s *service) Expire(ctx context.Context) error {
+	uploads, err := s.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	for id, upload := range uploads {
+		if now.After(upload.ExpiresAt) {
+			if err := s.Delete(ctx, id); err != nil {
+				return err
+			}
+
+			logging.LoggerFrom(ctx).Info("upload expired", "upload_id", id)
+		}
+	}
+
+	return nil
+}
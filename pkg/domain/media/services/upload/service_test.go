@@ -0,0 +1,218 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/domain/media"
+	"github.com/Taluu/media-go/pkg/domain/media/adapters"
+	uploadFake "github.com/Taluu/media-go/pkg/domain/media/adapters/upload/fake"
+	mediaService "github.com/Taluu/media-go/pkg/domain/media/services/media"
+	"github.com/Taluu/media-go/pkg/domain/media/services/quota"
+)
+
+func TestAppend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fakeUploader := adapters.NewFakeUploader()
+	medias := mediaService.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), fakeUploader)
+	service := NewUploadService(uploadFake.NewFake(), medias, time.Hour)
+
+	upload, err := service.Start(ctx, media.Upload{Length: 10, Name: "media-1", Tags: []string{"tag-1"}, Mimetype: "text/plain"})
+	if err != nil {
+		t.Fatalf("an error ocurred while starting the upload : %s", err)
+	}
+
+	if upload.ExpiresAt.Before(time.Now()) {
+		t.Fatalf("expected the upload to expire in the future, got %s", upload.ExpiresAt)
+	}
+
+	upload, err = service.Append(ctx, upload.ID, 0, []byte("hello"))
+	if err != nil {
+		t.Fatalf("an error ocurred while appending a chunk : %s", err)
+	}
+
+	if upload.MediaID != "" {
+		t.Fatalf("expected no media to be created before the upload is complete, got %q", upload.MediaID)
+	}
+
+	upload, err = service.Append(ctx, upload.ID, 5, []byte("world"))
+	if err != nil {
+		t.Fatalf("an error ocurred while appending the last chunk : %s", err)
+	}
+
+	if upload.MediaID == "" {
+		t.Fatalf("expected a media to be created once the upload is complete")
+	}
+
+	created, tags, err := medias.Get(ctx, upload.MediaID)
+	if err != nil {
+		t.Fatalf("expected the media %q to exist, got %s", upload.MediaID, err)
+	}
+
+	if created.Name != "media-1" || created.Mimetype != "text/plain" || len(tags) != 1 {
+		t.Fatalf("expected the media to be created from the upload metadata, got %+v with tags %v", created, tags)
+	}
+
+	content, _ := fakeUploader.GetContent(ctx, upload.MediaID)
+	if string(content) != "helloworld" {
+		t.Fatalf("expected the uploaded content to be %q, got %q", "helloworld", content)
+	}
+
+	upload, err = service.Get(ctx, upload.ID)
+	if err != nil || upload.MediaID != created.ID {
+		t.Fatalf("expected the completed upload to still be available, got %+v (%v)", upload, err)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	repository := uploadFake.NewFake()
+	medias := mediaService.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	service := NewUploadService(repository, medias, time.Hour).(*service)
+
+	expired, _ := service.Start(ctx, media.Upload{Length: 10})
+
+	now := time.Now()
+	service.now = func() time.Time { return now.Add(2 * time.Hour) }
+	fresh, _ := service.Start(ctx, media.Upload{Length: 10})
+
+	if _, err := service.Get(ctx, expired.ID); !errors.Is(err, media.ErrUploadExpired) {
+		t.Fatalf("expected an expired upload error, got %s", err)
+	}
+
+	if _, err := service.Append(ctx, expired.ID, 0, []byte("hello")); !errors.Is(err, media.ErrUploadExpired) {
+		t.Fatalf("expected an expired upload error, got %s", err)
+	}
+
+	if err := service.Expire(ctx); err != nil {
+		t.Fatalf("an error ocurred while expiring the uploads : %s", err)
+	}
+
+	if _, err := repository.Get(ctx, expired.ID); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Fatalf("expected the expired upload to be deleted, got %s", err)
+	}
+
+	if _, err := repository.Get(ctx, fresh.ID); err != nil {
+		t.Fatalf("expected the fresh upload to be kept, got %s", err)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	medias := mediaService.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	service := NewUploadService(uploadFake.NewFake(), medias, time.Hour)
+
+	upload, _ := service.Start(ctx, media.Upload{Length: 10})
+
+	if err := service.Terminate(ctx, upload.ID); err != nil {
+		t.Fatalf("an error ocurred while terminating the upload : %s", err)
+	}
+
+	if _, err := service.Get(ctx, upload.ID); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Fatalf("expected a not found error, got %s", err)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	acme := auth.WithTenant(ctx, "acme")
+	globex := auth.WithTenant(ctx, "globex")
+
+	medias := mediaService.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	service := NewUploadService(uploadFake.NewFake(), medias, time.Hour)
+
+	upload, err := service.Start(acme, media.Upload{Length: 5, Name: "media-1", Mimetype: "text/plain"})
+	if err != nil {
+		t.Fatalf("an error ocurred while starting the upload : %s", err)
+	}
+
+	if _, err := service.Get(globex, upload.ID); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Errorf("expected the upload of another tenant not to be found, got %v", err)
+	}
+
+	if _, err := service.Append(globex, upload.ID, 0, []byte("hello")); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Errorf("expected the upload of another tenant not to be appended to, got %v", err)
+	}
+
+	if err := service.Terminate(ctx, upload.ID); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Errorf("expected the upload of another tenant not to be terminated, got %v", err)
+	}
+
+	if _, err := service.Append(acme, upload.ID, 0, []byte("hello")); err != nil {
+		t.Errorf("unexpected error while appending to the upload of the tenant : %s", err)
+	}
+}
+
+func TestOwners(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	owner := auth.WithPrincipal(ctx, auth.Principal{ID: "owner"})
+	stranger := auth.WithPrincipal(ctx, auth.Principal{ID: "stranger"})
+
+	medias := mediaService.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader())
+	service := NewUploadService(uploadFake.NewFake(), medias, time.Hour)
+
+	upload, err := service.Start(owner, media.Upload{Length: 5, Name: "media-1", Mimetype: "text/plain"})
+	if err != nil {
+		t.Fatalf("an error ocurred while starting the upload : %s", err)
+	}
+
+	if upload.Owner != "owner" {
+		t.Errorf("expected the upload to be owned by its principal, got %q", upload.Owner)
+	}
+
+	if _, err := service.Get(stranger, upload.ID); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Errorf("expected the upload of another principal not to be found, got %v", err)
+	}
+
+	if _, err := service.Append(stranger, upload.ID, 0, []byte("hello")); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Errorf("expected the upload of another principal not to be appended to, got %v", err)
+	}
+
+	if err := service.Terminate(stranger, upload.ID); !errors.Is(err, media.ErrUploadNotFound) {
+		t.Errorf("expected the upload of another principal not to be terminated, got %v", err)
+	}
+
+	upload, err = service.Append(owner, upload.ID, 0, []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error while appending to the upload of the principal : %s", err)
+	}
+
+	created, _, err := medias.Get(owner, upload.MediaID)
+	if err != nil {
+		t.Fatalf("an error ocurred while fetching the media : %s", err)
+	}
+
+	if created.Access.Owner != "owner" {
+		t.Errorf("expected the media to be owned by the owner of the upload, got %q", created.Access.Owner)
+	}
+}
+
+func TestStartQuotas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quotas := quota.NewQuotaService(adapters.NewFakeQuotaRepository(), media.Usage{}, media.Usage{Bytes: 10})
+	medias := mediaService.NewMediaService(adapters.NewFakeMediaRepository(), adapters.NewFakeTagRegistry(), adapters.NewFakeUploader(), mediaService.WithQuotas(quotas))
+	service := NewUploadService(uploadFake.NewFake(), medias, time.Hour, WithQuotas(quotas))
+
+	if _, err := service.Start(ctx, media.Upload{Length: 11, Name: "media-1"}); !errors.Is(err, media.ErrLargerThanQuota) {
+		t.Errorf("expected an upload larger than the quota to be rejected when started, got %v", err)
+	}
+
+	if _, err := service.Start(ctx, media.Upload{Length: 10, Name: "media-1"}); err != nil {
+		t.Errorf("unexpected error while starting an upload within the quota : %s", err)
+	}
+}
@@ -14,6 +14,9 @@ type TagRegistry interface {
 	GetTagsForMedias(ctx context.Context, mediasID ...string) (map[string][]Tag, error)
 	Create(ctx context.Context, name string) (Tag, error)
 	Link(ctx context.Context, tagID, mediaID string) error
+	// Unlink removes the link between a tag and a media, if there is one. The
+	// tag itself is kept.
+	Unlink(ctx context.Context, tagID, mediaID string) error
 }
 
 type TagService interface {
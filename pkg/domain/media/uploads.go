@@ -0,0 +1,63 @@
+package media
+
+import (
+	"context"
+	"time"
+)
+
+// Upload is a resumable upload, whose content is sent in several chunks.
+// Once complete, a media is created out of it.
+type Upload struct {
+	ID        string
+	Length    int64
+	Offset    int64
+	ExpiresAt time.Time
+
+	// what the media will be created with
+	Name     string
+	Tags     []string
+	Mimetype string
+
+	// Metadata are the raw metadata given by the client when the upload was
+	// created
+	Metadata map[string]string
+
+	// MediaID is the id of the created media, once the upload is complete
+	MediaID string
+	// Tenant the upload, and then its media, belongs to
+	Tenant string
+	// Owner is the id of the principal who started the upload, the only one
+	// who can resume it, and who will own its media
+	Owner string
+}
+
+func (u Upload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+type UploadRepository interface {
+	Create(ctx context.Context, upload Upload) (Upload, error)
+	// Get returns a ErrUploadNotFound if the upload doesn't exist
+	Get(ctx context.Context, id string) (Upload, error)
+	// Append appends a chunk at the given offset, which must be the current
+	// offset of the upload, and returns the updated upload.
+	Append(ctx context.Context, id string, offset int64, chunk []byte) (Upload, error)
+	// Content returns the content received so far
+	Content(ctx context.Context, id string) ([]byte, error)
+	// Complete marks the upload as complete, discarding its content
+	Complete(ctx context.Context, id string, mediaID string) error
+	Delete(ctx context.Context, id string) error
+	GetAll(ctx context.Context) (map[string]Upload, error)
+}
+
+type UploadService interface {
+	Start(ctx context.Context, upload Upload) (Upload, error)
+	// Get returns a ErrUploadExpired if the upload has expired
+	Get(ctx context.Context, id string) (Upload, error)
+	// Append appends a chunk to the upload. When the upload is complete, its
+	// media is created, and its id set on the returned upload.
+	Append(ctx context.Context, id string, offset int64, chunk []byte) (Upload, error)
+	Terminate(ctx context.Context, id string) error
+	// Expire deletes the expired uploads
+	Expire(ctx context.Context) error
+}
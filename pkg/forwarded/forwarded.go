@@ -0,0 +1,184 @@
+// Package forwarded tells what the clients behind the trusted proxies sent,
+// from the Forwarded (RFC 7239) and X-Forwarded-* headers of their requests.
+package forwarded
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Origin returns the scheme and the host the client sent the request to,
+// as told by the proxies it went through. Each proxy appends what it received
+// to the Forwarded (or X-Forwarded-*) headers, so only the values appended by
+// the trusted proxies are used, up to the outermost one. Both are empty if
+// the request doesn't come from a trusted proxy.
+func Origin(r *http.Request, proxies []netip.Prefix) (scheme string, host string) {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok || !trusted(peer, proxies) {
+		return "", ""
+	}
+
+	if header := r.Header.Values("Forwarded"); len(header) > 0 {
+		elements := parseForwarded(header)
+
+		// walk back from the element appended by the peer, as long as the
+		// proxy which appended it is trusted
+		chosen := -1
+		for i := len(elements) - 1; i >= 0; i-- {
+			chosen = i
+
+			client, ok := parseAddr(elements[i]["for"])
+			if !ok || !trusted(client, proxies) {
+				break
+			}
+		}
+
+		if chosen < 0 {
+			return "", ""
+		}
+
+		return validScheme(elements[chosen]["proto"]), validHost(elements[chosen]["host"])
+	}
+
+	// the same way, the X-Forwarded-For chain tells how many trusted proxies
+	// appended their values
+	hops := 1
+	chain := splitList(r.Header.Values("X-Forwarded-For"))
+	for i := len(chain) - 1; i > 0; i-- {
+		client, ok := parseAddr(chain[i])
+		if !ok || !trusted(client, proxies) {
+			break
+		}
+
+		hops++
+	}
+
+	return validScheme(appendedBy(splitList(r.Header.Values("X-Forwarded-Proto")), hops)),
+		validHost(appendedBy(splitList(r.Header.Values("X-Forwarded-Host")), hops))
+}
+
+// Client returns the address of the client behind the trusted proxies, the
+// one of the peer if it is not a trusted proxy. As for the origin, only the
+// addresses appended by the trusted proxies are used : the client is the
+// first untrusted one, walking back from the peer.
+func Client(r *http.Request, proxies []netip.Prefix) string {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+
+	if !trusted(peer, proxies) {
+		return peer.String()
+	}
+
+	chain := make([]string, 0)
+	if header := r.Header.Values("Forwarded"); len(header) > 0 {
+		for _, element := range parseForwarded(header) {
+			chain = append(chain, element["for"])
+		}
+	} else {
+		chain = splitList(r.Header.Values("X-Forwarded-For"))
+	}
+
+	// an address that can't be parsed, such as unknown or an obfuscated
+	// one, can't be told apart from the others, so the proxy which appended
+	// it stands for the client
+	client := peer
+	for i := len(chain) - 1; i >= 0 && trusted(client, proxies); i-- {
+		addr, ok := parseAddr(chain[i])
+		if !ok {
+			break
+		}
+
+		client = addr
+	}
+
+	return client.String()
+}
+
+// parseForwarded parses the elements of the Forwarded headers (RFC 7239),
+// such as for=192.0.2.60;proto=https;host=example.com, their parameters being
+// lower cased
+func parseForwarded(header []string) []map[string]string {
+	elements := make([]map[string]string, 0)
+	for _, element := range splitList(header) {
+		parameters := make(map[string]string)
+		for _, pair := range strings.Split(element, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found {
+				continue
+			}
+
+			parameters[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+
+		elements = append(elements, parameters)
+	}
+
+	return elements
+}
+
+// appendedBy returns the value appended by the outermost of the trusted
+// proxies, the last one if there are less values than proxies
+func appendedBy(values []string, hops int) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[max(len(values)-hops, 0)]
+}
+
+// splitList splits the comma separated values of the headers
+func splitList(header []string) []string {
+	values := make([]string, 0)
+	for _, line := range header {
+		for _, value := range strings.Split(line, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// parseAddr parses an address with or without a port, such as 192.0.2.60,
+// 192.0.2.60:4711 or [2001:db8::1]:4711
+func parseAddr(address string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(address); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(address, "[]"))
+	return addr.Unmap(), err == nil
+}
+
+func trusted(addr netip.Addr, proxies []netip.Prefix) bool {
+	for _, proxy := range proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validScheme(scheme string) string {
+	scheme = strings.ToLower(scheme)
+	if scheme != "http" && scheme != "https" {
+		return ""
+	}
+
+	return scheme
+}
+
+// validHost discards the hosts which would change more than the host of the
+// links, such as example.com/path or user@example.com
+func validHost(host string) string {
+	if strings.ContainsAny(host, "/\\?#@ \t") {
+		return ""
+	}
+
+	return host
+}
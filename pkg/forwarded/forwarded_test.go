@@ -0,0 +1,85 @@
+package forwarded
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{
+			name:       "direct request",
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "untrusted peer",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.0.2.60"}},
+			expected:   "192.0.2.1",
+		},
+		{
+			name:       "forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=192.0.2.60;proto=https"}},
+			expected:   "192.0.2.60",
+		},
+		{
+			name:       "forwarded by a chain of trusted proxies",
+			remoteAddr: "[2001:db8::2]:1234",
+			headers:    map[string][]string{"Forwarded": {"for=192.0.2.60", `for="[2001:db8::1]:4711"`}},
+			expected:   "192.0.2.60",
+		},
+		{
+			name:       "forwarded element forged by the client",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=192.0.2.1, for=192.0.2.60"}},
+			expected:   "192.0.2.60",
+		},
+		{
+			name:       "unknown client",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"Forwarded": {"for=unknown, for=10.0.0.1"}},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "x-forwarded-for by a chain of trusted proxies",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.0.2.60, 10.0.0.1"}},
+			expected:   "192.0.2.60",
+		},
+		{
+			name:       "x-forwarded-for forged by the client",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.5, 192.0.2.60"}},
+			expected:   "192.0.2.60",
+		},
+		{
+			name:       "only trusted proxies",
+			remoteAddr: "10.0.0.2:1234",
+			expected:   "10.0.0.2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/medias/tag-1", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for name, values := range tc.headers {
+				r.Header[name] = values
+			}
+
+			if client := Client(r, proxies); client != tc.expected {
+				t.Errorf("expected the client %q, got %q", tc.expected, client)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package logging carries a structured logger in the contexts, so that what
+// is logged while handling a request can be correlated with it.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// NewLogger returns a logger writing a json object per line
+func NewLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of the context carrying the logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the logger carried by the context, or the default one
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// With returns a copy of the context whose logger adds the given attributes
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, LoggerFrom(ctx).With(args...))
+}
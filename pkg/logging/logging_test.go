@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLoggerFrom(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if LoggerFrom(ctx) != slog.Default() {
+		t.Errorf("expected the default logger without any logger in the context")
+	}
+
+	var output bytes.Buffer
+	ctx = WithLogger(ctx, NewLogger(&output, slog.LevelInfo))
+	ctx = With(ctx, "request_id", "request-1")
+
+	LoggerFrom(ctx).Debug("ignored")
+	LoggerFrom(ctx).Info("media created", "media_id", "media-1")
+
+	var line map[string]any
+	if err := json.Unmarshal(output.Bytes(), &line); err != nil {
+		t.Fatalf("expected a single json line, got %q : %s", output.String(), err)
+	}
+
+	expected := map[string]any{"level": "INFO", "msg": "media created", "request_id": "request-1", "media_id": "media-1"}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, line[key])
+		}
+	}
+}
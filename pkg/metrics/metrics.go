@@ -0,0 +1,288 @@
+// Package metrics exposes counters, histograms and gauges in the Prometheus
+// text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the histograms of
+// latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry holds the metrics, and serves them in the Prometheus text format
+type Registry struct {
+	metrics map[string]metric
+	mtx     sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds a metric, panicking if its name is already taken, as it is a
+// programming error
+func (r *Registry) register(name string, m metric) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, exists := r.metrics[name]; exists {
+		panic(fmt.Sprintf("metric %q already registered", name))
+	}
+
+	r.metrics[name] = m
+}
+
+// NewCounter registers a counter, whose series are identified by the values
+// of the given labels
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, "counter", labels)}
+	r.register(name, c)
+
+	return c
+}
+
+// NewHistogram registers a histogram counting the observations up to each of
+// the given (sorted) upper bounds
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(name, h)
+
+	return h
+}
+
+// NewGaugeFunc registers a gauge whose value is computed each time the
+// metrics are collected
+func (r *Registry) NewGaugeFunc(name string, help string, value func() float64) {
+	r.register(name, &gaugeFunc{family: newFamily(name, help, "gauge", nil), value: value})
+}
+
+// WriteText writes the metrics, sorted by name, in the Prometheus text format
+func (r *Registry) WriteText(w io.Writer) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	for _, name := range names {
+		r.metrics[name].write(w)
+	}
+}
+
+// ServeHTTP serves the metrics to a Prometheus server
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// family holds what the series of a metric share
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	mtx    sync.Mutex
+}
+
+func newFamily(name string, help string, kind string, labels []string) family {
+	return family{name: name, help: help, kind: kind, labels: labels}
+}
+
+// key identifies the series of the label values
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %q expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+func (f *family) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escape(f.help, false))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// labelPairs formats the labels of a series, with the extra ones given as
+// name and value pairs
+func (f *family) labelPairs(values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, label := range f.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label, escape(values[i], true)))
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra[i], escape(extra[i+1], true)))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	family
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+// Add adds a positive value to the series of the label values
+func (c *Counter) Add(value float64, values ...string) {
+	key := c.key(values)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.series == nil {
+		c.series = make(map[string]*counterSeries)
+	}
+
+	series, exists := c.series[key]
+	if !exists {
+		series = &counterSeries{values: values}
+		c.series[key] = series
+	}
+
+	series.value += value
+}
+
+// Inc adds one to the series of the label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(series.values), formatFloat(series.value))
+	}
+}
+
+// Histogram counts the observations in buckets
+type Histogram struct {
+	family
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe adds a value to the series of the label values
+func (h *Histogram) Observe(value float64, values ...string) {
+	key := h.key(values)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.series == nil {
+		h.series = make(map[string]*histogramSeries)
+	}
+
+	series, exists := h.series[key]
+	if !exists {
+		series = &histogramSeries{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+
+	series.count++
+	series.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(series.values, "le", formatFloat(bound)), series.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(series.values, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(series.values), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(series.values), series.count)
+	}
+}
+
+type gaugeFunc struct {
+	family
+	value func() float64
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.value()))
+}
+
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// escape escapes the backslashes and the new lines, and also the double quotes
+// in the label values
+func escape(value string, quotes bool) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	if quotes {
+		value = strings.ReplaceAll(value, `"`, `\"`)
+	}
+
+	return value
+}
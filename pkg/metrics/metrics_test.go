@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	requests := registry.NewCounter("http_requests_total", "Number of handled requests", "route", "status")
+	requests.Inc("GET /tags", "200")
+	requests.Add(2, "POST /tags", "201")
+	requests.Inc("GET /tags", "200")
+	requests.Inc("GET \"quoted\"\n", "500")
+
+	durations := registry.NewHistogram("http_request_duration_seconds", "Duration of the requests", []float64{0.1, 1}, "route")
+	durations.Observe(0.05, "GET /tags")
+	durations.Observe(0.5, "GET /tags")
+	durations.Observe(5, "GET /tags")
+
+	registry.NewGaugeFunc("medias", "Number of medias", func() float64 { return 12 })
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := `# HELP http_request_duration_seconds Duration of the requests
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="GET /tags",le="0.1"} 1
+http_request_duration_seconds_bucket{route="GET /tags",le="1"} 2
+http_request_duration_seconds_bucket{route="GET /tags",le="+Inf"} 3
+http_request_duration_seconds_sum{route="GET /tags"} 5.55
+http_request_duration_seconds_count{route="GET /tags"} 3
+# HELP http_requests_total Number of handled requests
+# TYPE http_requests_total counter
+http_requests_total{route="GET \"quoted\"\n",status="500"} 1
+http_requests_total{route="GET /tags",status="200"} 2
+http_requests_total{route="POST /tags",status="201"} 2
+# HELP medias Number of medias
+# TYPE medias gauge
+medias 12
+`
+
+	if w.Body.String() != expected {
+		t.Errorf("expected the metrics\n%s\ngot\n%s", expected, w.Body.String())
+	}
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("expected the prometheus text format, got %q", contentType)
+	}
+}
+
+func TestRegistryMisuse(t *testing.T) {
+	testCases := []struct {
+		name string
+		use  func(registry *Registry)
+	}{
+		{name: "duplicated name", use: func(registry *Registry) {
+			registry.NewCounter("medias", "")
+			registry.NewGaugeFunc("medias", "", func() float64 { return 0 })
+		}},
+		{name: "missing label value", use: func(registry *Registry) {
+			registry.NewCounter("requests", "", "route", "status").Inc("GET /tags")
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic")
+				}
+			}()
+
+			tc.use(NewRegistry())
+		})
+	}
+}
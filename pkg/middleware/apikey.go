@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Taluu/media-go/pkg/auth"
+)
+
+// NewAPIKeyAuthenticator authenticates the requests holding one of the given
+// keys, either in a X-Api-Key header or as an "ApiKey" authorization.
+func NewAPIKeyAuthenticator(keys map[string]auth.Principal) Authenticator {
+	hashed := make(map[[sha256.Size]byte]auth.Principal, len(keys))
+	for key, principal := range keys {
+		hashed[sha256.Sum256([]byte(key))] = principal
+	}
+
+	return &apiKeyAuthenticator{hashed}
+}
+
+// LoadAPIKeys reads the keys from a json file, holding an object whose keys
+// are the api keys, and values the principals they authenticate :
+//
+//	{"secret-key": {"id": "uploader-bot", "roles": ["uploader"], "groups": ["bots"]}}
+//
+// Every principal must have an id, as it is what identifies who did what.
+func LoadAPIKeys(path string) (map[string]auth.Principal, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file map[string]struct {
+		ID     string   `json:"id"`
+		Roles  []string `json:"roles"`
+		Groups []string `json:"groups"`
+		Tenant string   `json:"tenant"`
+	}
+
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("invalid api keys file %q : %w", path, err)
+	}
+
+	keys := make(map[string]auth.Principal, len(file))
+	for key, principal := range file {
+		// the key itself is a secret, it is not worth leaking in the logs
+		if principal.ID == "" {
+			return nil, fmt.Errorf("invalid api keys file %q : a principal has no id", path)
+		}
+
+		keys[key] = auth.Principal{ID: principal.ID, Roles: principal.Roles, Groups: principal.Groups, Tenant: principal.Tenant}
+	}
+
+	return keys, nil
+}
+
+type apiKeyAuthenticator struct {
+	// the keys are hashed, so that the time taken to look them up doesn't
+	// tell anything about them
+	keys map[[sha256.Size]byte]auth.Principal
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (auth.Principal, error) {
+	key := apiKey(r)
+	if key == "" {
+		return auth.Principal{}, ErrNoCredentials
+	}
+
+	principal, exists := a.keys[sha256.Sum256([]byte(key))]
+	if !exists {
+		return auth.Principal{}, fmt.Errorf("%w : unknown api key", ErrInvalidCredentials)
+	}
+
+	return principal, nil
+}
+
+// apiKey returns the api key of the request, if any
+func apiKey(r *http.Request) string {
+	if scheme, credentials, found := strings.Cut(r.Header.Get("Authorization"), " "); found && strings.EqualFold(scheme, "ApiKey") {
+		return credentials
+	}
+
+	return r.Header.Get("X-Api-Key")
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+var (
+	// ErrNoCredentials is returned by an authenticator when the request does
+	// not hold the credentials it handles
+	ErrNoCredentials      = fmt.Errorf("no credentials")
+	ErrInvalidCredentials = fmt.Errorf("invalid credentials")
+)
+
+// Authenticator finds out who is behind a request
+type Authenticator interface {
+	Authenticate(r *http.Request) (auth.Principal, error)
+}
+
+// AuthMiddleware authenticates the requests with the first authenticator
+// finding credentials in it, and stores the principal in the context of the
+// request. Requests with invalid credentials are rejected with a 401, as are
+// the anonymous ones if the authentication is required.
+func AuthMiddleware(required bool, authenticators ...Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+
+				if err != nil {
+					logging.LoggerFrom(r.Context()).Warn("could not authenticate the request", "error", err)
+					unauthorized(w, "invalid credentials")
+					return
+				}
+
+				ctx := logging.With(auth.WithPrincipal(r.Context(), principal), "principal", principal.ID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if required {
+				unauthorized(w, "authentication required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unauthorized sends a 401, in the same json format as the other errors
+func unauthorized(w http.ResponseWriter, error string) {
+	w.Header().Set("WWW-Authenticate", `Bearer, ApiKey`)
+	jsonError(w, error, http.StatusUnauthorized)
+}
+
+// jsonError sends an error in the same json format as the ports
+func jsonError(w http.ResponseWriter, error string, code int) {
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(struct {
+		Code  int    `json:"code"`
+		Error string `json:"error"`
+	}{code, error})
+}
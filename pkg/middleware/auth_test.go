@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+)
+
+func encodeSegment(v any) string {
+	content, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func signHS256(secret []byte, header map[string]any, claims map[string]any) string {
+	signed := encodeSegment(header) + "." + encodeSegment(claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := encodeSegment(map[string]any{"alg": "RS256", "kid": kid}) + "." + encodeSegment(claims)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	content, _ := json.Marshal(map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+
+	os.WriteFile(path, content, 0644)
+	return path
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	keys, err := LoadJWKS(writeJWKS(t, "key-1", &rsaKey.PublicKey))
+	if err != nil {
+		t.Fatalf("unexpected error while loading the jwks : %s", err)
+	}
+
+	apiKeys := map[string]auth.Principal{
+		"key-123": {ID: "bot", Roles: []string{"uploader"}},
+	}
+
+	authenticators := []Authenticator{
+		NewAPIKeyAuthenticator(apiKeys),
+		NewJWTAuthenticator(JWTConfig{Secret: secret, Keys: keys, Issuer: "issuer", Audience: "medias", Leeway: time.Second}),
+	}
+
+	// echoes the principal found in the context
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFrom(r.Context())
+		if !ok {
+			principal.ID = "anonymous"
+		}
+
+		json.NewEncoder(w).Encode(principal)
+	})
+
+	now := time.Now().Unix()
+	valid := map[string]any{"sub": "john", "iss": "issuer", "aud": []string{"medias", "other"}, "exp": now + 60, "groups": []string{"editors"}}
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+
+	claims := func(overrides map[string]any) map[string]any {
+		result := make(map[string]any)
+		for k, v := range valid {
+			result[k] = v
+		}
+
+		for k, v := range overrides {
+			result[k] = v
+		}
+
+		return result
+	}
+
+	testCases := []struct {
+		name          string
+		required      bool
+		headers       map[string]string
+		expectedCode  int
+		expectedID    string
+		expectedError string
+	}{
+		{name: "anonymous", expectedCode: 200, expectedID: "anonymous"},
+		{name: "anonymous when required", required: true, expectedCode: 401, expectedError: "authentication required"},
+		{name: "api key header", required: true, headers: map[string]string{"X-Api-Key": "key-123"}, expectedCode: 200, expectedID: "bot"},
+		{name: "api key authorization", required: true, headers: map[string]string{"Authorization": "ApiKey key-123"}, expectedCode: 200, expectedID: "bot"},
+		{name: "unknown api key", headers: map[string]string{"X-Api-Key": "key-456"}, expectedCode: 401, expectedError: "invalid credentials"},
+		{name: "HS256", required: true, headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, valid)}, expectedCode: 200, expectedID: "john"},
+		{name: "RS256", required: true, headers: map[string]string{"Authorization": "Bearer " + signRS256(rsaKey, "key-1", valid)}, expectedCode: 200, expectedID: "john"},
+		{name: "single audience", headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, claims(map[string]any{"aud": "medias"}))}, expectedCode: 200, expectedID: "john"},
+		{name: "HS256 with another secret", headers: map[string]string{"Authorization": "Bearer " + signHS256([]byte("other"), hs256, valid)}, expectedCode: 401},
+		{name: "RS256 with another key", headers: map[string]string{"Authorization": "Bearer " + signRS256(otherKey, "key-1", valid)}, expectedCode: 401},
+		{name: "RS256 with an unknown key", headers: map[string]string{"Authorization": "Bearer " + signRS256(rsaKey, "key-2", valid)}, expectedCode: 401},
+		{name: "none algorithm", headers: map[string]string{"Authorization": "Bearer " + encodeSegment(map[string]any{"alg": "none"}) + "." + encodeSegment(valid) + "."}, expectedCode: 401},
+		{name: "expired", headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, claims(map[string]any{"exp": now - 60}))}, expectedCode: 401},
+		{name: "no expiration", headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, claims(map[string]any{"exp": nil}))}, expectedCode: 401},
+		{name: "not valid yet", headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, claims(map[string]any{"nbf": now + 60}))}, expectedCode: 401},
+		{name: "other issuer", headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, claims(map[string]any{"iss": "other"}))}, expectedCode: 401},
+		{name: "other audience", headers: map[string]string{"Authorization": "Bearer " + signHS256(secret, hs256, claims(map[string]any{"aud": "other"}))}, expectedCode: 401},
+		{name: "malformed token", headers: map[string]string{"Authorization": "Bearer not-a-token"}, expectedCode: 401},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/tags", nil)
+			for header, value := range tc.headers {
+				r.Header.Set(header, value)
+			}
+
+			w := httptest.NewRecorder()
+			AuthMiddleware(tc.required, authenticators...)(handler).ServeHTTP(w, r)
+			resp := w.Result()
+
+			if resp.StatusCode != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				var gotResponse struct {
+					Code  int    `json:"code"`
+					Error string `json:"error"`
+				}
+
+				json.NewDecoder(resp.Body).Decode(&gotResponse)
+
+				if gotResponse.Code != tc.expectedCode || (tc.expectedError != "" && gotResponse.Error != tc.expectedError) {
+					t.Errorf("expected a %d %q error, got %+v", tc.expectedCode, tc.expectedError, gotResponse)
+				}
+
+				if resp.Header.Get("WWW-Authenticate") == "" {
+					t.Errorf("expected a WWW-Authenticate header")
+				}
+
+				return
+			}
+
+			var principal auth.Principal
+			json.NewDecoder(resp.Body).Decode(&principal)
+
+			if principal.ID != tc.expectedID {
+				t.Errorf("expected the principal %q, got %q", tc.expectedID, principal.ID)
+			}
+		})
+	}
+
+	t.Run("claims", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/tags", nil)
+		r.Header.Set("Authorization", "Bearer "+signHS256(secret, hs256, claims(map[string]any{"roles": []string{"curator"}})))
+
+		principal, err := authenticators[1].Authenticate(r)
+		if err != nil {
+			t.Fatalf("unexpected error : %s", err)
+		}
+
+		if fmt.Sprint(principal.Roles, principal.Groups) != "[curator] [editors]" {
+			t.Errorf("expected the roles and groups to be read from the claims, got %+v", principal)
+		}
+	})
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	t.Run("nominal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		os.WriteFile(path, []byte(`{"key-123": {"id": "bot", "roles": ["uploader"], "groups": ["bots"]}}`), 0644)
+
+		keys, err := LoadAPIKeys(path)
+		if err != nil {
+			t.Fatalf("unexpected error while loading the keys : %s", err)
+		}
+
+		if principal := keys["key-123"]; principal.ID != "bot" || len(principal.Roles) != 1 || len(principal.Groups) != 1 {
+			t.Errorf("expected the key to authenticate the bot, got %+v", principal)
+		}
+	})
+
+	t.Run("missing principal id", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		os.WriteFile(path, []byte(`{"key-123": {"id": "bot"}, "key-456": {"roles": ["admin"]}}`), 0644)
+
+		if _, err := LoadAPIKeys(path); err == nil {
+			t.Errorf("expected the keys without a principal id to be rejected")
+		}
+	})
+}
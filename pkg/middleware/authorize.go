@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// AuthorizeMiddleware rejects with a 403 the requests whose principal is not
+// granted the permission by the policy. It must be wrapped by the
+// AuthMiddleware, so that the principal is known.
+func AuthorizeMiddleware(policy *auth.Policy, permission auth.Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := policy.Authorize(r.Context(), permission); err != nil {
+				logging.LoggerFrom(r.Context()).Warn("could not authorize the request", "error", err)
+				jsonError(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Taluu/media-go/pkg/auth"
+)
+
+func TestAuthorizeMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	testCases := []struct {
+		name         string
+		policy       *auth.Policy
+		principal    *auth.Principal
+		expectedCode int
+	}{
+		{name: "no policy", policy: nil, expectedCode: http.StatusNoContent},
+		{name: "granted role", policy: auth.DefaultPolicy(), principal: &auth.Principal{ID: "alice", Roles: []string{auth.RoleCurator}}, expectedCode: http.StatusNoContent},
+		{name: "admin", policy: auth.DefaultPolicy(), principal: &auth.Principal{ID: "root", Roles: []string{auth.RoleAdmin}}, expectedCode: http.StatusNoContent},
+		{name: "missing role", policy: auth.DefaultPolicy(), principal: &auth.Principal{ID: "bob", Roles: []string{auth.RoleUploader}}, expectedCode: http.StatusForbidden},
+		{name: "no role", policy: auth.DefaultPolicy(), principal: &auth.Principal{ID: "eve"}, expectedCode: http.StatusForbidden},
+		{name: "anonymous", policy: auth.DefaultPolicy(), expectedCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/tags", nil)
+			if tc.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), *tc.principal))
+			}
+
+			w := httptest.NewRecorder()
+			AuthorizeMiddleware(tc.policy, auth.PermissionCreateTags)(next).ServeHTTP(w, r)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, w.Code)
+			}
+
+			if tc.expectedCode != http.StatusForbidden {
+				return
+			}
+
+			var response struct {
+				Code  int    `json:"code"`
+				Error string `json:"error"`
+			}
+
+			json.NewDecoder(w.Body).Decode(&response)
+			if response.Code != http.StatusForbidden || response.Error != "forbidden" {
+				t.Errorf("expected a forbidden json error, got %+v", response)
+			}
+		})
+	}
+}
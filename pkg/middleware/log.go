@@ -1,14 +1,16 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"time"
+
+	"github.com/Taluu/media-go/pkg/logging"
 )
 
 type customResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	written    int64
 }
 
 func (w *customResponseWriter) WriteHeader(statusCode int) {
@@ -16,14 +18,42 @@ func (w *customResponseWriter) WriteHeader(statusCode int) {
 	w.statusCode = statusCode
 }
 
+func (w *customResponseWriter) Write(b []byte) (int, error) {
+	// writing without a status sends a 200
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// status returns the status sent, nothing written at all being an empty 200
+func (w *customResponseWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+
+	return w.statusCode
+}
+
+// LogMiddleware logs each request once handled, with the logger of its
+// context
 func LogMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.LoggerFrom(r.Context()).With("method", r.Method, "path", r.URL.Path)
 		extendedWriter := &customResponseWriter{ResponseWriter: w}
+
 		defer func(start time.Time) {
-			log.Printf("HTTP %s %s status %d, took %s", r.Method, r.URL.Path, extendedWriter.statusCode, time.Since(start))
+			logger.Info("request handled",
+				"status", extendedWriter.status(),
+				"bytes", extendedWriter.written,
+				"duration", time.Since(start),
+			)
 		}(time.Now())
 
-		log.Printf("Received HTTP %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(extendedWriter, r)
+		logger.Debug("request received")
+		next.ServeHTTP(extendedWriter, r.WithContext(logging.WithLogger(r.Context(), logger)))
 	})
 }
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/metrics"
+)
+
+// MetricsMiddleware counts the requests by route and status, observes their
+// durations, and counts the bytes uploaded in their bodies and served in their
+// responses.
+//
+// It must wrap the ServeMux, which tells the route the requests matched : the
+// requests matching none are counted under the "unmatched" route, so that the
+// number of series doesn't depend on the paths requested.
+func MetricsMiddleware(registry *metrics.Registry) func(next http.Handler) http.Handler {
+	requests := registry.NewCounter("http_requests_total", "Number of handled requests", "route", "status")
+	durations := registry.NewHistogram("http_request_duration_seconds", "Duration of the requests, in seconds", metrics.DefaultBuckets, "route", "status")
+	uploaded := registry.NewCounter("http_request_body_bytes_total", "Bytes read from the bodies of the requests", "route")
+	served := registry.NewCounter("http_response_body_bytes_total", "Bytes written in the bodies of the responses", "route")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			extendedWriter := &customResponseWriter{ResponseWriter: w}
+			body := &countingReader{ReadCloser: r.Body}
+			r.Body = body
+
+			start := time.Now()
+			next.ServeHTTP(extendedWriter, r)
+
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+
+			status := strconv.Itoa(extendedWriter.status())
+			requests.Inc(route, status)
+			durations.Observe(time.Since(start).Seconds(), route, status)
+			uploaded.Add(float64(body.read), route)
+			served.Add(float64(extendedWriter.written), route)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Taluu/media-go/pkg/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /medias", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	mux.HandleFunc("GET /medias/{tag}", func(w http.ResponseWriter, r *http.Request) {})
+
+	registry := metrics.NewRegistry()
+	handler := MetricsMiddleware(registry)(mux)
+
+	requests := []*http.Request{
+		httptest.NewRequest("POST", "/medias", strings.NewReader("content")),
+		httptest.NewRequest("POST", "/medias", strings.NewReader("more content")),
+		httptest.NewRequest("GET", "/medias/tag-1", nil),
+		httptest.NewRequest("GET", "/medias/tag-2", nil),
+		httptest.NewRequest("GET", "/unknown/path", nil),
+	}
+
+	for _, r := range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	expectedLines := []string{
+		`http_requests_total{route="POST /medias",status="201"} 2`,
+		`http_requests_total{route="GET /medias/{tag}",status="200"} 2`,
+		`http_requests_total{route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{route="POST /medias",status="201"} 2`,
+		`http_request_body_bytes_total{route="POST /medias"} 19`,
+		`http_response_body_bytes_total{route="POST /medias"} 14`,
+	}
+
+	for _, line := range expectedLines {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Errorf("expected the metrics to hold %q, got\n%s", line, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/forwarded"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// RateLimit is the budget of each client, a zero value meaning no limit
+type RateLimit struct {
+	// Requests is the number of requests per minute
+	Requests int
+	// Bytes is the number of bytes per second of the request bodies. A
+	// request may be larger than this budget : its client then has to wait
+	// for the budget to be refilled before sending another one.
+	Bytes int64
+	// TrustedProxies are the proxies whose Forwarded and X-Forwarded-For
+	// headers tell the address of the anonymous clients
+	TrustedProxies []netip.Prefix
+}
+
+// RateLimitMiddleware limits the requests of each client with token buckets,
+// rejecting the requests over budget with a 429. The clients are identified
+// by their principal, or by their ip address (the one behind the trusted
+// proxies) when they are anonymous, so it must be wrapped by the
+// AuthMiddleware.
+//
+// Each call returns a middleware with its own budgets, so that the routes
+// can be limited separately.
+func RateLimitMiddleware(limit RateLimit) func(next http.Handler) http.Handler {
+	requests := newTokenBuckets(float64(limit.Requests)/60, float64(limit.Requests))
+	bytes := newTokenBuckets(float64(limit.Bytes), float64(limit.Bytes))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := clientKey(r, limit.TrustedProxies)
+
+			if limit.Requests > 0 {
+				allowed, remaining, wait := requests.take(key, time.Now(), 1)
+
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(remaining)))
+				w.Header().Set("RateLimit-Reset", seconds(requests.untilFull(remaining)))
+				w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w=60")
+
+				if !allowed {
+					tooManyRequests(w, r, key, wait)
+					return
+				}
+			}
+
+			if limit.Bytes > 0 {
+				if wait := bytes.debt(key, time.Now()); wait > 0 {
+					tooManyRequests(w, r, key, wait)
+					return
+				}
+
+				body := &countingReader{ReadCloser: r.Body}
+				r.Body = body
+
+				// the bytes are only known once read, and are then taken from
+				// the budget, even if it goes into debt
+				defer func() {
+					bytes.consume(key, time.Now(), float64(body.read))
+				}()
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies who is behind a request
+func clientKey(r *http.Request, proxies []netip.Prefix) string {
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		return "principal:" + principal.Tenant + "/" + principal.ID
+	}
+
+	// the keys of the anonymous requests are not valid ones, so a new one on
+	// each request would get a new budget each time
+	return "ip:" + forwarded.Client(r, proxies)
+}
+
+// tooManyRequests sends a 429, in the same json format as the other errors
+func tooManyRequests(w http.ResponseWriter, r *http.Request, key string, wait time.Duration) {
+	logging.LoggerFrom(r.Context()).Warn("rate limit exceeded", "client", key, "retry_after", wait)
+
+	w.Header().Set("Retry-After", seconds(wait))
+	jsonError(w, "too many requests", http.StatusTooManyRequests)
+}
+
+// seconds formats a duration as a number of seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// tokenBuckets holds a bucket per client, refilled at the given rate (per
+// second) up to the given capacity.
+type tokenBuckets struct {
+	rate      float64
+	capacity  float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mtx       sync.Mutex
+}
+
+func newTokenBuckets(rate float64, capacity float64) *tokenBuckets {
+	return &tokenBuckets{
+		rate:     rate,
+		capacity: capacity,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// refill returns the bucket of the client, refilled up to now. The lock must
+// be held.
+func (b *tokenBuckets) refill(key string, now time.Time) *bucket {
+	b.sweep(now)
+
+	current, exists := b.buckets[key]
+	if !exists {
+		current = &bucket{tokens: b.capacity, updated: now}
+		b.buckets[key] = current
+	}
+
+	current.tokens = min(b.capacity, current.tokens+now.Sub(current.updated).Seconds()*b.rate)
+	current.updated = now
+
+	return current
+}
+
+// sweep forgets the full buckets from time to time, as they are the same as
+// new ones. The lock must be held.
+func (b *tokenBuckets) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < time.Minute {
+		return
+	}
+
+	b.lastSweep = now
+	for key, current := range b.buckets {
+		if current.tokens+now.Sub(current.updated).Seconds()*b.rate >= b.capacity {
+			delete(b.buckets, key)
+		}
+	}
+}
+
+// take takes n tokens if there are enough of them, and returns how many are
+// left, or how long to wait for them otherwise.
+func (b *tokenBuckets) take(key string, now time.Time, n float64) (allowed bool, remaining float64, wait time.Duration) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	current := b.refill(key, now)
+	if current.tokens < n {
+		return false, current.tokens, b.duration(n - current.tokens)
+	}
+
+	current.tokens -= n
+	return true, current.tokens, 0
+}
+
+// debt returns how long to wait for the bucket not to be in debt anymore
+func (b *tokenBuckets) debt(key string, now time.Time) time.Duration {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	current := b.refill(key, now)
+	if current.tokens >= 0 {
+		return 0
+	}
+
+	return b.duration(-current.tokens)
+}
+
+// consume takes n tokens, even if it puts the bucket into debt
+func (b *tokenBuckets) consume(key string, now time.Time, n float64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.refill(key, now).tokens -= n
+}
+
+// untilFull returns how long it takes to refill a bucket with the given
+// tokens
+func (b *tokenBuckets) untilFull(tokens float64) time.Duration {
+	return b.duration(b.capacity - tokens)
+}
+
+// duration returns how long it takes to refill n tokens
+func (b *tokenBuckets) duration(n float64) time.Duration {
+	return time.Duration(n / b.rate * float64(time.Second))
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Taluu/media-go/pkg/auth"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	request := func(remoteAddr string, principal *auth.Principal, apiKey string, body string) *http.Request {
+		r := httptest.NewRequest("POST", "/medias", strings.NewReader(body))
+		r.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			r.Header.Set("X-Api-Key", apiKey)
+		}
+
+		if principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), *principal))
+		}
+
+		return r
+	}
+
+	t.Run("requests", func(t *testing.T) {
+		handler := RateLimitMiddleware(RateLimit{Requests: 2})(next)
+
+		for i, expectedRemaining := range []string{"1", "0"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request("192.0.2.1:1234", nil, "", ""))
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("expected the request %d to be allowed, got %d", i, w.Code)
+			}
+
+			if remaining := w.Header().Get("RateLimit-Remaining"); remaining != expectedRemaining {
+				t.Errorf("expected %s remaining requests, got %q", expectedRemaining, remaining)
+			}
+
+			if limit := w.Header().Get("RateLimit-Limit"); limit != "2" {
+				t.Errorf("expected a limit of 2, got %q", limit)
+			}
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request("192.0.2.1:5678", nil, "", ""))
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected a %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+
+		// a request is refilled every 30 seconds
+		if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 29 || retry > 30 {
+			t.Errorf("expected to retry after 30 seconds, got %q", w.Header().Get("Retry-After"))
+		}
+
+		var response struct {
+			Code  int    `json:"code"`
+			Error string `json:"error"`
+		}
+
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Code != http.StatusTooManyRequests || response.Error != "too many requests" {
+			t.Errorf("expected a too many requests json error, got %+v", response)
+		}
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		handler := RateLimitMiddleware(RateLimit{Requests: 1})(next)
+
+		testCases := []struct {
+			name         string
+			request      *http.Request
+			expectedCode int
+		}{
+			{name: "first ip", request: request("192.0.2.1:1234", nil, "", ""), expectedCode: http.StatusNoContent},
+			{name: "same ip", request: request("192.0.2.1:1234", nil, "", ""), expectedCode: http.StatusTooManyRequests},
+			{name: "other ip", request: request("192.0.2.2:1234", nil, "", ""), expectedCode: http.StatusNoContent},
+			{name: "unverified api key", request: request("192.0.2.1:1234", nil, "forged", ""), expectedCode: http.StatusTooManyRequests},
+			{name: "other unverified api key", request: request("192.0.2.1:1234", nil, "forged-again", ""), expectedCode: http.StatusTooManyRequests},
+			{name: "principal", request: request("192.0.2.1:1234", &auth.Principal{ID: "alice"}, "secret", ""), expectedCode: http.StatusNoContent},
+			{name: "same principal", request: request("192.0.2.2:1234", &auth.Principal{ID: "alice"}, "", ""), expectedCode: http.StatusTooManyRequests},
+			{name: "same principal of another tenant", request: request("192.0.2.2:1234", &auth.Principal{ID: "alice", Tenant: "acme"}, "", ""), expectedCode: http.StatusNoContent},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, tc.request)
+
+				if w.Code != tc.expectedCode {
+					t.Errorf("expected a %d, got %d", tc.expectedCode, w.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("proxies", func(t *testing.T) {
+		handler := RateLimitMiddleware(RateLimit{Requests: 1, TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})(next)
+
+		forwardedFor := func(remoteAddr string, client string) *http.Request {
+			r := request(remoteAddr, nil, "", "")
+			r.Header.Set("X-Forwarded-For", client)
+			return r
+		}
+
+		testCases := []struct {
+			name         string
+			request      *http.Request
+			expectedCode int
+		}{
+			{name: "first client", request: forwardedFor("10.0.0.1:1234", "192.0.2.1"), expectedCode: http.StatusNoContent},
+			{name: "other client behind the same proxy", request: forwardedFor("10.0.0.1:1234", "192.0.2.2"), expectedCode: http.StatusNoContent},
+			{name: "same client behind another proxy", request: forwardedFor("10.0.0.2:1234", "192.0.2.1"), expectedCode: http.StatusTooManyRequests},
+			{name: "forged by an untrusted client", request: forwardedFor("192.0.2.3:1234", "192.0.2.4"), expectedCode: http.StatusNoContent},
+			{name: "same untrusted client", request: forwardedFor("192.0.2.3:1234", "192.0.2.5"), expectedCode: http.StatusTooManyRequests},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, tc.request)
+
+				if w.Code != tc.expectedCode {
+					t.Errorf("expected a %d, got %d", tc.expectedCode, w.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		handler := RateLimitMiddleware(RateLimit{Bytes: 10})(next)
+
+		// the first request goes over the budget, which then has to be refilled
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request("192.0.2.1:1234", nil, "", strings.Repeat("a", 30)))
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected the first request to be allowed, got %d", w.Code)
+		}
+
+		if w.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("expected no request limit header, got %q", w.Header().Get("RateLimit-Limit"))
+		}
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, request("192.0.2.1:1234", nil, "", "a"))
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected a %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+
+		if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 1 || retry > 2 {
+			t.Errorf("expected to retry after 2 seconds, got %q", w.Header().Get("Retry-After"))
+		}
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, request("192.0.2.2:1234", nil, "", "a"))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected another client to be allowed, got %d", w.Code)
+		}
+	})
+}
+
+func TestTokenBuckets(t *testing.T) {
+	buckets := newTokenBuckets(1, 2)
+	now := time.Now()
+
+	for i := range 2 {
+		if allowed, _, _ := buckets.take("client", now, 1); !allowed {
+			t.Fatalf("expected the token %d to be taken", i)
+		}
+	}
+
+	if allowed, _, wait := buckets.take("client", now, 1); allowed || wait != time.Second {
+		t.Fatalf("expected to wait a second for a token, got %t and %s", allowed, wait)
+	}
+
+	// the bucket is refilled up to its capacity
+	now = now.Add(time.Hour)
+	if allowed, remaining, _ := buckets.take("client", now, 1); !allowed || remaining != 1 {
+		t.Fatalf("expected a refilled bucket, got %t and %f remaining", allowed, remaining)
+	}
+
+	buckets.consume("client", now, 4)
+	if wait := buckets.debt("client", now); wait != 3*time.Second {
+		t.Errorf("expected to wait 3 seconds for the debt, got %s", wait)
+	}
+
+	if wait := buckets.debt("client", now.Add(3*time.Second)); wait != 0 {
+		t.Errorf("expected the debt to be paid, got %s", wait)
+	}
+
+	if len(buckets.buckets) != 1 {
+		t.Fatalf("expected a single bucket, got %d", len(buckets.buckets))
+	}
+
+	// the full buckets are forgotten
+	buckets.take("other", now.Add(time.Hour), 1)
+	if _, exists := buckets.buckets["client"]; exists || len(buckets.buckets) != 1 {
+		t.Errorf("expected the full bucket to be forgotten, got %d buckets", len(buckets.buckets))
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// RequestIDHeader identifies a request, in its logs and in its response
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware identifies each request with the id given by the
+// client, or a generated one if it has none or an invalid one. The id is sent
+// back in the response, and added to the logger of the request.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.With(r.Context(), "request_id", id)))
+	})
+}
+
+// validRequestID tells whether an id given by a client is short enough and
+// only made of visible ascii characters, so that it can be logged as is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, c := range []byte(id) {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
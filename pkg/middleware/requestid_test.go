@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		honoured  bool
+	}{
+		{name: "given id", requestID: "request-1", honoured: true},
+		{name: "no id", requestID: ""},
+		{name: "too long id", requestID: strings.Repeat("a", 129)},
+		{name: "id with a newline", requestID: "request-1\nforged log line"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logging.LoggerFrom(r.Context()).Info("handled")
+			})
+
+			r := httptest.NewRequest("GET", "/tags", nil)
+			r.Header.Set(RequestIDHeader, tc.requestID)
+			r = r.WithContext(logging.WithLogger(r.Context(), logging.NewLogger(&output, slog.LevelInfo)))
+
+			w := httptest.NewRecorder()
+			RequestIDMiddleware(next).ServeHTTP(w, r)
+
+			requestID := w.Header().Get(RequestIDHeader)
+			if tc.honoured && requestID != tc.requestID {
+				t.Errorf("expected the request id %q, got %q", tc.requestID, requestID)
+			}
+
+			if _, err := uuid.Parse(requestID); !tc.honoured && err != nil {
+				t.Errorf("expected a generated request id, got %q", requestID)
+			}
+
+			var line struct {
+				RequestID string `json:"request_id"`
+			}
+
+			json.Unmarshal(output.Bytes(), &line)
+			if line.RequestID != requestID {
+				t.Errorf("expected the logs to hold the request id %q, got %q", requestID, output.String())
+			}
+		})
+	}
+}
+
+func TestLogMiddleware(t *testing.T) {
+	var output bytes.Buffer
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	r := httptest.NewRequest("GET", "/tags", nil)
+	r = r.WithContext(logging.WithLogger(r.Context(), logging.NewLogger(&output, slog.LevelInfo)))
+
+	LogMiddleware(next).ServeHTTP(httptest.NewRecorder(), r)
+
+	var line struct {
+		Message string `json:"msg"`
+		Method  string `json:"method"`
+		Path    string `json:"path"`
+		Status  int    `json:"status"`
+		Bytes   int64  `json:"bytes"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &line); err != nil {
+		t.Fatalf("expected a single json line, got %q : %s", output.String(), err)
+	}
+
+	if line.Message != "request handled" || line.Method != "GET" || line.Path != "/tags" {
+		t.Errorf("expected the request to be logged, got %q", output.String())
+	}
+
+	if line.Status != http.StatusOK || line.Bytes != 5 {
+		t.Errorf("expected an implicit 200 of 5 bytes, got %d of %d bytes", line.Status, line.Bytes)
+	}
+}
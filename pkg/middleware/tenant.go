@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/auth"
+	"github.com/Taluu/media-go/pkg/logging"
+)
+
+// TenantMiddleware scopes the requests to a tenant. The tenant of the
+// principal is used if it has one, and can't be overridden ; otherwise, it
+// is read from the given header, or from the tenant query parameter of the
+// links built for the other tenants. Without any of them, the request is
+// scoped to the default tenant.
+//
+// It must be wrapped by the AuthMiddleware, so that the principal is known.
+func TenantMiddleware(header string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested := r.URL.Query().Get("tenant")
+			if header != "" && r.Header.Get(header) != "" {
+				requested = r.Header.Get(header)
+			}
+
+			if !auth.ValidTenant(requested) {
+				logging.LoggerFrom(r.Context()).Warn("invalid tenant", "tenant", requested)
+				jsonError(w, "invalid tenant", http.StatusBadRequest)
+				return
+			}
+
+			tenant := requested
+			if principal, ok := auth.PrincipalFrom(r.Context()); ok && principal.Tenant != "" {
+				// it comes from the credentials, which are not trusted to
+				// scope the keys either
+				if !auth.ValidTenant(principal.Tenant) {
+					logging.LoggerFrom(r.Context()).Warn("invalid tenant of the principal", "principal_tenant", principal.Tenant)
+					jsonError(w, "invalid tenant", http.StatusForbidden)
+					return
+				}
+
+				if requested != "" && requested != principal.Tenant {
+					logging.LoggerFrom(r.Context()).Warn("the principal can't access the tenant", "principal_tenant", principal.Tenant, "tenant", requested)
+					jsonError(w, "forbidden", http.StatusForbidden)
+					return
+				}
+
+				tenant = principal.Tenant
+			}
+
+			ctx := auth.WithTenant(r.Context(), tenant)
+			if tenant != "" {
+				ctx = logging.With(ctx, "tenant", tenant)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
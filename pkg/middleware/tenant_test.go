@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Taluu/media-go/pkg/auth"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	var gotTenant string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTenant = auth.TenantFrom(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	testCases := []struct {
+		name           string
+		principal      *auth.Principal
+		header         string
+		query          string
+		expectedCode   int
+		expectedTenant string
+	}{
+		{name: "default tenant", expectedCode: http.StatusNoContent},
+		{name: "from the header", header: "acme", expectedCode: http.StatusNoContent, expectedTenant: "acme"},
+		{name: "from the query", query: "acme", expectedCode: http.StatusNoContent, expectedTenant: "acme"},
+		{name: "header over query", header: "acme", query: "globex", expectedCode: http.StatusNoContent, expectedTenant: "acme"},
+		{name: "invalid tenant", header: "../acme", expectedCode: http.StatusBadRequest},
+		{name: "from the principal", principal: &auth.Principal{ID: "alice", Tenant: "acme"}, expectedCode: http.StatusNoContent, expectedTenant: "acme"},
+		{name: "same as the principal", principal: &auth.Principal{ID: "alice", Tenant: "acme"}, header: "acme", expectedCode: http.StatusNoContent, expectedTenant: "acme"},
+		{name: "invalid tenant of the principal", principal: &auth.Principal{ID: "alice", Tenant: "../acme"}, expectedCode: http.StatusForbidden},
+		{name: "other than the principal", principal: &auth.Principal{ID: "alice", Tenant: "acme"}, header: "globex", expectedCode: http.StatusForbidden},
+		{name: "principal without a tenant", principal: &auth.Principal{ID: "bob"}, header: "globex", expectedCode: http.StatusNoContent, expectedTenant: "globex"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotTenant = ""
+
+			r := httptest.NewRequest("GET", "/tags", nil)
+			if tc.query != "" {
+				r.URL.RawQuery = "tenant=" + tc.query
+			}
+
+			if tc.header != "" {
+				r.Header.Set("X-Tenant", tc.header)
+			}
+
+			if tc.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), *tc.principal))
+			}
+
+			w := httptest.NewRecorder()
+			TenantMiddleware("X-Tenant")(next).ServeHTTP(w, r)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected a %d, got %d", tc.expectedCode, w.Code)
+			}
+
+			if gotTenant != tc.expectedTenant {
+				t.Errorf("expected the tenant %q, got %q", tc.expectedTenant, gotTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Taluu/media-go/pkg/logging"
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+// TracingMiddleware starts a server span for each request, child of the span
+// propagated by its client in the traceparent header, if any. The trace is
+// added to the logger of the request.
+//
+// It must wrap the ServeMux, so that the span is named after the route the
+// request matched.
+func TracingMiddleware(tracer *tracing.Tracer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracer.StartServer(tracing.Extract(r.Context(), r.Header), r.Method,
+				"http.request.method", r.Method,
+				"url.path", r.URL.Path,
+			)
+
+			ctx = logging.With(ctx, "trace_id", span.Context.TraceID.String())
+
+			extendedWriter := &customResponseWriter{ResponseWriter: w}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(extendedWriter, r)
+
+			if r.Pattern != "" {
+				span.SetName(r.Pattern)
+				span.SetAttributes("http.route", r.Pattern)
+			}
+
+			span.SetAttributes("http.response.status_code", extendedWriter.status())
+
+			// only the server errors are failures of the server span
+			var err error
+			if extendedWriter.status() >= http.StatusInternalServerError {
+				err = fmt.Errorf("status %d", extendedWriter.status())
+			}
+
+			span.Finish(err)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Taluu/media-go/pkg/tracing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.NewTracer(exporter)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /medias/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "MediaService.SearchByTag")
+		span.Finish(nil)
+	})
+
+	mux.HandleFunc("POST /medias", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	handler := TracingMiddleware(tracer)(mux)
+
+	testCases := []struct {
+		name           string
+		request        *http.Request
+		traceparent    string
+		expectedName   string
+		expectedStatus int
+		expectedFailed bool
+	}{
+		{name: "route", request: httptest.NewRequest("GET", "/medias/tag-1", nil), expectedName: "GET /medias/{tag}", expectedStatus: http.StatusOK},
+		{name: "propagated trace", request: httptest.NewRequest("GET", "/medias/tag-1", nil), traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectedName: "GET /medias/{tag}", expectedStatus: http.StatusOK},
+		{name: "server error", request: httptest.NewRequest("POST", "/medias", nil), expectedName: "POST /medias", expectedStatus: http.StatusInternalServerError, expectedFailed: true},
+		{name: "no route", request: httptest.NewRequest("GET", "/unknown", nil), expectedName: "GET", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer exporter.Reset()
+
+			if tc.traceparent != "" {
+				tc.request.Header.Set(tracing.TraceparentHeader, tc.traceparent)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), tc.request)
+
+			spans := exporter.Spans()
+			server := spans[len(spans)-1]
+
+			if server.Name != tc.expectedName || server.Kind != tracing.SpanKindServer {
+				t.Errorf("expected a server span named %q, got %q", tc.expectedName, server.Name)
+			}
+
+			if server.Attributes["http.response.status_code"] != tc.expectedStatus {
+				t.Errorf("expected the status %d, got %v", tc.expectedStatus, server.Attributes["http.response.status_code"])
+			}
+
+			if (server.Err != nil) != tc.expectedFailed {
+				t.Errorf("expected the span to be failed : %t, got %v", tc.expectedFailed, server.Err)
+			}
+
+			if tc.traceparent != "" && (server.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent.String() != "00f067aa0ba902b7") {
+				t.Errorf("expected the span to be a child of the propagated one, got %s and %s", server.Context.TraceID, server.Parent)
+			}
+
+			for _, span := range spans[:len(spans)-1] {
+				if span.Parent != server.Context.SpanID {
+					t.Errorf("expected the span %q to be a child of the server one", span.Name)
+				}
+			}
+		})
+	}
+}
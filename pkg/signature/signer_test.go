@@ -0,0 +1,59 @@
+package signature
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	signer := NewSigner([]byte("current"), []byte("previous"))
+	previous := NewSigner([]byte("previous"))
+	revoked := NewSigner([]byte("revoked"))
+
+	tampered := signer.Sign("GET", "/viewer/foo", now.Add(time.Minute))
+	tampered.Set(ExpiresParameter, "4102444800")
+
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		query    url.Values
+		expected error
+	}{
+		{name: "valid", method: "GET", path: "/viewer/foo", query: signer.Sign("GET", "/viewer/foo", now.Add(time.Minute))},
+		{name: "signed with a previous key", method: "GET", path: "/viewer/foo", query: previous.Sign("GET", "/viewer/foo", now.Add(time.Minute))},
+		{name: "signed with a revoked key", method: "GET", path: "/viewer/foo", query: revoked.Sign("GET", "/viewer/foo", now.Add(time.Minute)), expected: ErrInvalidSignature},
+		{name: "expired", method: "GET", path: "/viewer/foo", query: signer.Sign("GET", "/viewer/foo", now.Add(-time.Minute)), expected: ErrExpiredSignature},
+		{name: "other path", method: "GET", path: "/viewer/bar", query: signer.Sign("GET", "/viewer/foo", now.Add(time.Minute)), expected: ErrInvalidSignature},
+		{name: "other method", method: "PUT", path: "/viewer/foo", query: signer.Sign("GET", "/viewer/foo", now.Add(time.Minute)), expected: ErrInvalidSignature},
+		{name: "tampered expiration", method: "GET", path: "/viewer/foo", query: tampered, expected: ErrInvalidSignature},
+		{name: "missing", method: "GET", path: "/viewer/foo", query: url.Values{}, expected: ErrMissingSignature},
+		{name: "garbage", method: "GET", path: "/viewer/foo", query: url.Values{ExpiresParameter: {"soon"}, SignatureParameter: {"!!"}}, expected: ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := signer.Verify(tc.method, tc.path, tc.query, now)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	signer := NewSigner([]byte("current"))
+	expires := time.Unix(1700000000, 0)
+
+	signed, _ := url.Parse(signer.SignURL("GET", "/viewer/foo", expires))
+	if signed.Path != "/viewer/foo" {
+		t.Errorf("expected the path to be kept, got %q", signed.Path)
+	}
+
+	if err := signer.Verify("GET", signed.Path, signed.Query(), expires); err != nil {
+		t.Errorf("expected the signed url to be valid, got %s", err)
+	}
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import "sync"
+
+// NoopExporter drops the spans, so that only the trace context is propagated
+type NoopExporter struct{}
+
+// Export implements Exporter.
+func (NoopExporter) Export(span Span) {}
+
+// InMemoryExporter keeps the spans in memory, to be inspected by the tests
+type InMemoryExporter struct {
+	spans []Span
+	mtx   sync.Mutex
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export implements Exporter.
+func (e *InMemoryExporter) Export(span Span) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans, in the order they finished
+func (e *InMemoryExporter) Spans() []Span {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	spans := make([]Span, len(e.spans))
+	copy(spans, e.spans)
+
+	return spans
+}
+
+// Reset forgets the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.spans = nil
+}
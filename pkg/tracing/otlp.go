@@ -0,0 +1,260 @@
+package tracing
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OTLPConfig configures the export of the spans to an OpenTelemetry collector
+type OTLPConfig struct {
+	// Endpoint is the url the spans are posted to, such as
+	// http://localhost:4318/v1/traces
+	Endpoint string
+	// ServiceName names this application in the traces
+	ServiceName string
+	// Interval between two exports, 5 seconds by default
+	Interval time.Duration
+	// MaxQueueSize is the number of spans waiting to be exported, after which
+	// the next ones are dropped, 2048 by default
+	MaxQueueSize int
+	// Client posts the spans, http.DefaultClient by default
+	Client *http.Client
+}
+
+// OTLPExporter periodically posts the spans to an OpenTelemetry collector,
+// with the OTLP/HTTP protocol in its json encoding. It must be closed to
+// export the last spans.
+type OTLPExporter struct {
+	config OTLPConfig
+	queue  []Span
+	// dropped counts the spans dropped since the last export, so that they
+	// are logged once per export rather than once per span
+	dropped int
+	mtx     sync.Mutex
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewOTLPExporter(config OTLPConfig) *OTLPExporter {
+	config.Interval = cmp.Or(config.Interval, 5*time.Second)
+	config.MaxQueueSize = cmp.Or(config.MaxQueueSize, 2048)
+	config.ServiceName = cmp.Or(config.ServiceName, "media-api")
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	e := &OTLPExporter{
+		config: config,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go e.loop()
+
+	return e
+}
+
+// Export implements Exporter.
+func (e *OTLPExporter) Export(span Span) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if len(e.queue) >= e.config.MaxQueueSize {
+		e.dropped++
+		return
+	}
+
+	e.queue = append(e.queue, span)
+}
+
+// Flush posts the spans waiting to be exported
+func (e *OTLPExporter) Flush(ctx context.Context) error {
+	e.mtx.Lock()
+	spans, dropped := e.queue, e.dropped
+	e.queue, e.dropped = nil, 0
+	e.mtx.Unlock()
+
+	if dropped > 0 {
+		slog.Warn("too many spans waiting to be exported, some were dropped", "dropped", dropped)
+	}
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := e.config.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("could not export %d spans : %w", len(spans), err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("could not export %d spans : unexpected status %d", len(spans), response.StatusCode)
+	}
+
+	return nil
+}
+
+// Close stops the periodic exports, and exports the last spans
+func (e *OTLPExporter) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.stop)
+	})
+
+	<-e.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return e.Flush(ctx)
+}
+
+func (e *OTLPExporter) loop() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), e.config.Interval)
+			if err := e.Flush(ctx); err != nil {
+				slog.Error("could not export the spans", "error", err)
+			}
+
+			cancel()
+		}
+	}
+}
+
+// the OTLP json encoding, where the ids are hexadecimal and the 64 bits
+// integers are strings
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// otlpStatusError is the status of a failed span
+const otlpStatusError = 2
+
+func (e *OTLPExporter) encode(spans []Span) otlpRequest {
+	encoded := make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		encoded[i] = otlpSpan{
+			TraceID:           span.Context.TraceID.String(),
+			SpanID:            span.Context.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.Attributes),
+		}
+
+		if span.Parent.IsValid() {
+			encoded[i].ParentSpanID = span.Parent.String()
+		}
+
+		if span.Err != nil {
+			encoded[i].Status = &otlpStatus{Code: otlpStatusError, Message: span.Err.Error()}
+		}
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: encodeAttributes(map[string]any{"service.name": e.config.ServiceName})},
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "github.com/Taluu/media-go"}, Spans: encoded}},
+		}},
+	}
+}
+
+func encodeAttributes(attributes map[string]any) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attributes))
+	for key, value := range attributes {
+		encoded = append(encoded, otlpAttribute{Key: key, Value: encodeValue(value)})
+	}
+
+	return encoded
+}
+
+func encodeValue(value any) map[string]any {
+	switch value := value.(type) {
+	case string:
+		return map[string]any{"stringValue": value}
+	case bool:
+		return map[string]any{"boolValue": value}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(value)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(value, 10)}
+	case float64:
+		return map[string]any{"doubleValue": value}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(value)}
+	}
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	received := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a json post to /v1/traces, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var request otlpRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		received <- request
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{Endpoint: collector.URL + "/v1/traces", ServiceName: "media-test", Interval: time.Hour})
+	tracer := NewTracer(exporter)
+
+	rootCtx, root := tracer.StartServer(ctx, "POST /medias")
+	_, child := tracer.StartClient(rootCtx, "MediaUploader.Upload", "media.id", "media-1", "size", 12)
+	child.Finish(errors.New("storage unavailable"))
+	root.Finish(nil)
+
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("could not close the exporter : %s", err)
+	}
+
+	var request otlpRequest
+	select {
+	case request = <-received:
+	case <-ctx.Done():
+		t.Fatalf("expected the spans to be exported once closed")
+	}
+
+	resource := request.ResourceSpans[0]
+	if resource.Resource.Attributes[0].Value["stringValue"] != "media-test" {
+		t.Errorf("expected the service name, got %v", resource.Resource.Attributes)
+	}
+
+	spans := resource.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	if spans[0].Name != "MediaUploader.Upload" || spans[0].Kind != SpanKindClient || spans[0].ParentSpanID != root.Context.SpanID.String() {
+		t.Errorf("expected the client span, child of the root one, got %+v", spans[0])
+	}
+
+	if spans[0].TraceID != root.Context.TraceID.String() || len(spans[0].TraceID) != 32 {
+		t.Errorf("expected the hexadecimal trace id %s, got %s", root.Context.TraceID, spans[0].TraceID)
+	}
+
+	if spans[0].Status == nil || spans[0].Status.Code != otlpStatusError || spans[0].Status.Message != "storage unavailable" {
+		t.Errorf("expected the span to be failed, got %+v", spans[0].Status)
+	}
+
+	attributes := make(map[string]map[string]any)
+	for _, attribute := range spans[0].Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+
+	if attributes["media.id"]["stringValue"] != "media-1" || attributes["size"]["intValue"] != "12" {
+		t.Errorf("expected the typed attributes, got %v", attributes)
+	}
+
+	if spans[1].ParentSpanID != "" || spans[1].Status != nil {
+		t.Errorf("expected a successful root span, got %+v", spans[1])
+	}
+}
+
+func TestOTLPExporterDroppedSpans(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	received := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request otlpRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		received <- request
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{Endpoint: collector.URL, Interval: time.Hour, MaxQueueSize: 1})
+	defer exporter.Close()
+
+	tracer := NewTracer(exporter)
+	for range 3 {
+		_, span := tracer.StartServer(ctx, "GET /medias")
+		span.Finish(nil)
+	}
+
+	if err := exporter.Flush(ctx); err != nil {
+		t.Fatalf("could not flush the exporter : %s", err)
+	}
+
+	if spans := (<-received).ResourceSpans[0].ScopeSpans[0].Spans; len(spans) != 1 {
+		t.Errorf("expected 1 span to be exported, got %d", len(spans))
+	}
+
+	if lines := strings.Count(logs.String(), "\n"); lines != 1 || !strings.Contains(logs.String(), "dropped=2") {
+		t.Errorf("expected the 2 dropped spans to be logged once, got %q", logs.String())
+	}
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader propagates the span of a client, as defined by the W3C
+// trace context :
+//
+//	traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+const TraceparentHeader = "traceparent"
+
+var ErrInvalidTraceparent = fmt.Errorf("invalid traceparent")
+
+// ParseTraceparent parses the value of a traceparent header. The versions
+// after 00 are parsed as 00, as required by the specification.
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, fmt.Errorf("%w : %q", ErrInvalidTraceparent, value)
+	}
+
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("%w : unsupported version in %q", ErrInvalidTraceparent, value)
+	}
+
+	var spanContext SpanContext
+	var flags [1]byte
+
+	_, traceErr := hex.Decode(spanContext.TraceID[:], []byte(parts[1]))
+	_, spanErr := hex.Decode(spanContext.SpanID[:], []byte(parts[2]))
+	_, flagsErr := hex.Decode(flags[:], []byte(parts[3]))
+
+	// only the lowercase hexadecimal digits are allowed
+	if traceErr != nil || spanErr != nil || flagsErr != nil || strings.ToLower(value) != value || !spanContext.IsValid() {
+		return SpanContext{}, fmt.Errorf("%w : %q", ErrInvalidTraceparent, value)
+	}
+
+	spanContext.Sampled = flags[0]&1 == 1
+	return spanContext, nil
+}
+
+// FormatTraceparent returns the value of the traceparent header propagating
+// the span
+func FormatTraceparent(spanContext SpanContext) string {
+	flags := "00"
+	if spanContext.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + spanContext.TraceID.String() + "-" + spanContext.SpanID.String() + "-" + flags
+}
+
+// Extract returns a copy of the context whose spans are children of the one
+// propagated in the headers, if it is valid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	spanContext, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+
+	return WithSpanContext(ctx, spanContext)
+}
+
+// Inject propagates the span of the context in the headers, if any
+func Inject(ctx context.Context, header http.Header) {
+	if spanContext := SpanContextFrom(ctx); spanContext.IsValid() {
+		header.Set(TraceparentHeader, FormatTraceparent(spanContext))
+	}
+}
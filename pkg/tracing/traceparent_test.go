@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	testCases := []struct {
+		name            string
+		value           string
+		expectedSampled bool
+		expectedErr     error
+	}{
+		{name: "sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectedSampled: true},
+		{name: "not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "future version", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", expectedSampled: true},
+		{name: "empty", value: "", expectedErr: ErrInvalidTraceparent},
+		{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectedErr: ErrInvalidTraceparent},
+		{name: "extra field in version 00", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", expectedErr: ErrInvalidTraceparent},
+		{name: "uppercase", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", expectedErr: ErrInvalidTraceparent},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expectedErr: ErrInvalidTraceparent},
+		{name: "zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", expectedErr: ErrInvalidTraceparent},
+		{name: "not hexadecimal", value: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", expectedErr: ErrInvalidTraceparent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spanContext, err := ParseTraceparent(tc.value)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if spanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || spanContext.SpanID.String() != "00f067aa0ba902b7" {
+				t.Errorf("expected the ids to be parsed, got %s and %s", spanContext.TraceID, spanContext.SpanID)
+			}
+
+			if spanContext.Sampled != tc.expectedSampled {
+				t.Errorf("expected sampled to be %t, got %t", tc.expectedSampled, spanContext.Sampled)
+			}
+		})
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	incoming := http.Header{}
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, span := NewTracer(NoopExporter{}).Start(Extract(ctx, incoming), "operation")
+	defer span.Finish(nil)
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.Context.SpanID.String() + "-01"
+	if outgoing.Get(TraceparentHeader) != expected {
+		t.Errorf("expected the traceparent %q, got %q", expected, outgoing.Get(TraceparentHeader))
+	}
+
+	empty := http.Header{}
+	Inject(context.Background(), empty)
+	if len(empty) != 0 {
+		t.Errorf("expected nothing to be propagated without any span, got %v", empty)
+	}
+}
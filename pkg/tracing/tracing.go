@@ -0,0 +1,173 @@
+// Package tracing records spans of the work done for a request, propagated
+// with the W3C trace context, and exports them to an OpenTelemetry collector.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// TraceID identifies a trace, shared by all its spans
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid tells whether the id is not all zeros, which is invalid
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies a span within its trace
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid tells whether the id is not all zeros, which is invalid
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext is what is propagated of a span, to its children and to the
+// other services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled tells whether the spans of the trace are exported
+	Sampled bool
+}
+
+// IsValid tells whether the context identifies a span
+func (c SpanContext) IsValid() bool {
+	return c.TraceID.IsValid() && c.SpanID.IsValid()
+}
+
+// SpanKind tells the role of a span, numbered as in OpenTelemetry
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	// SpanKindServer handles a request of a client
+	SpanKindServer SpanKind = 2
+	// SpanKindClient calls another component, such as a storage
+	SpanKindClient SpanKind = 3
+)
+
+// Span is a timed operation of a trace
+type Span struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+	// Err is the error the operation failed with, if any
+	Err error
+
+	tracer *Tracer
+}
+
+// SetName renames the span, when its name is only known later on
+func (s *Span) SetName(name string) {
+	s.Name = name
+}
+
+// SetAttributes adds attributes to the span, given as alternating keys and
+// values
+func (s *Span) SetAttributes(attributes ...any) {
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if key, ok := attributes[i].(string); ok {
+			s.Attributes[key] = attributes[i+1]
+		}
+	}
+}
+
+// Finish ends the span, failed if the error is not nil, and exports it if
+// its trace is sampled
+func (s *Span) Finish(err error) {
+	s.End = time.Now()
+	s.Err = err
+
+	if s.Context.Sampled {
+		s.tracer.exporter.Export(*s)
+	}
+}
+
+// Exporter sends the finished spans somewhere. It must not block, as it is
+// called while handling the requests.
+type Exporter interface {
+	Export(span Span)
+}
+
+// Tracer starts the spans, and exports them once finished
+type Tracer struct {
+	exporter Exporter
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter}
+}
+
+// Start starts a span, child of the span of the context if any. The returned
+// context holds the new span.
+func (t *Tracer) Start(ctx context.Context, name string, attributes ...any) (context.Context, *Span) {
+	return t.start(ctx, SpanKindInternal, name, attributes)
+}
+
+// StartServer starts a span handling a request, child of the span of the
+// client if it was propagated
+func (t *Tracer) StartServer(ctx context.Context, name string, attributes ...any) (context.Context, *Span) {
+	return t.start(ctx, SpanKindServer, name, attributes)
+}
+
+// StartClient starts a span calling another component
+func (t *Tracer) StartClient(ctx context.Context, name string, attributes ...any) (context.Context, *Span) {
+	return t.start(ctx, SpanKindClient, name, attributes)
+}
+
+func (t *Tracer) start(ctx context.Context, kind SpanKind, name string, attributes []any) (context.Context, *Span) {
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: make(map[string]any, len(attributes)/2),
+		tracer:     t,
+	}
+
+	// a trace without any parent is always sampled
+	parent := SpanContextFrom(ctx)
+	if parent.IsValid() {
+		span.Context.TraceID = parent.TraceID
+		span.Context.Sampled = parent.Sampled
+		span.Parent = parent.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+		span.Context.Sampled = true
+	}
+
+	rand.Read(span.Context.SpanID[:])
+	span.SetAttributes(attributes...)
+
+	return context.WithValue(ctx, spanContextKey{}, span.Context), span
+}
+
+type spanContextKey struct{}
+
+// WithSpanContext returns a copy of the context whose spans are children of
+// the given one, such as a span propagated by a client
+func WithSpanContext(ctx context.Context, spanContext SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, spanContext)
+}
+
+// SpanContextFrom returns the context of the current span, invalid if there is
+// none
+func SpanContextFrom(ctx context.Context) SpanContext {
+	spanContext, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return spanContext
+}
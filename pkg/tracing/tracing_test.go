@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTracer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	t.Run("root and children", func(t *testing.T) {
+		defer exporter.Reset()
+
+		rootCtx, root := tracer.StartServer(ctx, "GET /medias/{tag}")
+		childCtx, child := tracer.Start(rootCtx, "MediaService.SearchByTag", "tag", "tag-1")
+		_, grandChild := tracer.StartClient(childCtx, "TagRegistry.GetMediaIDsForTag")
+
+		failure := errors.New("failure")
+		grandChild.Finish(failure)
+		child.Finish(nil)
+		root.Finish(nil)
+
+		spans := exporter.Spans()
+		if len(spans) != 3 {
+			t.Fatalf("expected 3 exported spans, got %d", len(spans))
+		}
+
+		if spans[2].Parent.IsValid() || spans[2].Kind != SpanKindServer {
+			t.Errorf("expected a root server span, got %+v", spans[2])
+		}
+
+		if spans[1].Parent != spans[2].Context.SpanID || spans[0].Parent != spans[1].Context.SpanID {
+			t.Errorf("expected the spans to be children of each other")
+		}
+
+		for _, span := range spans {
+			if span.Context.TraceID != root.Context.TraceID {
+				t.Errorf("expected the span %q to share the trace of its parent", span.Name)
+			}
+		}
+
+		if spans[1].Attributes["tag"] != "tag-1" {
+			t.Errorf("expected the attributes to be kept, got %v", spans[1].Attributes)
+		}
+
+		if spans[0].Err != failure || spans[0].Kind != SpanKindClient {
+			t.Errorf("expected a failed client span, got %+v", spans[0])
+		}
+
+		if spans[0].End.Before(spans[0].Start) {
+			t.Errorf("expected the span to end after it started")
+		}
+	})
+
+	t.Run("remote parent", func(t *testing.T) {
+		defer exporter.Reset()
+
+		parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		_, span := tracer.StartServer(WithSpanContext(ctx, parent), "GET /tags")
+		span.Finish(nil)
+
+		if span.Context.TraceID != parent.TraceID || span.Parent != parent.SpanID {
+			t.Errorf("expected the span to be a child of the remote one, got %+v", span)
+		}
+
+		if len(exporter.Spans()) != 1 {
+			t.Errorf("expected the sampled span to be exported")
+		}
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		defer exporter.Reset()
+
+		parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+		ctx, span := tracer.StartServer(WithSpanContext(ctx, parent), "GET /tags")
+		_, child := tracer.Start(ctx, "TagService.GetAll")
+		child.Finish(nil)
+		span.Finish(nil)
+
+		if len(exporter.Spans()) != 0 {
+			t.Errorf("expected the spans of a trace not sampled not to be exported, got %d", len(exporter.Spans()))
+		}
+	})
+}